@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/moderation"
+)
+
+const testSecret = "test-secret"
+
+// fakeDB - a database/sql driver that answers sqlc queries by their "-- name:" line, so
+// handlers run without Postgres; a query nobody answers returns no rows
+type fakeDB struct {
+	mu      sync.Mutex
+	answers map[string]func(args []driver.NamedValue) [][]driver.Value
+	ran     []string
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{answers: map[string]func([]driver.NamedValue) [][]driver.Value{}}
+}
+
+// answer - rows for every run of the query called name
+func (db *fakeDB) answer(name string, rows ...[]driver.Value) {
+	db.answers[name] = func([]driver.NamedValue) [][]driver.Value { return rows }
+}
+
+// answerFunc - rows depending on the query's arguments
+func (db *fakeDB) answerFunc(name string, fn func(args []driver.NamedValue) [][]driver.Value) {
+	db.answers[name] = fn
+}
+
+// didRun - whether the query called name ran at all
+func (db *fakeDB) didRun(name string) bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, q := range db.ran {
+		if q == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (db *fakeDB) rows(query string, args []driver.NamedValue) [][]driver.Value {
+	_, name, _ := strings.Cut(query, "-- name: ")
+	name, _, _ = strings.Cut(name, " ")
+
+	db.mu.Lock()
+	db.ran = append(db.ran, name)
+	fn := db.answers[name]
+	db.mu.Unlock()
+
+	if fn == nil {
+		return nil
+	}
+	return fn(args)
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return fakeTx{}, nil }
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{rows: c.db.rows(query, args)}, nil
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(len(c.db.rows(query, args))), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next == len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+// testConfig - an apiConfig on db with the default moderation rules
+func testConfig(t *testing.T, db *fakeDB) *apiConfig {
+	t.Helper()
+	conn := sql.OpenDB(db)
+	t.Cleanup(func() { conn.Close() })
+
+	moderator, words, err := moderation.New(moderation.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &apiConfig{
+		db:              database.New(conn),
+		dbConn:          conn,
+		secretKey:       testSecret,
+		moderator:       moderator,
+		moderationWords: words,
+	}
+}
+
+// serve - runs handler on a request with the path values set, as the mux would
+func serve(handler http.HandlerFunc, r *http.Request, pathValues map[string]string) *httptest.ResponseRecorder {
+	for k, v := range pathValues {
+		r.SetPathValue(k, v)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+// bearer - sets an access token for userID on r
+func bearer(t *testing.T, r *http.Request, userID uuid.UUID) *http.Request {
+	t.Helper()
+	token, err := auth.MakeJWT(userID, testSecret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func nullable[T any](v T, valid bool) driver.Value {
+	if !valid {
+		return nil
+	}
+	return v
+}
+
+func nullUUID(id uuid.NullUUID) driver.Value {
+	return nullable(id.UUID.String(), id.Valid)
+}
+
+// userRow - u the way users.* comes back from Postgres
+func userRow(u database.User) []driver.Value {
+	return []driver.Value{
+		u.ID.String(), u.CreatedAt, u.UpdatedAt,
+		nullable(u.Email.String, u.Email.Valid),
+		nullable(u.HashedPassword.String, u.HashedPassword.Valid),
+		u.IsChirpyRed, u.IsAdmin,
+		nullable(u.SuspendedAt.Time, u.SuspendedAt.Valid),
+		nullable(u.Handle.String, u.Handle.Valid),
+		u.DisplayName, u.Bio, u.AvatarUrl,
+		nullable(u.EmailVerifiedAt.Time, u.EmailVerifiedAt.Valid),
+		nullable(u.ActorUri.String, u.ActorUri.Valid),
+	}
+}
+
+// chirpRow - c the way chirps.* comes back from Postgres
+func chirpRow(c database.Chirp) []driver.Value {
+	return []driver.Value{
+		c.ID.String(), c.CreatedAt, c.UpdatedAt, c.Body, c.UserID.String(), nil,
+		int64(c.RevisionCount), nullUUID(c.InReplyTo), c.ConversationID.String(),
+		nullable(c.DeletedAt.Time, c.DeletedAt.Valid),
+		int64(c.LikeCount), int64(c.RechirpCount), nullUUID(c.RechirpOf), c.Status,
+		nullable(c.PublishAt.Time, c.PublishAt.Valid),
+		nullable(c.HiddenAt.Time, c.HiddenAt.Valid),
+		c.Visibility,
+	}
+}
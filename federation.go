@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/internal/activitypub"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/visibility"
+)
+
+const (
+	actorPath = "/ap/users/"
+	notePath  = "/ap/chirps/"
+)
+
+var errLocalActor = errors.New("actor belongs to this instance")
+
+// actorURL - ids handed to other instances are built from BASE_URL, never from a request's Host
+func (cfg *apiConfig) actorURL(userID uuid.UUID) string {
+	return cfg.baseURL + actorPath + userID.String()
+}
+
+func (cfg *apiConfig) keyID(userID uuid.UUID) string {
+	return cfg.actorURL(userID) + "#main-key"
+}
+
+func (cfg *apiConfig) noteURL(chirpID uuid.UUID) string {
+	return cfg.baseURL + notePath + chirpID.String()
+}
+
+// localID - the id in an actor or note URL this instance handed out, false for anything else
+func (cfg *apiConfig) localID(objectURL, prefix string) (uuid.UUID, bool) {
+	rest, ok := strings.CutPrefix(objectURL, cfg.baseURL+prefix)
+	if !ok {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(rest)
+	return id, err == nil
+}
+
+// objectURL - a chirp's ActivityPub id: the Note it came from for remote chirps
+func (cfg *apiConfig) objectURL(ctx context.Context, chirpID uuid.UUID) (string, error) {
+	objectURL, err := cfg.db.GetRemoteChirpURL(ctx, chirpID)
+	if err == sql.ErrNoRows {
+		return cfg.noteURL(chirpID), nil
+	}
+	return objectURL, err
+}
+
+// chirpByObjectURL - the local or remote chirp an object URL names; sql.ErrNoRows when this
+// instance does not have it
+func (cfg *apiConfig) chirpByObjectURL(ctx context.Context, objectURL string) (database.Chirp, error) {
+	chirpID, ok := cfg.localID(objectURL, notePath)
+	if !ok {
+		var err error
+		chirpID, err = cfg.db.GetRemoteChirpID(ctx, objectURL)
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	return cfg.db.GetChirp(ctx, chirpID)
+}
+
+func (cfg *apiConfig) isRemoteUser(ctx context.Context, userID uuid.UUID) (bool, error) {
+	_, err := cfg.db.GetRemoteActor(ctx, userID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// federatable - only public, published chirps of local users leave the instance as notes; plain
+// rechirps go out as an Announce instead, see federateRechirp
+func (cfg *apiConfig) federatable(ctx context.Context, chirp database.Chirp) (bool, error) {
+	if chirp.Status != chirpStatusPublished || chirp.HiddenAt.Valid ||
+		visibility.Level(chirp.Visibility) != visibility.Public ||
+		(chirp.RechirpOf.Valid && chirp.Body == "") {
+		return false, nil
+	}
+	remote, err := cfg.isRemoteUser(ctx, chirp.UserID)
+	return !remote, err
+}
+
+// noteOf - the Note a local chirp is published as
+func (cfg *apiConfig) noteOf(ctx context.Context, chirp database.Chirp) (activitypub.Note, error) {
+	note := activitypub.Note{
+		ID:           cfg.noteURL(chirp.ID),
+		Type:         activitypub.TypeNote,
+		AttributedTo: cfg.actorURL(chirp.UserID),
+		Content:      activitypub.Content(chirp.Body),
+		Published:    chirp.CreatedAt.UTC(),
+		URL:          fmt.Sprintf("%s/api/chirps/%s", cfg.baseURL, chirp.ID),
+		To:           []string{activitypub.Public},
+		CC:           []string{cfg.actorURL(chirp.UserID) + "/followers"},
+	}
+	if chirp.InReplyTo.Valid {
+		inReplyTo, err := cfg.objectURL(ctx, chirp.InReplyTo.UUID)
+		if err != nil {
+			return activitypub.Note{}, err
+		}
+		note.InReplyTo = inReplyTo
+	}
+	return note, nil
+}
+
+// federate - hands job to the delivery worker; a no-op when federation is off (no BASE_URL)
+func (cfg *apiConfig) federate(job activitypub.Job) {
+	if cfg.federation == nil {
+		return
+	}
+	if !cfg.federation.Enqueue(job) {
+		log.Printf("activitypub: queue full, dropped a delivery\n")
+	}
+}
+
+// federateChirp - a chirp that just went public goes to the author's remote followers and,
+// for a reply, to the remote author it replies to
+func (cfg *apiConfig) federateChirp(chirpID uuid.UUID) {
+	cfg.federate(func(ctx context.Context) error {
+		chirp, err := cfg.db.GetChirp(ctx, chirpID)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if ok, err := cfg.federatable(ctx, chirp); err != nil || !ok || chirp.DeletedAt.Valid {
+			return err
+		}
+
+		note, err := cfg.noteOf(ctx, chirp)
+		if err != nil {
+			return err
+		}
+		activity, err := activitypub.NewActivity(note.ID+"/activity", activitypub.TypeCreate, note.AttributedTo, note)
+		if err != nil {
+			return err
+		}
+		activity.To, activity.CC = note.To, note.CC
+
+		inboxes, err := cfg.db.ListFollowerInboxes(ctx, chirp.UserID)
+		if err != nil {
+			return err
+		}
+		if chirp.InReplyTo.Valid {
+			parent, err := cfg.db.GetChirp(ctx, chirp.InReplyTo.UUID)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+			remote, err := cfg.db.GetRemoteActor(ctx, parent.UserID)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+			if err == nil && !slices.Contains(inboxes, remote.InboxUrl) {
+				inboxes = append(inboxes, remote.InboxUrl)
+			}
+		}
+		return cfg.deliverActivity(ctx, chirp.UserID, activity, inboxes)
+	})
+}
+
+// federateDelete - chirp is the row as it was before it was deleted, which may have removed it
+func (cfg *apiConfig) federateDelete(chirp database.Chirp) {
+	cfg.federate(func(ctx context.Context) error {
+		if ok, err := cfg.federatable(ctx, chirp); err != nil || !ok {
+			return err
+		}
+
+		noteID := cfg.noteURL(chirp.ID)
+		activity, err := activitypub.NewActivity(noteID+"/delete", activitypub.TypeDelete, cfg.actorURL(chirp.UserID),
+			activitypub.Tombstone{ID: noteID, Type: "Tombstone"})
+		if err != nil {
+			return err
+		}
+		activity.To = []string{activitypub.Public}
+
+		inboxes, err := cfg.db.ListFollowerInboxes(ctx, chirp.UserID)
+		if err != nil {
+			return err
+		}
+		return cfg.deliverActivity(ctx, chirp.UserID, activity, inboxes)
+	})
+}
+
+// federateFollow - a Follow, or with undo its Undo, when followeeID is a remote user
+func (cfg *apiConfig) federateFollow(followerID, followeeID uuid.UUID, undo bool) {
+	cfg.federate(func(ctx context.Context) error {
+		remote, err := cfg.db.GetRemoteActor(ctx, followeeID)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		actor := cfg.actorURL(followerID)
+		activity, err := activitypub.NewActivity(fmt.Sprintf("%s#follows/%s", actor, followeeID), activitypub.TypeFollow, actor, remote.ActorUrl)
+		if err != nil {
+			return err
+		}
+		if undo {
+			if activity, err = activitypub.NewActivity(activity.ID+"/undo", activitypub.TypeUndo, actor, activity); err != nil {
+				return err
+			}
+		}
+		return cfg.deliverActivity(ctx, followerID, activity, []string{remote.InboxUrl})
+	})
+}
+
+// federateLike - a Like, or with undo its Undo, when the chirp came from another instance
+func (cfg *apiConfig) federateLike(userID, chirpID uuid.UUID, undo bool) {
+	cfg.federate(func(ctx context.Context) error {
+		objectURL, err := cfg.db.GetRemoteChirpURL(ctx, chirpID)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		chirp, err := cfg.db.GetChirp(ctx, chirpID)
+		if err != nil {
+			return err
+		}
+		remote, err := cfg.db.GetRemoteActor(ctx, chirp.UserID)
+		if err != nil {
+			return err
+		}
+
+		actor := cfg.actorURL(userID)
+		activity, err := activitypub.NewActivity(fmt.Sprintf("%s#likes/%s", actor, chirpID), activitypub.TypeLike, actor, objectURL)
+		if err != nil {
+			return err
+		}
+		if undo {
+			if activity, err = activitypub.NewActivity(activity.ID+"/undo", activitypub.TypeUndo, actor, activity); err != nil {
+				return err
+			}
+		}
+		return cfg.deliverActivity(ctx, userID, activity, []string{remote.InboxUrl})
+	})
+}
+
+// federateRechirp - an Announce of a plain rechirp, or with undo its Undo, to the user's remote
+// followers and, when the original came from another instance, to its author
+func (cfg *apiConfig) federateRechirp(userID, originalID uuid.UUID, undo bool) {
+	cfg.federate(func(ctx context.Context) error {
+		if remote, err := cfg.isRemoteUser(ctx, userID); err != nil || remote {
+			return err
+		}
+		objectURL, err := cfg.objectURL(ctx, originalID)
+		if err != nil {
+			return err
+		}
+
+		actor := cfg.actorURL(userID)
+		activity, err := activitypub.NewActivity(fmt.Sprintf("%s#rechirps/%s", actor, originalID), activitypub.TypeAnnounce, actor, objectURL)
+		if err != nil {
+			return err
+		}
+		activity.To, activity.CC = []string{activitypub.Public}, []string{actor + "/followers"}
+		if undo {
+			if activity, err = activitypub.NewActivity(activity.ID+"/undo", activitypub.TypeUndo, actor, activity); err != nil {
+				return err
+			}
+			activity.To, activity.CC = []string{activitypub.Public}, []string{actor + "/followers"}
+		}
+
+		inboxes, err := cfg.db.ListFollowerInboxes(ctx, userID)
+		if err != nil {
+			return err
+		}
+		//* an original deleted since the rechirp still gets the Undo to the followers
+		original, err := cfg.db.GetChirp(ctx, originalID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil {
+			remote, err := cfg.db.GetRemoteActor(ctx, original.UserID)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+			if err == nil && !slices.Contains(inboxes, remote.InboxUrl) {
+				inboxes = append(inboxes, remote.InboxUrl)
+			}
+		}
+		return cfg.deliverActivity(ctx, userID, activity, inboxes)
+	})
+}
+
+// federateAccept - answers a remote Follow of a local user
+func (cfg *apiConfig) federateAccept(userID uuid.UUID, remote database.RemoteActor, follow activitypub.Activity) {
+	cfg.federate(func(ctx context.Context) error {
+		actor := cfg.actorURL(userID)
+		activity, err := activitypub.NewActivity(fmt.Sprintf("%s#accepts/%s", actor, remote.UserID), activitypub.TypeAccept, actor, follow)
+		if err != nil {
+			return err
+		}
+		return cfg.deliverActivity(ctx, userID, activity, []string{remote.InboxUrl})
+	})
+}
+
+// actorKey - the user's signing key, made the first time it is needed
+func (cfg *apiConfig) actorKey(ctx context.Context, userID uuid.UUID) (database.ActorKey, error) {
+	key, err := cfg.db.GetActorKey(ctx, userID)
+	if err != sql.ErrNoRows {
+		return key, err
+	}
+
+	privatePEM, publicPEM, err := activitypub.GenerateKey()
+	if err != nil {
+		return database.ActorKey{}, err
+	}
+	return cfg.db.CreateActorKey(ctx, database.CreateActorKeyParams{
+		UserID:        userID,
+		PublicKeyPem:  publicPEM,
+		PrivateKeyPem: privatePEM,
+	})
+}
+
+// deliverActivity - signs activity as the user and POSTs it to every inbox; one failing inbox
+// does not stop the others, and nothing is retried
+func (cfg *apiConfig) deliverActivity(ctx context.Context, userID uuid.UUID, activity activitypub.Activity, inboxes []string) error {
+	if len(inboxes) == 0 {
+		return nil
+	}
+
+	key, err := cfg.actorKey(ctx, userID)
+	if err != nil {
+		return err
+	}
+	private, err := activitypub.ParsePrivateKey(key.PrivateKeyPem)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, inbox := range inboxes {
+		if err := activitypub.Deliver(ctx, cfg.federationClient, inbox, body, cfg.keyID(userID), private); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", activity.Type, activity.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fetchRemoteActor - the actor document behind an actor URL, nothing is stored; an inbox only
+// saves the actor once its signature verified, see saveRemoteActor
+func (cfg *apiConfig) fetchRemoteActor(ctx context.Context, actorURL string) (activitypub.Actor, error) {
+	if strings.HasPrefix(actorURL, cfg.baseURL+"/") {
+		return activitypub.Actor{}, errLocalActor
+	}
+	return activitypub.FetchActor(ctx, cfg.federationClient, actorURL)
+}
+
+// saveRemoteActor - creates the remote user behind a fetched actor the first time it is seen, and
+// updates its profile and key after that
+func (cfg *apiConfig) saveRemoteActor(ctx context.Context, actor activitypub.Actor) (database.RemoteActor, error) {
+	//* remote users have no handle, their name is how they show up here
+	displayName := actor.Name
+	if displayName == "" {
+		displayName = actor.PreferredUsername
+		if u, err := url.Parse(actor.ID); err == nil {
+			displayName += "@" + u.Host
+		}
+	}
+	avatarURL := ""
+	if actor.Icon != nil {
+		avatarURL = actor.Icon.URL
+	}
+	sharedInbox := sql.NullString{}
+	if actor.Endpoints != nil && actor.Endpoints.SharedInbox != "" {
+		sharedInbox = sql.NullString{String: actor.Endpoints.SharedInbox, Valid: true}
+	}
+
+	var remote database.RemoteActor
+	err := cfg.withTx(ctx, func(q *database.Queries) error {
+		existing, err := q.GetRemoteActorByURL(ctx, actor.ID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		userID := existing.UserID
+		if err == nil {
+			if _, err := q.UpdateUserProfile(ctx, database.UpdateUserProfileParams{
+				DisplayName: displayName,
+				Bio:         activitypub.PlainText(actor.Summary),
+				AvatarUrl:   avatarURL,
+				ID:          userID,
+			}); err != nil {
+				return err
+			}
+		} else {
+			user, err := q.CreateRemoteUser(ctx, database.CreateRemoteUserParams{
+				ActorUri:    actor.ID,
+				DisplayName: displayName,
+				Bio:         activitypub.PlainText(actor.Summary),
+				AvatarUrl:   avatarURL,
+			})
+			if err != nil {
+				return err
+			}
+			userID = user.ID
+		}
+		if err := q.UpsertRemoteActor(ctx, database.UpsertRemoteActorParams{
+			UserID:         userID,
+			ActorUrl:       actor.ID,
+			InboxUrl:       actor.Inbox,
+			SharedInboxUrl: sharedInbox,
+			KeyID:          actor.PublicKey.ID,
+			PublicKeyPem:   actor.PublicKey.PublicKeyPem,
+		}); err != nil {
+			return err
+		}
+		remote, err = q.GetRemoteActorByURL(ctx, actor.ID)
+		return err
+	})
+	return remote, err
+}
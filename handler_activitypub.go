@@ -0,0 +1,701 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/activitypub"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/notification"
+	"github.com/trantuvan/chirpy/internal/visibility"
+)
+
+const (
+	// federationTimeout - how long one fetch of or delivery to another instance may take
+	federationTimeout = 10 * time.Second
+	// maxInboxBody - the largest activity an inbox accepts
+	maxInboxBody = 1 << 20
+)
+
+// errBadActivity - the activity is malformed or not the sender's to make; answered with a 400
+var errBadActivity = errors.New("bad activity")
+
+// respondActivity - like helpers.ResponseWithJson with the ActivityPub or WebFinger content type
+func respondActivity(w http.ResponseWriter, contentType string, payload any) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("respondActivity: %s", err), err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// localActorUser - a local user that is not suspended; sql.ErrNoRows for anyone else
+func (cfg *apiConfig) localActorUser(ctx context.Context, userID uuid.UUID) (database.User, error) {
+	user, err := cfg.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return database.User{}, err
+	}
+	if user.SuspendedAt.Valid {
+		return database.User{}, sql.ErrNoRows
+	}
+	remote, err := cfg.isRemoteUser(ctx, userID)
+	if err != nil {
+		return database.User{}, err
+	}
+	if remote {
+		return database.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+// actorUser - the local user an /ap/users/{userID} request names
+func (cfg *apiConfig) actorUser(r *http.Request) (database.User, error) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		return database.User{}, sql.ErrNoRows
+	}
+	return cfg.localActorUser(r.Context(), userID)
+}
+
+// handlerWebFinger - resolves acct:handle@host to the user's actor
+func (cfg *apiConfig) handlerWebFinger(w http.ResponseWriter, r *http.Request) {
+	resource := r.URL.Query().Get("resource")
+	handle, host, err := activitypub.ParseAcct(resource)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerWebFinger: %s", err), err)
+		return
+	}
+
+	base, err := url.Parse(cfg.baseURL)
+	if err != nil || !strings.EqualFold(host, base.Host) {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerWebFinger: %s is not on this instance", resource), err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByHandle(r.Context(), handle)
+	if err == nil {
+		user, err = cfg.localActorUser(r.Context(), user.ID)
+	}
+	if err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerWebFinger: user %s not exist", resource), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerWebFinger: failed to get user %s", err), err)
+		return
+	}
+
+	respondActivity(w, activitypub.JRDContentType, activitypub.WebFinger{
+		Subject: "acct:" + user.Handle.String + "@" + base.Host,
+		Aliases: []string{cfg.actorURL(user.ID)},
+		Links: []activitypub.WebFingerLink{
+			{Rel: "self", Type: activitypub.ContentType, Href: cfg.actorURL(user.ID)},
+		},
+	})
+}
+
+func (cfg *apiConfig) handlerGetActor(w http.ResponseWriter, r *http.Request) {
+	user, err := cfg.actorUser(r)
+	if err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetActor: user %s not exist", r.PathValue("userID")), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetActor: failed to get user %s", err), err)
+		return
+	}
+
+	key, err := cfg.actorKey(r.Context(), user.ID)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetActor: failed to get key %s", err), err)
+		return
+	}
+
+	id := cfg.actorURL(user.ID)
+	//* users without a handle still federate, under their id
+	username := user.ID.String()
+	if user.Handle.Valid {
+		username = user.Handle.String
+	}
+	actor := activitypub.Actor{
+		Context:           activitypub.Context,
+		ID:                id,
+		Type:              "Person",
+		PreferredUsername: username,
+		Name:              user.DisplayName,
+		URL:               fmt.Sprintf("%s/api/users/%s", cfg.baseURL, user.ID),
+		Inbox:             id + "/inbox",
+		Outbox:            id + "/outbox",
+		Followers:         id + "/followers",
+		Endpoints:         &activitypub.Endpoints{SharedInbox: cfg.baseURL + "/ap/inbox"},
+		PublicKey: activitypub.PublicKey{
+			ID:           cfg.keyID(user.ID),
+			Owner:        id,
+			PublicKeyPem: key.PublicKeyPem,
+		},
+	}
+	if user.Bio != "" {
+		actor.Summary = activitypub.Content(user.Bio)
+	}
+	if user.AvatarUrl != "" {
+		actor.Icon = &activitypub.Image{Type: "Image", URL: user.AvatarUrl}
+	}
+
+	respondActivity(w, activitypub.ContentType, actor)
+}
+
+// handlerGetOutbox - the user's latest public chirps as Create activities, the same ones
+// their feed carries
+func (cfg *apiConfig) handlerGetOutbox(w http.ResponseWriter, r *http.Request) {
+	user, err := cfg.actorUser(r)
+	if err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetOutbox: user %s not exist", r.PathValue("userID")), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetOutbox: failed to get user %s", err), err)
+		return
+	}
+
+	chirps, err := cfg.db.GetChirpsByUserID(r.Context(), database.GetChirpsByUserIDParams{
+		UserID: user.ID,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetOutbox: failed to get chirps %s", err), err)
+		return
+	}
+
+	outbox := activitypub.OrderedCollection{
+		Context: activitypub.Context,
+		ID:      cfg.actorURL(user.ID) + "/outbox",
+		Type:    "OrderedCollection",
+	}
+	//* GetChirpsByUserID is oldest first
+	for i := len(chirps) - 1; i >= 0; i-- {
+		chirp := chirps[i]
+		if visibility.Level(chirp.Visibility) != visibility.Public || (chirp.RechirpOf.Valid && chirp.Body == "") {
+			continue
+		}
+		outbox.TotalItems++
+		if len(outbox.OrderedItems) == feedSize {
+			continue
+		}
+
+		note, err := cfg.noteOf(r.Context(), chirp)
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetOutbox: failed to build note %s", err), err)
+			return
+		}
+		activity, err := activitypub.NewActivity(note.ID+"/activity", activitypub.TypeCreate, note.AttributedTo, note)
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetOutbox: %s", err), err)
+			return
+		}
+		activity.Context = nil
+		activity.To, activity.CC, activity.Published = note.To, note.CC, &note.Published
+		outbox.OrderedItems = append(outbox.OrderedItems, activity)
+	}
+
+	respondActivity(w, activitypub.ContentType, outbox)
+}
+
+// handlerGetFollowersCollection - only the count; who follows whom is not published
+func (cfg *apiConfig) handlerGetFollowersCollection(w http.ResponseWriter, r *http.Request) {
+	user, err := cfg.actorUser(r)
+	if err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetFollowersCollection: user %s not exist", r.PathValue("userID")), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetFollowersCollection: failed to get user %s", err), err)
+		return
+	}
+
+	stats, err := cfg.db.GetUserStats(r.Context(), user.ID)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetFollowersCollection: failed to get stats %s", err), err)
+		return
+	}
+
+	respondActivity(w, activitypub.ContentType, activitypub.OrderedCollection{
+		Context:    activitypub.Context,
+		ID:         cfg.actorURL(user.ID) + "/followers",
+		Type:       "OrderedCollection",
+		TotalItems: stats.FollowerCount,
+	})
+}
+
+func (cfg *apiConfig) handlerGetNote(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetNote: %s", err), err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil && err != sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetNote: failed to get chirp %s", err), err)
+		return
+	}
+	if err == sql.ErrNoRows || !chirpVisibleTo(chirp, uuid.NullUUID{}) {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetNote: chirp with ID - %s not exist", chirpID), err)
+		return
+	}
+
+	if ok, err := cfg.federatable(r.Context(), chirp); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetNote: failed to get author %s", err), err)
+		return
+	} else if !ok {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetNote: chirp with ID - %s not exist", chirpID), nil)
+		return
+	}
+
+	note, err := cfg.noteOf(r.Context(), chirp)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetNote: failed to build note %s", err), err)
+		return
+	}
+	note.Context = activitypub.Context
+
+	respondActivity(w, activitypub.ContentType, note)
+}
+
+// handlerLookupRemoteUser - finds alice@other.example through WebFinger and returns her profile,
+// so she can be followed by id like anyone else
+func (cfg *apiConfig) handlerLookupRemoteUser(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Profile
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerLookupRemoteUser: %s", err), err)
+		return
+	}
+
+	if _, err := auth.ValidateJWT(tokenJWT, cfg.secretKey); err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerLookupRemoteUser: %s", err), err)
+		return
+	}
+
+	acct := r.URL.Query().Get("acct")
+	handle, host, err := activitypub.ParseAcct(acct)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerLookupRemoteUser: %s", err), err)
+		return
+	}
+
+	//* instances reach each other the way this one is reached, so two of them can federate over http://localhost
+	scheme := "https"
+	if strings.HasPrefix(cfg.baseURL, "http://") {
+		scheme = "http"
+	}
+
+	actorURL, err := activitypub.LookupActor(r.Context(), cfg.federationClient, scheme, handle, host)
+	if err != nil {
+		//* what the other host answered, or failed to, stays in the log
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerLookupRemoteUser: user %s not found", acct), err)
+		return
+	}
+
+	var userID uuid.UUID
+	if localID, ok := cfg.localID(actorURL, actorPath); ok {
+		userID = localID
+	} else {
+		actor, err := cfg.fetchRemoteActor(r.Context(), actorURL)
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusBadGateway, fmt.Sprintf("handlerLookupRemoteUser: failed to fetch user %s", acct), err)
+			return
+		}
+		remote, err := cfg.saveRemoteActor(r.Context(), actor)
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerLookupRemoteUser: failed to save user %s", err), err)
+			return
+		}
+		userID = remote.UserID
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err == sql.ErrNoRows || user.SuspendedAt.Valid {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerLookupRemoteUser: user %s not exist", acct), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerLookupRemoteUser: failed to get user %s", err), err)
+		return
+	}
+
+	p, err := cfg.profileOf(r.Context(), user)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerLookupRemoteUser: failed to get stats %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Profile: p,
+	})
+}
+
+// handlerInbox - both the personal inboxes and the shared one. The request must be signed by
+// the actor the activity claims to be from; activities Chirpy has no use for are accepted and
+// dropped.
+func (cfg *apiConfig) handlerInbox(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("userID") != "" {
+		if _, err := cfg.actorUser(r); err == sql.ErrNoRows {
+			helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerInbox: user %s not exist", r.PathValue("userID")), err)
+			return
+		} else if err != nil {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerInbox: failed to get user %s", err), err)
+			return
+		}
+	}
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxInboxBody+1))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerInbox: failed to read body %s", err), err)
+		return
+	}
+	if len(body) > maxInboxBody {
+		helpers.ResponseWithError(w, http.StatusRequestEntityTooLarge, "handlerInbox: activity too large", nil)
+		return
+	}
+
+	activity := activitypub.Activity{}
+	if err := json.Unmarshal(body, &activity); err != nil || activity.Actor == "" {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerInbox: failed to read activity %s", err), err)
+		return
+	}
+
+	remote, err := cfg.verifyInbox(r, body, activity.Actor)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerInbox: %s", err), err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), remote.UserID)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerInbox: failed to get user %s", err), err)
+		return
+	}
+	//* a suspended remote user is cut off the same way a local one is
+	if !user.SuspendedAt.Valid {
+		err = cfg.handleActivity(r.Context(), remote, activity)
+	}
+	if errors.Is(err, errBadActivity) {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerInbox: %s", err), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerInbox: failed to handle %s %s", activity.Type, err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// verifyInbox - the body matches its digest and the request is signed with the actor's key.
+// The stored key is tried first; one that does not verify fetches the actor again, in case the
+// key was rotated, and only a verified actor is saved.
+func (cfg *apiConfig) verifyInbox(r *http.Request, body []byte, actorURL string) (database.RemoteActor, error) {
+	sig, err := activitypub.ParseSignature(r.Header)
+	if err != nil {
+		return database.RemoteActor{}, err
+	}
+	if err := activitypub.VerifyDigest(r.Header, body); err != nil {
+		return database.RemoteActor{}, err
+	}
+	//* a replayed or stale request is turned away before anything is fetched for it
+	if err := activitypub.VerifyDate(r.Header, time.Now()); err != nil {
+		return database.RemoteActor{}, err
+	}
+
+	remote, err := cfg.db.GetRemoteActorByURL(r.Context(), actorURL)
+	if err != nil && err != sql.ErrNoRows {
+		return database.RemoteActor{}, err
+	}
+	if err == nil {
+		err = verifyActorSignature(r, sig, remote.KeyID, remote.PublicKeyPem)
+		if !errors.Is(err, activitypub.ErrInvalidSignature) {
+			return remote, err
+		}
+	}
+
+	actor, err := cfg.fetchRemoteActor(r.Context(), actorURL)
+	if err != nil {
+		return database.RemoteActor{}, err
+	}
+	if err := verifyActorSignature(r, sig, actor.PublicKey.ID, actor.PublicKey.PublicKeyPem); err != nil {
+		return database.RemoteActor{}, err
+	}
+	return cfg.saveRemoteActor(r.Context(), actor)
+}
+
+func verifyActorSignature(r *http.Request, sig activitypub.Signature, keyID, publicKeyPem string) error {
+	if sig.KeyID != keyID {
+		return activitypub.ErrInvalidSignature
+	}
+	key, err := activitypub.ParsePublicKey(publicKeyPem)
+	if err != nil {
+		return err
+	}
+	return sig.Verify(r, key, time.Now())
+}
+
+// handleActivity - maps an incoming activity onto follows, likes and chirps
+func (cfg *apiConfig) handleActivity(ctx context.Context, remote database.RemoteActor, activity activitypub.Activity) error {
+	switch activity.Type {
+	case activitypub.TypeFollow:
+		return cfg.handleFollow(ctx, remote, activity)
+	case activitypub.TypeUndo:
+		return cfg.handleUndo(ctx, remote, activity)
+	case activitypub.TypeCreate:
+		note := activitypub.Note{}
+		if err := json.Unmarshal(activity.Object, &note); err != nil {
+			return fmt.Errorf("%w: %s", errBadActivity, err)
+		}
+		if note.Type != activitypub.TypeNote {
+			return nil
+		}
+		return cfg.handleCreateNote(ctx, remote, note)
+	case activitypub.TypeLike:
+		return cfg.handleLike(ctx, remote, activity)
+	case activitypub.TypeDelete:
+		return cfg.handleDelete(ctx, remote, activity)
+	default:
+		//* Accept needs nothing, follows are recorded when they are sent
+		return nil
+	}
+}
+
+func (cfg *apiConfig) handleFollow(ctx context.Context, remote database.RemoteActor, activity activitypub.Activity) error {
+	objectID, err := activity.ObjectID()
+	if err != nil {
+		return fmt.Errorf("%w: %s", errBadActivity, err)
+	}
+	userID, ok := cfg.localID(objectID, actorPath)
+	if !ok {
+		return nil
+	}
+	if _, err := cfg.localActorUser(ctx, userID); err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	//* a blocked follower gets no Accept, and so never sees the follow go through
+	if blocked, err := cfg.blockedWith(ctx, uuid.NullUUID{UUID: remote.UserID, Valid: true}, userID); err != nil || blocked {
+		return err
+	}
+
+	followed, err := cfg.db.FollowUser(ctx, database.FollowUserParams{
+		FollowerID: remote.UserID,
+		FolloweeID: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if followed > 0 {
+		cfg.notify(notification.Event{Kind: notification.Follow, ActorID: remote.UserID, UserID: userID})
+	}
+	//* a repeated Follow is accepted again: the server sends it again when it missed the first Accept
+	cfg.federateAccept(userID, remote, activity)
+	return nil
+}
+
+func (cfg *apiConfig) handleUndo(ctx context.Context, remote database.RemoteActor, activity activitypub.Activity) error {
+	inner := activitypub.Activity{}
+	if err := json.Unmarshal(activity.Object, &inner); err != nil {
+		return fmt.Errorf("%w: %s", errBadActivity, err)
+	}
+	if inner.Actor != activity.Actor {
+		return fmt.Errorf("%w: undo of another actor's activity", errBadActivity)
+	}
+	objectID, err := inner.ObjectID()
+	if err != nil {
+		return fmt.Errorf("%w: %s", errBadActivity, err)
+	}
+
+	switch inner.Type {
+	case activitypub.TypeFollow:
+		userID, ok := cfg.localID(objectID, actorPath)
+		if !ok {
+			return nil
+		}
+		_, err := cfg.db.UnfollowUser(ctx, database.UnfollowUserParams{
+			FollowerID: remote.UserID,
+			FolloweeID: userID,
+		})
+		return err
+	case activitypub.TypeLike:
+		chirp, err := cfg.chirpByObjectURL(ctx, objectID)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		_, err = cfg.db.UnlikeChirp(ctx, database.UnlikeChirpParams{UserID: remote.UserID, ChirpID: chirp.ID})
+		return err
+	default:
+		return nil
+	}
+}
+
+// handleCreateNote - stores a public note as a chirp of the remote user, when someone here
+// follows them or it replies to a chirp of someone here. Followers-only and direct notes are
+// dropped: Chirpy could not keep them to their audience.
+func (cfg *apiConfig) handleCreateNote(ctx context.Context, remote database.RemoteActor, note activitypub.Note) error {
+	if note.AttributedTo != remote.ActorUrl {
+		return fmt.Errorf("%w: note attributed to %s", errBadActivity, note.AttributedTo)
+	}
+	//* a note id is what Delete and replies find the chirp by, so an actor only gets ids on its own host
+	noteURL, err := url.Parse(note.ID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errBadActivity, err)
+	}
+	actorURL, err := url.Parse(remote.ActorUrl)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(noteURL.Host, actorURL.Host) {
+		return fmt.Errorf("%w: note %s is not on the host of %s", errBadActivity, note.ID, remote.ActorUrl)
+	}
+	if !note.IsPublic() {
+		return nil
+	}
+	if _, err := cfg.db.GetRemoteChirpID(ctx, note.ID); err == nil {
+		return nil
+	} else if err != sql.ErrNoRows {
+		return err
+	}
+
+	inReplyTo := uuid.NullUUID{}
+	repliesHere := false
+	if note.InReplyTo != "" {
+		parent, err := cfg.chirpByObjectURL(ctx, note.InReplyTo)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil {
+			if blocked, err := cfg.blockedWith(ctx, uuid.NullUUID{UUID: remote.UserID, Valid: true}, parent.UserID); err != nil || blocked {
+				return err
+			}
+			parentRemote, err := cfg.isRemoteUser(ctx, parent.UserID)
+			if err != nil {
+				return err
+			}
+			inReplyTo = uuid.NullUUID{UUID: parent.ID, Valid: true}
+			repliesHere = !parentRemote
+		}
+	}
+	if !repliesHere {
+		followed, err := cfg.db.HasLocalFollowers(ctx, remote.UserID)
+		if err != nil || !followed {
+			return err
+		}
+	}
+
+	//* remote chirps go through the same moderation as local ones
+	moderated, err := cfg.moderator.Moderate(activitypub.PlainText(note.Content))
+	if err != nil {
+		log.Printf("handleCreateNote: dropped %s %s\n", note.ID, err)
+		return nil
+	}
+
+	var chirp database.Chirp
+	err = cfg.withTx(ctx, func(q *database.Queries) error {
+		var err error
+		chirp, err = q.CreateChirps(ctx, database.CreateChirpsParams{
+			Body:       moderated.Text,
+			UserID:     remote.UserID,
+			InReplyTo:  inReplyTo,
+			Status:     chirpStatusPublished,
+			Visibility: string(visibility.Public),
+		})
+		if err != nil {
+			return err
+		}
+		if err := recordChirpFlags(ctx, q, chirp.ID, moderated); err != nil {
+			return err
+		}
+		if err := q.CreateRemoteChirp(ctx, database.CreateRemoteChirpParams{
+			ChirpID:   chirp.ID,
+			ObjectUrl: note.ID,
+		}); err != nil {
+			return err
+		}
+		return indexChirpEntities(ctx, q, chirp)
+	})
+	if err != nil {
+		return err
+	}
+	cfg.notifyPublished(chirp.ID)
+	return nil
+}
+
+func (cfg *apiConfig) handleLike(ctx context.Context, remote database.RemoteActor, activity activitypub.Activity) error {
+	objectID, err := activity.ObjectID()
+	if err != nil {
+		return fmt.Errorf("%w: %s", errBadActivity, err)
+	}
+	chirp, err := cfg.chirpByObjectURL(ctx, objectID)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == sql.ErrNoRows || !chirpVisibleTo(chirp, uuid.NullUUID{}) {
+		return nil
+	}
+	if withheld, err := cfg.chirpWithheldFrom(ctx, chirp, uuid.NullUUID{UUID: remote.UserID, Valid: true}); err != nil || withheld {
+		return err
+	}
+
+	liked, err := cfg.db.LikeChirp(ctx, database.LikeChirpParams{UserID: remote.UserID, ChirpID: chirp.ID})
+	if err != nil {
+		return err
+	}
+	if liked > 0 {
+		cfg.notify(notification.Event{Kind: notification.Like, ActorID: remote.UserID, ChirpID: chirp.ID})
+	}
+	return nil
+}
+
+// handleDelete - removes a remote chirp; deleting the actor itself is not supported and ignored
+func (cfg *apiConfig) handleDelete(ctx context.Context, remote database.RemoteActor, activity activitypub.Activity) error {
+	objectID, err := activity.ObjectID()
+	if err != nil {
+		return fmt.Errorf("%w: %s", errBadActivity, err)
+	}
+
+	chirpID, err := cfg.db.GetRemoteChirpID(ctx, objectID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	//* DeleteRechirpsOf and DeleteChirpByID only touch the chirp when it is the sender's
+	return cfg.withTx(ctx, func(q *database.Queries) error {
+		if err := q.DeleteRechirpsOf(ctx, database.DeleteRechirpsOfParams{ID: chirpID, UserID: remote.UserID}); err != nil {
+			return err
+		}
+		return q.DeleteChirpByID(ctx, database.DeleteChirpByIDParams{ID: chirpID, UserID: remote.UserID})
+	})
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/trantuvan/chirpy/internal/activitypub"
+)
+
+func TestHandlerInboxVerifiesBeforeSaving(t *testing.T) {
+	_, publicPEM, err := activitypub.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPEM, _, err := activitypub.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := activitypub.ParsePrivateKey(otherPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//* the actor publishes one key, the requests are signed with another
+	var fetches atomic.Int32
+	var actorURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Header().Set("Content-Type", activitypub.ContentType)
+		json.NewEncoder(w).Encode(activitypub.Actor{
+			ID:                actorURL,
+			Type:              "Person",
+			PreferredUsername: "mallory",
+			Inbox:             actorURL + "/inbox",
+			PublicKey: activitypub.PublicKey{
+				ID:           actorURL + "#main-key",
+				Owner:        actorURL,
+				PublicKeyPem: publicPEM,
+			},
+		})
+	}))
+	defer server.Close()
+	actorURL = server.URL + "/users/mallory"
+
+	tests := []struct {
+		name        string
+		date        time.Time
+		wantFetches int32
+	}{
+		{
+			name:        "Signed with another key",
+			wantFetches: 1,
+		},
+		{
+			name:        "Stale date",
+			date:        time.Now().Add(-2 * activitypub.MaxClockSkew),
+			wantFetches: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetches.Store(0)
+			body, _ := json.Marshal(activitypub.Activity{
+				ID:    actorURL + "#follows/1",
+				Type:  activitypub.TypeFollow,
+				Actor: actorURL,
+			})
+			r := httptest.NewRequest(http.MethodPost, "/ap/inbox", bytes.NewReader(body))
+			if err := activitypub.Sign(r, actorURL+"#main-key", other, body); err != nil {
+				t.Fatal(err)
+			}
+			if !tt.date.IsZero() {
+				r.Header.Set("Date", tt.date.UTC().Format(http.TimeFormat))
+			}
+
+			db := newFakeDB()
+			cfg := testConfig(t, db)
+			cfg.federationClient = server.Client()
+
+			w := serve(cfg.handlerInbox, r, nil)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, http.StatusUnauthorized)
+			}
+			if got := fetches.Load(); got != tt.wantFetches {
+				t.Errorf("fetched the actor %d times, want %d", got, tt.wantFetches)
+			}
+			if db.didRun("CreateRemoteUser") || db.didRun("UpsertRemoteActor") {
+				t.Error("the actor was saved")
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/pagination"
+)
+
+type Relation struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (cfg *apiConfig) handlerBlockUser(w http.ResponseWriter, r *http.Request) {
+	cfg.setRelation(w, r, "handlerBlockUser", func(userID, otherID uuid.UUID) error {
+		return cfg.db.BlockUser(r.Context(), database.BlockUserParams{BlockerID: userID, BlockedID: otherID})
+	})
+}
+
+func (cfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, r *http.Request) {
+	cfg.setRelation(w, r, "handlerUnblockUser", func(userID, otherID uuid.UUID) error {
+		return cfg.db.UnblockUser(r.Context(), database.UnblockUserParams{BlockerID: userID, BlockedID: otherID})
+	})
+}
+
+func (cfg *apiConfig) handlerMuteUser(w http.ResponseWriter, r *http.Request) {
+	cfg.setRelation(w, r, "handlerMuteUser", func(userID, otherID uuid.UUID) error {
+		return cfg.db.MuteUser(r.Context(), database.MuteUserParams{MuterID: userID, MutedID: otherID})
+	})
+}
+
+func (cfg *apiConfig) handlerUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	cfg.setRelation(w, r, "handlerUnmuteUser", func(userID, otherID uuid.UUID) error {
+		return cfg.db.UnmuteUser(r.Context(), database.UnmuteUserParams{MuterID: userID, MutedID: otherID})
+	})
+}
+
+// setRelation - shared by block/unblock/mute/unmute, which only differ in the query.
+// All four are idempotent, so repeating one is still a 204.
+func (cfg *apiConfig) setRelation(w http.ResponseWriter, r *http.Request, handler string, set func(userID, otherID uuid.UUID) error) {
+	otherID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("%s: %s", handler, err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("%s: %s", handler, err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("%s: %s", handler, err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, handler, userID); !ok {
+		return
+	}
+
+	if otherID == userID {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("%s: cannot target yourself", handler), nil)
+		return
+	}
+
+	if _, err := cfg.db.GetUserByID(r.Context(), otherID); err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("%s: user with ID - %s not exist", handler, otherID), err)
+		return
+	} else if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s: failed to get user %s", handler, err), err)
+		return
+	}
+
+	if err := set(userID, otherID); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s: failed to update user %s", handler, err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) handlerGetBlocks(w http.ResponseWriter, r *http.Request) {
+	cfg.listRelations(w, r, "handlerGetBlocks", func(params database.ListBlocksParams) ([]Relation, error) {
+		rows, err := cfg.db.ListBlocks(r.Context(), params)
+		relations := make([]Relation, len(rows))
+		for i, row := range rows {
+			relations[i] = Relation{UserID: row.UserID, CreatedAt: row.CreatedAt}
+		}
+		return relations, err
+	})
+}
+
+func (cfg *apiConfig) handlerGetMutes(w http.ResponseWriter, r *http.Request) {
+	cfg.listRelations(w, r, "handlerGetMutes", func(params database.ListBlocksParams) ([]Relation, error) {
+		rows, err := cfg.db.ListMutes(r.Context(), database.ListMutesParams(params))
+		relations := make([]Relation, len(rows))
+		for i, row := range rows {
+			relations[i] = Relation{UserID: row.UserID, CreatedAt: row.CreatedAt}
+		}
+		return relations, err
+	})
+}
+
+// listRelations - the caller's own blocks or mutes, newest first; nobody else gets to see them
+func (cfg *apiConfig) listRelations(w http.ResponseWriter, r *http.Request, handler string, list func(database.ListBlocksParams) ([]Relation, error)) {
+	type response struct {
+		Users      []Relation `json:"users"`
+		NextCursor string     `json:"next_cursor,omitempty"`
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("%s: %s", handler, err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("%s: %s", handler, err), err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("%s: %s", handler, err), err)
+		return
+	}
+
+	cursor, err := pagination.DecodeCursor(query.Get("cursor"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("%s: %s", handler, err), err)
+		return
+	}
+
+	relations, err := list(database.ListBlocksParams{
+		UserID:          userID,
+		CursorCreatedAt: cursor.NullCreatedAt(),
+		CursorID:        cursor.NullID(),
+		PageLimit:       limit + 1,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s: failed to list users %s", handler, err), err)
+		return
+	}
+
+	relations, nextCursor := pagination.Paginate(relations, limit, func(rel Relation) string {
+		return pagination.EncodeCursor(pagination.Cursor{CreatedAt: rel.CreatedAt, ID: rel.UserID})
+	})
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Users:      relations,
+		NextCursor: nextCursor,
+	})
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/pagination"
+)
+
+type Bookmark struct {
+	Chirp        Chirp     `json:"chirp"`
+	BookmarkedAt time.Time `json:"bookmarked_at"`
+}
+
+func (cfg *apiConfig) handlerBookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerBookmarkChirp: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerBookmarkChirp: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerBookmarkChirp: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerBookmarkChirp", userID); !ok {
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil && err != sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerBookmarkChirp: failed to get chirp %s", err), err)
+		return
+	}
+	if err == sql.ErrNoRows || !chirpVisibleTo(chirp, uuid.NullUUID{}) {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerBookmarkChirp: chirp with ID - %s not exist", chirpID), err)
+		return
+	}
+
+	if withheld, err := cfg.chirpWithheldFrom(r.Context(), chirp, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerBookmarkChirp: failed to check visibility %s", err), err)
+		return
+	} else if withheld {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerBookmarkChirp: chirp with ID - %s not exist", chirpID), nil)
+		return
+	}
+
+	if err := cfg.db.BookmarkChirp(r.Context(), database.BookmarkChirpParams{UserID: userID, ChirpID: chirpID}); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerBookmarkChirp: failed to bookmark chirp %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) handlerUnbookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerUnbookmarkChirp: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerUnbookmarkChirp: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerUnbookmarkChirp: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerUnbookmarkChirp", userID); !ok {
+		return
+	}
+
+	if err := cfg.db.DeleteBookmark(r.Context(), database.DeleteBookmarkParams{UserID: userID, ChirpID: chirpID}); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerUnbookmarkChirp: failed to delete bookmark %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
+
+// handlerGetBookmarks - the caller's bookmarks, newest first. Deleted chirps and ones the
+// caller can no longer read stay in the list as placeholders, so a bookmark never vanishes
+// without the user removing it.
+func (cfg *apiConfig) handlerGetBookmarks(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Bookmarks  []Bookmark `json:"bookmarks"`
+		NextCursor string     `json:"next_cursor,omitempty"`
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetBookmarks: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetBookmarks: %s", err), err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetBookmarks: %s", err), err)
+		return
+	}
+
+	cursor, err := pagination.DecodeCursor(query.Get("cursor"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetBookmarks: %s", err), err)
+		return
+	}
+
+	rows, err := cfg.db.ListBookmarks(r.Context(), database.ListBookmarksParams{
+		UserID:          userID,
+		CursorCreatedAt: cursor.NullCreatedAt(),
+		CursorID:        cursor.NullID(),
+		PageLimit:       limit + 1,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetBookmarks: failed to get bookmarks %s", err), err)
+		return
+	}
+
+	rows, nextCursor := pagination.Paginate(rows, limit, func(row database.ListBookmarksRow) string {
+		return pagination.EncodeCursor(pagination.Cursor{CreatedAt: row.BookmarkedAt, ID: row.Chirp.ID})
+	})
+
+	bookmarks := make([]Bookmark, len(rows))
+	var refs []*Chirp
+	for i, row := range rows {
+		bookmarks[i].BookmarkedAt = row.BookmarkedAt
+		if !row.Available {
+			bookmarks[i].Chirp = chirpPlaceholder(row.Chirp)
+			continue
+		}
+		bookmarks[i].Chirp = chirpFromDB(row.Chirp)
+		refs = append(refs, &bookmarks[i].Chirp)
+	}
+
+	if err := cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, refs...); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetBookmarks: failed to load chirp details %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Bookmarks:  bookmarks,
+		NextCursor: nextCursor,
+	})
+}
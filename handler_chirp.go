@@ -3,10 +3,8 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
-	"sort"
 	"strings"
 	"time"
 
@@ -14,18 +12,112 @@ import (
 	"github.com/trantuvan/chirpy/helpers"
 	"github.com/trantuvan/chirpy/internal/auth"
 	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/entities"
+	"github.com/trantuvan/chirpy/internal/pagination"
+	"github.com/trantuvan/chirpy/internal/poll"
+	"github.com/trantuvan/chirpy/internal/visibility"
 )
 
+const (
+	chirpStatusDraft     = "draft"
+	chirpStatusScheduled = "scheduled"
+	chirpStatusPublished = "published"
+)
+
+type Chirp struct {
+	ID             uuid.UUID    `json:"id"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	Body           string       `json:"body"`
+	UserId         uuid.UUID    `json:"user_id"`
+	Edited         bool         `json:"edited"`
+	RevisionCount  int32        `json:"revision_count"`
+	InReplyTo      *uuid.UUID   `json:"in_reply_to,omitempty"`
+	ConversationID uuid.UUID    `json:"conversation_id"`
+	Deleted        bool         `json:"deleted,omitempty"`
+	Hidden         bool         `json:"hidden,omitempty"`
+	RechirpOf      *uuid.UUID   `json:"rechirp_of,omitempty"`
+	Original       *Chirp       `json:"original,omitempty"`
+	LikeCount      int32        `json:"like_count"`
+	RechirpCount   int32        `json:"rechirp_count"`
+	LikedByMe      bool         `json:"liked_by_me"`
+	Attachments    []Attachment `json:"attachments,omitempty"`
+	Poll           *Poll        `json:"poll,omitempty"`
+	Status         string       `json:"status"`
+	PublishAt      *time.Time   `json:"publish_at,omitempty"`
+	Visibility     string       `json:"visibility"`
+	Pinned         bool         `json:"pinned,omitempty"`
+}
+
+func chirpFromDB(c database.Chirp) Chirp {
+	chirp := Chirp{
+		ID:             c.ID,
+		CreatedAt:      c.CreatedAt,
+		UpdatedAt:      c.UpdatedAt,
+		Body:           c.Body,
+		UserId:         c.UserID,
+		Edited:         c.RevisionCount > 0,
+		RevisionCount:  c.RevisionCount,
+		ConversationID: c.ConversationID,
+		Deleted:        c.DeletedAt.Valid,
+		Hidden:         c.HiddenAt.Valid,
+		LikeCount:      c.LikeCount,
+		RechirpCount:   c.RechirpCount,
+		Status:         c.Status,
+		Visibility:     c.Visibility,
+	}
+
+	if c.InReplyTo.Valid {
+		chirp.InReplyTo = &c.InReplyTo.UUID
+	}
+	if c.RechirpOf.Valid {
+		chirp.RechirpOf = &c.RechirpOf.UUID
+	}
+	if c.PublishAt.Valid {
+		chirp.PublishAt = &c.PublishAt.Time
+	}
+
+	return chirp
+}
+
+// directWithoutMentions - a direct chirp is addressed through its mentions, so its body,
+// created or edited, must keep at least one
+func directWithoutMentions(level visibility.Level, body string) bool {
+	return level == visibility.Direct && len(entities.Mentions(body)) == 0
+}
+
+// chirpPlaceholder - keeps a chirp's place (in a thread, among bookmarks) while leaving out
+// everything the viewer may not read
+func chirpPlaceholder(c database.Chirp) Chirp {
+	placeholder := Chirp{
+		ID:             c.ID,
+		CreatedAt:      c.CreatedAt,
+		UpdatedAt:      c.UpdatedAt,
+		UserId:         c.UserID,
+		ConversationID: c.ConversationID,
+		Deleted:        c.DeletedAt.Valid,
+		Status:         c.Status,
+		Visibility:     c.Visibility,
+	}
+
+	if c.InReplyTo.Valid {
+		placeholder.InReplyTo = &c.InReplyTo.UUID
+	}
+
+	return placeholder
+}
+
 func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request) {
 	type parameter struct {
-		Body string `json:"body"`
+		Body       string         `json:"body"`
+		InReplyTo  *uuid.UUID     `json:"in_reply_to"`
+		Status     string         `json:"status"`
+		PublishAt  *time.Time     `json:"publish_at"`
+		Poll       *pollParameter `json:"poll"`
+		Visibility string         `json:"visibility"`
 	}
 	type response struct {
-		ID        uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserId    uuid.UUID `json:"user_id"`
+		Chirp
 	}
 
 	token, err := auth.GetBearerToken(r.Header)
@@ -42,36 +134,179 @@ func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	user, ok := cfg.requireActiveUser(w, r, "handlerCreateChirp", userID)
+	if !ok || !cfg.requireCanChirp(w, "handlerCreateChirp", user) {
+		return
+	}
+
 	params := parameter{}
+	var uploads []upload
 	defer r.Body.Close()
-	decoder := json.NewDecoder(r.Body)
 
-	if err := decoder.Decode(&params); err != nil {
-		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateChirp: failed to read params %s\n", err), err)
+	//* attachments come as multipart/form-data, text-only chirps may still send JSON
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		form, err := readChirpForm(w, r)
+		if err != nil {
+			helpers.ResponseWithError(w, uploadStatus(err), fmt.Sprintf("handlerCreateChirp: failed to read form %s\n", err), err)
+			return
+		}
+		params.Body = form.body
+		if form.inReplyTo != "" {
+			inReplyTo, err := uuid.Parse(form.inReplyTo)
+			if err != nil {
+				helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerCreateChirp: %s\n", err), err)
+				return
+			}
+			params.InReplyTo = &inReplyTo
+		}
+		params.Status = form.status
+		params.Visibility = form.visibility
+		if form.publishAt != "" {
+			publishAt, err := time.Parse(time.RFC3339, form.publishAt)
+			if err != nil {
+				helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerCreateChirp: %s\n", err), err)
+				return
+			}
+			params.PublishAt = &publishAt
+		}
+		if len(form.pollOptions) > 0 || form.pollClosesAt != "" {
+			closesAt, err := time.Parse(time.RFC3339, form.pollClosesAt)
+			if err != nil {
+				helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerCreateChirp: %s\n", err), err)
+				return
+			}
+			params.Poll = &pollParameter{Options: form.pollOptions, ClosesAt: closesAt}
+		}
+		uploads = form.uploads
+	} else {
+		decoder := json.NewDecoder(r.Body)
+
+		if err := decoder.Decode(&params); err != nil {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateChirp: failed to read params %s\n", err), err)
+			return
+		}
+	}
+
+	moderated, err := cfg.moderator.Moderate(params.Body)
+
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerCreateChirp: %s\n", err), err)
 		return
 	}
 
-	cleanedChirp, err := validateChirp(params.Body)
+	status, publishAt, err := chirpStatus(params.Status, params.PublishAt, time.Now())
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerCreateChirp: %s\n", err), err)
+		return
+	}
 
+	level, err := visibility.Parse(params.Visibility)
 	if err != nil {
 		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerCreateChirp: %s\n", err), err)
 		return
 	}
+	if directWithoutMentions(level, moderated.Text) {
+		helpers.ResponseWithError(w, http.StatusBadRequest, "handlerCreateChirp: a direct chirp must mention at least one user", nil)
+		return
+	}
+
+	var pollOptions []string
+	if params.Poll != nil {
+		//* a scheduled chirp's poll runs from when it is published
+		opensAt := time.Now()
+		if publishAt.Valid {
+			opensAt = publishAt.Time
+		}
+		pollOptions, err = poll.Validate(params.Poll.Options, params.Poll.ClosesAt, opensAt)
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerCreateChirp: %s\n", err), err)
+			return
+		}
+	}
 
-	chirp, err := cfg.db.CreateChirps(r.Context(), database.CreateChirpsParams{Body: cleanedChirp, UserID: userID})
+	inReplyTo := uuid.NullUUID{}
+	if params.InReplyTo != nil {
+		parent, err := cfg.db.GetChirp(r.Context(), *params.InReplyTo)
+		if err != nil && err != sql.ErrNoRows {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateChirp: failed to get parent chirp %s\n", err), err)
+			return
+		}
+		if err == sql.ErrNoRows || !chirpVisibleTo(parent, uuid.NullUUID{}) {
+			helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerCreateChirp: in_reply_to chirp with ID - %s not exist", params.InReplyTo), err)
+			return
+		}
 
+		if blocked, err := cfg.blockedWith(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, parent.UserID); err != nil {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateChirp: failed to check blocks %s", err), err)
+			return
+		} else if blocked {
+			helpers.ResponseWithError(w, http.StatusForbidden, "handlerCreateChirp: cannot reply to this chirp", nil)
+			return
+		}
+
+		if allowed, err := cfg.chirpAllows(r.Context(), parent, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateChirp: failed to check visibility %s", err), err)
+			return
+		} else if !allowed {
+			helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerCreateChirp: in_reply_to chirp with ID - %s not exist", params.InReplyTo), nil)
+			return
+		}
+
+		inReplyTo = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+
+	blobKeys, err := cfg.storeUploads(r.Context(), uploads)
 	if err != nil {
-		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateChirp: failed to create chirp %s\n", err), err)
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateChirp: failed to store attachments %s\n", err), err)
 		return
 	}
 
-	helpers.ResponseWithJson(w, http.StatusCreated, response{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserId:    chirp.UserID,
+	var chirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		chirp, err = q.CreateChirps(r.Context(), database.CreateChirpsParams{
+			Body:       moderated.Text,
+			UserID:     userID,
+			InReplyTo:  inReplyTo,
+			Status:     status,
+			PublishAt:  publishAt,
+			Visibility: string(level),
+		})
+		if err != nil {
+			return err
+		}
+		if err := recordChirpFlags(r.Context(), q, chirp.ID, moderated); err != nil {
+			return err
+		}
+		if err := createChirpAttachments(r.Context(), q, chirp.ID, uploads, blobKeys); err != nil {
+			return err
+		}
+		if pollOptions != nil {
+			if err := createChirpPoll(r.Context(), q, chirp.ID, pollOptions, params.Poll.ClosesAt.UTC()); err != nil {
+				return err
+			}
+		}
+		return indexChirpEntities(r.Context(), q, chirp)
 	})
+
+	if err != nil {
+		cfg.deleteBlobs(blobKeys)
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateChirp: failed to create chirp %s\n", err), err)
+		return
+	}
+	//* drafts and scheduled chirps notify once they are published
+	if chirp.Status == chirpStatusPublished {
+		cfg.notifyPublished(chirp.ID)
+		cfg.federateChirp(chirp.ID)
+	}
+
+	resp := response{Chirp: chirpFromDB(chirp)}
+	if err := cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, &resp.Chirp); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateChirp: failed to load chirp details %s\n", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusCreated, resp)
 }
 
 func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request) {
@@ -93,8 +328,12 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if _, ok := cfg.requireActiveUser(w, r, "handlerDeleteChirp", userID); !ok {
+		return
+	}
+
 	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
-	if err == sql.ErrNoRows {
+	if err == sql.ErrNoRows || chirp.DeletedAt.Valid {
 		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerDeleteChirp: chirp with ID - %s not exist", chirpID), err)
 		return
 	}
@@ -103,22 +342,32 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	errDel := cfg.db.DeleteChirpByID(r.Context(), database.DeleteChirpByIDParams{ID: chirpID, UserID: userID})
+	//* tombstones keep their row, so attachments are removed explicitly rather than by cascade
+	var blobKeys []string
+	errDel := cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		blobKeys, err = q.DeleteChirpAttachments(r.Context(), chirpID)
+		if err != nil {
+			return err
+		}
+		if err := q.DeleteRechirpsOf(r.Context(), database.DeleteRechirpsOfParams{ID: chirpID, UserID: userID}); err != nil {
+			return err
+		}
+		return q.DeleteChirpByID(r.Context(), database.DeleteChirpByIDParams{ID: chirpID, UserID: userID})
+	})
 	if errDel != nil {
-		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerDeleteChirp: failed to del chirpID - %s", chirpID), err)
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerDeleteChirp: failed to del chirpID - %s", chirpID), errDel)
 		return
 	}
+	cfg.deleteBlobs(blobKeys)
+	cfg.federateDelete(chirp)
 
 	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
 }
 
 func (cfg *apiConfig) handlerGetChirp(w http.ResponseWriter, r *http.Request) {
 	type response struct {
-		ID        uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserId    uuid.UUID `json:"user_id"`
+		Chirp
 	}
 	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
 
@@ -127,120 +376,184 @@ func (cfg *apiConfig) handlerGetChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	viewerID, err := cfg.viewerID(r)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetChirp: %s", err), err)
+		return
+	}
+
 	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
 
-	if err == sql.ErrNoRows {
+	if err != nil && err != sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirp: failed to get chirp %s", err), err)
+		return
+	}
+	if err == sql.ErrNoRows || (!chirpVisibleTo(chirp, viewerID) && !chirp.HiddenAt.Valid) {
 		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetChirp: chirp with ID - %s not exist", chirpID), err)
 		return
 	}
 
-	helpers.ResponseWithJson(w, http.StatusOK, response{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserId:    chirp.UserID,
-	})
+	if withheld, err := cfg.chirpWithheldFrom(r.Context(), chirp, viewerID); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirp: failed to check visibility %s", err), err)
+		return
+	} else if withheld {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetChirp: chirp with ID - %s not exist", chirpID), nil)
+		return
+	}
+
+	//* a hidden chirp is still there for admins reviewing it
+	if !chirpVisibleTo(chirp, viewerID) {
+		isAdmin, err := cfg.isAdmin(r.Context(), viewerID)
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirp: failed to get viewer %s", err), err)
+			return
+		}
+		if !isAdmin || chirp.DeletedAt.Valid || chirp.Status != chirpStatusPublished {
+			helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetChirp: chirp with ID - %s not exist", chirpID), nil)
+			return
+		}
+	}
+
+	resp := response{Chirp: chirpFromDB(chirp)}
+	if err := cfg.hydrateChirps(r.Context(), viewerID, &resp.Chirp); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirp: failed to load chirp details %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, resp)
 }
 
 func (cfg *apiConfig) handlerGetChirps(w http.ResponseWriter, r *http.Request) {
 	type response struct {
-		ID        uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserId    uuid.UUID `json:"user_id"`
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	viewerID, err := cfg.viewerID(r)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetChirps: %s", err), err)
+		return
 	}
 
-	sortQueryParam := r.URL.Query().Get("sort")
+	query := r.URL.Query()
+	sortQueryParam := query.Get("sort")
 
-	if authorQueryParam := r.URL.Query().Get("author_id"); authorQueryParam != "" {
+	authorID := uuid.NullUUID{}
+	if authorQueryParam := query.Get("author_id"); authorQueryParam != "" {
 		userID, err := uuid.Parse(authorQueryParam)
 		if err != nil {
 			helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetChirps: failed parse userID %s\n", authorQueryParam), err)
 			return
 		}
+		authorID = uuid.NullUUID{UUID: userID, Valid: true}
+	}
 
-		chirps, err := cfg.db.GetChirpsByUserID(r.Context(), userID)
-		if err != nil {
-			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirps: failed to get chirps of userID %s\n", authorQueryParam), err)
-			return
-		}
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetChirps: %s", err), err)
+		return
+	}
 
-		if sortQueryParam != "" && sortQueryParam == "desc" {
-			// *func(i, j int) bool; Ask i < j true ?
-			// *note order j index 0, i index 1
-			// *if true swap i index 0, j index 1
-			sort.Slice(chirps, func(i, j int) bool {
-				return chirps[i].CreatedAt.After(chirps[j].CreatedAt)
-			})
-		}
+	cursor, err := pagination.DecodeCursor(query.Get("cursor"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetChirps: %s", err), err)
+		return
+	}
 
-		//* map chirps to reponses
-		responses := make([]response, len(chirps))
+	isAdmin, err := cfg.isAdmin(r.Context(), viewerID)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirps: failed to get viewer %s", err), err)
+		return
+	}
 
-		for i, c := range chirps {
-			responses[i] = response{
-				ID:        c.ID,
-				CreatedAt: c.CreatedAt,
-				UpdatedAt: c.UpdatedAt,
-				Body:      c.Body,
-				UserId:    c.UserID,
-			}
+	//* an author's pins lead the first page of the listing, whatever its sort, and count towards
+	//* its limit; every page leaves them out of the chronological part, so they show up only once
+	var pinned []database.GetPinnedChirpsRow
+	if authorID.Valid {
+		pinned, err = cfg.db.GetPinnedChirps(r.Context(), database.GetPinnedChirpsParams{
+			UserID:   authorID.UUID,
+			ViewerID: viewerID,
+		})
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirps: failed to get pinned chirps %s\n", err), err)
+			return
 		}
+	}
+	pinnedIDs := make(map[uuid.UUID]struct{}, len(pinned))
+	for _, row := range pinned {
+		pinnedIDs[row.Chirp.ID] = struct{}{}
+	}
 
-		helpers.ResponseWithJson(w, http.StatusOK, responses)
-		return
+	//* at least one unpinned chirp so the next cursor gets past the pins: only an author with
+	//* limit or more pins gets a first page longer than limit
+	pageLimit := limit
+	if cursor.IsZero() {
+		pageLimit = max(limit-int32(len(pinned)), 1)
 	}
 
-	chirps, err := cfg.db.GetChirps(r.Context())
+	//* fetch one extra row to know whether there is a next page, plus room for the pins dropped below
+	var chirps []database.Chirp
+	switch sortQueryParam {
+	case "", "asc":
+		chirps, err = cfg.db.ListChirpsAsc(r.Context(), database.ListChirpsAscParams{
+			AuthorID:        authorID,
+			ViewerID:        viewerID,
+			IncludeHidden:   isAdmin,
+			CursorCreatedAt: cursor.NullCreatedAt(),
+			CursorID:        cursor.NullID(),
+			PageLimit:       pageLimit + int32(len(pinned)) + 1,
+		})
+	case "desc":
+		chirps, err = cfg.db.ListChirpsDesc(r.Context(), database.ListChirpsDescParams{
+			AuthorID:        authorID,
+			ViewerID:        viewerID,
+			IncludeHidden:   isAdmin,
+			CursorCreatedAt: cursor.NullCreatedAt(),
+			CursorID:        cursor.NullID(),
+			PageLimit:       pageLimit + int32(len(pinned)) + 1,
+		})
+	default:
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetChirps: invalid sort %s", sortQueryParam), nil)
+		return
+	}
 
 	if err != nil {
 		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirps: failed to get chirps %s\n", err), err)
 		return
 	}
 
-	if sortQueryParam != "" && sortQueryParam == "desc" {
-		sort.Slice(chirps, func(i, j int) bool {
-			return chirps[i].CreatedAt.After(chirps[j].CreatedAt)
-		})
+	unpinned := chirps[:0]
+	for _, c := range chirps {
+		if _, ok := pinnedIDs[c.ID]; !ok {
+			unpinned = append(unpinned, c)
+		}
 	}
+	chirps, nextCursor := pagination.Paginate(unpinned, pageLimit, chirpCursor)
 
 	//* map chirps to reponses
-	responses := make([]response, len(chirps))
-
-	for i, c := range chirps {
-		responses[i] = response{
-			ID:        c.ID,
-			CreatedAt: c.CreatedAt,
-			UpdatedAt: c.UpdatedAt,
-			Body:      c.Body,
-			UserId:    c.UserID,
+	responses := make([]Chirp, 0, len(pinned)+len(chirps))
+	if cursor.IsZero() {
+		for _, row := range pinned {
+			c := chirpFromDB(row.Chirp)
+			c.Pinned = true
+			responses = append(responses, c)
 		}
 	}
-
-	helpers.ResponseWithJson(w, http.StatusOK, responses)
-}
-
-func validateChirp(msg string) (string, error) {
-	const maxChirpLength = 140
-	profaneWords := map[string]struct{}{
-		"kerfuffle": {},
-		"sharbert":  {},
-		"fornax":    {},
+	for _, c := range chirps {
+		responses = append(responses, chirpFromDB(c))
 	}
 
-	if len(msg) > maxChirpLength {
-		return "", errors.New("chirp is too long")
+	if err := cfg.hydrateChirps(r.Context(), viewerID, chirpRefs(responses)...); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirps: failed to load chirp details %s", err), err)
+		return
 	}
 
-	origins := strings.Split(msg, " ")
-
-	for i, w := range origins {
-		if _, ok := profaneWords[strings.ToLower(w)]; ok {
-			origins[i] = "****"
-		}
-	}
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Chirps:     responses,
+		NextCursor: nextCursor,
+	})
+}
 
-	return strings.Join(origins, " "), nil
+func chirpCursor(c database.Chirp) string {
+	return pagination.EncodeCursor(pagination.Cursor{CreatedAt: c.CreatedAt, ID: c.ID})
 }
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/media"
+)
+
+// maxChirpFormSize - every attachment at its cap plus room for the text fields
+const maxChirpFormSize = media.MaxAttachments*media.MaxAttachmentSize + 1<<20
+
+var errTooManyAttachments = fmt.Errorf("at most %d attachments per chirp", media.MaxAttachments)
+
+type Attachment struct {
+	ID          uuid.UUID `json:"id"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+}
+
+// upload - a validated image, metadata already stripped
+type upload struct {
+	data        []byte
+	contentType string
+}
+
+// chirpForm - fields of a multipart POST /api/chirps
+type chirpForm struct {
+	body       string
+	inReplyTo  string
+	status     string
+	publishAt  string
+	visibility string
+	uploads    []upload
+	// pollOptions - one poll_options field per option
+	pollOptions  []string
+	pollClosesAt string
+}
+
+// readChirpForm - streams the multipart body, sniffing and stripping each image as it arrives
+func readChirpForm(w http.ResponseWriter, r *http.Request) (chirpForm, error) {
+	form := chirpForm{}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxChirpFormSize)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return form, err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return form, nil
+		}
+		if err != nil {
+			return form, err
+		}
+
+		switch part.FormName() {
+		case "body":
+			form.body, err = readFormValue(part)
+		case "in_reply_to":
+			form.inReplyTo, err = readFormValue(part)
+		case "status":
+			form.status, err = readFormValue(part)
+		case "publish_at":
+			form.publishAt, err = readFormValue(part)
+		case "visibility":
+			form.visibility, err = readFormValue(part)
+		case "poll_options":
+			var option string
+			option, err = readFormValue(part)
+			form.pollOptions = append(form.pollOptions, option)
+		case "poll_closes_at":
+			form.pollClosesAt, err = readFormValue(part)
+		case "attachments":
+			if len(form.uploads) == media.MaxAttachments {
+				return form, errTooManyAttachments
+			}
+			var u upload
+			u, err = readUpload(part)
+			form.uploads = append(form.uploads, u)
+		}
+		part.Close()
+
+		if err != nil {
+			return form, err
+		}
+	}
+}
+
+func readFormValue(part *multipart.Part) (string, error) {
+	value, err := io.ReadAll(io.LimitReader(part, 1<<16))
+	return string(value), err
+}
+
+func readUpload(part *multipart.Part) (upload, error) {
+	data, err := io.ReadAll(io.LimitReader(part, media.MaxAttachmentSize+1))
+	if err != nil {
+		return upload{}, err
+	}
+	if len(data) > media.MaxAttachmentSize {
+		return upload{}, media.ErrTooLarge
+	}
+
+	//* the part's Content-Type header is client controlled, trust the bytes instead
+	contentType, err := media.Sniff(data)
+	if err != nil {
+		return upload{}, err
+	}
+
+	data, err = media.StripMetadata(contentType, data)
+	if err != nil {
+		return upload{}, err
+	}
+
+	return upload{data: data, contentType: contentType}, nil
+}
+
+// uploadStatus - HTTP status for an error out of readChirpForm
+func uploadStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, media.ErrTooLarge), errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, media.ErrUnsupportedType):
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// storeUploads - writes the blobs before the chirp row exists, callers delete the keys if the insert fails
+func (cfg *apiConfig) storeUploads(ctx context.Context, uploads []upload) ([]string, error) {
+	keys := make([]string, 0, len(uploads))
+	for _, u := range uploads {
+		key := "chirps/" + uuid.NewString() + media.Extension(u.contentType)
+		if err := cfg.blobs.Put(ctx, key, bytes.NewReader(u.data), int64(len(u.data)), u.contentType); err != nil {
+			cfg.deleteBlobs(keys)
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// createChirpAttachments - rows for blobs stored by storeUploads, in upload order
+func createChirpAttachments(ctx context.Context, q *database.Queries, chirpID uuid.UUID, uploads []upload, keys []string) error {
+	for i, u := range uploads {
+		if _, err := q.CreateChirpAttachment(ctx, database.CreateChirpAttachmentParams{
+			ChirpID:     chirpID,
+			Position:    int32(i),
+			BlobKey:     keys[i],
+			ContentType: u.contentType,
+			SizeBytes:   int64(len(u.data)),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteBlobs - best effort, a leftover blob is only wasted space
+func (cfg *apiConfig) deleteBlobs(keys []string) {
+	for _, key := range keys {
+		if err := cfg.blobs.Delete(context.Background(), key); err != nil {
+			log.Printf("deleteBlobs: failed to delete %s %s\n", key, err)
+		}
+	}
+}
+
+// attachMedia - fills Chirp.Attachments with one query for the whole page
+func (cfg *apiConfig) attachMedia(ctx context.Context, chirps ...*Chirp) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, len(chirps))
+	for i, c := range chirps {
+		chirpIDs[i] = c.ID
+	}
+
+	attachments, err := cfg.db.ListChirpAttachments(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	byChirp := make(map[uuid.UUID][]Attachment, len(chirps))
+	for _, a := range attachments {
+		byChirp[a.ChirpID] = append(byChirp[a.ChirpID], Attachment{
+			ID:          a.ID,
+			URL:         cfg.blobs.URL(a.BlobKey),
+			ContentType: a.ContentType,
+			Size:        a.SizeBytes,
+		})
+	}
+
+	for _, c := range chirps {
+		c.Attachments = byChirp[c.ID]
+	}
+
+	return nil
+}
+
+// handlerGetMedia - an attachment from the local store, only for viewers who may see its chirp
+func (cfg *apiConfig) handlerGetMedia(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+
+	viewerID, err := cfg.viewerID(r)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetMedia: %s", err), err)
+		return
+	}
+
+	//* no row for blobs of a chirp that failed to insert, they are only waiting to be removed
+	chirp, err := cfg.db.GetChirpByBlobKey(r.Context(), key)
+	if err != nil && err != sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetMedia: failed to get chirp %s", err), err)
+		return
+	}
+	if err == sql.ErrNoRows || !chirpVisibleTo(chirp, viewerID) {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetMedia: media %s not exist", key), err)
+		return
+	}
+
+	if withheld, err := cfg.chirpWithheldFrom(r.Context(), chirp, viewerID); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetMedia: failed to check visibility %s", err), err)
+		return
+	} else if withheld {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetMedia: media %s not exist", key), nil)
+		return
+	}
+
+	cfg.mediaFiles.ServeHTTP(w, r)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/entities"
+	"github.com/trantuvan/chirpy/internal/pagination"
+)
+
+// indexChirpEntities - (re)writes the hashtag and mention rows of a chirp from its body
+func indexChirpEntities(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if err := q.DeleteChirpHashtags(ctx, chirp.ID); err != nil {
+		return err
+	}
+	if err := q.DeleteChirpMentions(ctx, chirp.ID); err != nil {
+		return err
+	}
+
+	if tags := entities.Hashtags(chirp.Body); len(tags) > 0 {
+		if err := q.InsertChirpHashtags(ctx, database.InsertChirpHashtagsParams{
+			ChirpID:   chirp.ID,
+			Tags:      tags,
+			CreatedAt: chirp.CreatedAt,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if handles := entities.Mentions(chirp.Body); len(handles) > 0 {
+		if err := q.InsertChirpMentions(ctx, database.InsertChirpMentionsParams{
+			ChirpID:   chirp.ID,
+			CreatedAt: chirp.CreatedAt,
+			Handles:   handles,
+			AuthorID:  chirp.UserID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cfg *apiConfig) handlerGetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+
+	viewerID, err := cfg.viewerID(r)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetHashtagChirps: %s", err), err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetHashtagChirps: %s", err), err)
+		return
+	}
+
+	cursor, err := pagination.DecodeCursor(query.Get("cursor"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetHashtagChirps: %s", err), err)
+		return
+	}
+
+	rows, err := cfg.db.ListChirpsByHashtag(r.Context(), database.ListChirpsByHashtagParams{
+		Tag:             tag,
+		ViewerID:        viewerID,
+		CursorCreatedAt: cursor.NullCreatedAt(),
+		CursorID:        cursor.NullID(),
+		PageLimit:       limit + 1,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetHashtagChirps: failed to get chirps %s", err), err)
+		return
+	}
+
+	rows, nextCursor := pagination.Paginate(rows, limit, func(row database.ListChirpsByHashtagRow) string {
+		return chirpCursor(row.Chirp)
+	})
+
+	responses := make([]Chirp, len(rows))
+	for i, row := range rows {
+		responses[i] = chirpFromDB(row.Chirp)
+	}
+
+	if err := cfg.hydrateChirps(r.Context(), viewerID, chirpRefs(responses)...); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetHashtagChirps: failed to load chirp details %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Chirps:     responses,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *apiConfig) handlerGetUserMentions(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetUserMentions: %s", err), err)
+		return
+	}
+
+	viewerID, err := cfg.viewerID(r)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetUserMentions: %s", err), err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetUserMentions: %s", err), err)
+		return
+	}
+
+	cursor, err := pagination.DecodeCursor(query.Get("cursor"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetUserMentions: %s", err), err)
+		return
+	}
+
+	rows, err := cfg.db.ListChirpsMentioningUser(r.Context(), database.ListChirpsMentioningUserParams{
+		UserID:          userID,
+		ViewerID:        viewerID,
+		CursorCreatedAt: cursor.NullCreatedAt(),
+		CursorID:        cursor.NullID(),
+		PageLimit:       limit + 1,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetUserMentions: failed to get chirps %s", err), err)
+		return
+	}
+
+	rows, nextCursor := pagination.Paginate(rows, limit, func(row database.ListChirpsMentioningUserRow) string {
+		return chirpCursor(row.Chirp)
+	})
+
+	responses := make([]Chirp, len(rows))
+	for i, row := range rows {
+		responses[i] = chirpFromDB(row.Chirp)
+	}
+
+	if err := cfg.hydrateChirps(r.Context(), viewerID, chirpRefs(responses)...); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetUserMentions: failed to load chirp details %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Chirps:     responses,
+		NextCursor: nextCursor,
+	})
+}
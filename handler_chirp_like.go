@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/moderation"
+	"github.com/trantuvan/chirpy/internal/notification"
+	"github.com/trantuvan/chirpy/internal/visibility"
+)
+
+func (cfg *apiConfig) handlerLikeChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerLikeChirp: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerLikeChirp: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerLikeChirp: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerLikeChirp", userID); !ok {
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil && err != sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerLikeChirp: failed to get chirp %s", err), err)
+		return
+	}
+	if err == sql.ErrNoRows || !chirpVisibleTo(chirp, uuid.NullUUID{}) {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerLikeChirp: chirp with ID - %s not exist", chirpID), err)
+		return
+	}
+
+	if withheld, err := cfg.chirpWithheldFrom(r.Context(), chirp, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerLikeChirp: failed to check visibility %s", err), err)
+		return
+	} else if withheld {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerLikeChirp: chirp with ID - %s not exist", chirpID), nil)
+		return
+	}
+
+	liked, err := cfg.db.LikeChirp(r.Context(), database.LikeChirpParams{UserID: userID, ChirpID: chirpID})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerLikeChirp: failed to like chirp %s", err), err)
+		return
+	}
+	//* liking again changes nothing, and tells nobody
+	if liked > 0 {
+		cfg.notify(notification.Event{Kind: notification.Like, ActorID: userID, ChirpID: chirpID})
+		cfg.federateLike(userID, chirpID, false)
+	}
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) handlerUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerUnlikeChirp: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerUnlikeChirp: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerUnlikeChirp: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerUnlikeChirp", userID); !ok {
+		return
+	}
+
+	unliked, err := cfg.db.UnlikeChirp(r.Context(), database.UnlikeChirpParams{UserID: userID, ChirpID: chirpID})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerUnlikeChirp: failed to unlike chirp %s", err), err)
+		return
+	}
+	if unliked > 0 {
+		cfg.federateLike(userID, chirpID, true)
+	}
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) handlerRechirp(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		Body string `json:"body"`
+	}
+	type response struct {
+		Chirp
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerRechirp: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerRechirp: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerRechirp: %s", err), err)
+		return
+	}
+
+	user, ok := cfg.requireActiveUser(w, r, "handlerRechirp", userID)
+	if !ok {
+		return
+	}
+
+	//* body is optional: empty request is a plain rechirp, a body makes it a quote
+	params := parameter{}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil && !errors.Is(err, io.EOF) {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerRechirp: failed to read params %s", err), err)
+		return
+	}
+	//* a quote is a chirp of the user's own words
+	if params.Body != "" && !cfg.requireCanChirp(w, "handlerRechirp", user) {
+		return
+	}
+
+	chirp, ok := cfg.requireRechirpable(w, r, chirpID, userID)
+	if !ok {
+		return
+	}
+	//* rechirping a plain rechirp counts towards the original, which has to pass the same checks:
+	//* the rechirp may still be public after its original was narrowed or withheld
+	if chirp.RechirpOf.Valid && chirp.Body == "" {
+		if chirp, ok = cfg.requireRechirpable(w, r, chirp.RechirpOf.UUID, userID); !ok {
+			return
+		}
+	}
+	original := chirp.ID
+
+	moderated := moderation.Result{}
+	if params.Body != "" {
+		moderated, err = cfg.moderator.Moderate(params.Body)
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerRechirp: %s", err), err)
+			return
+		}
+	}
+
+	var rechirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		rechirp, err = q.CreateRechirp(r.Context(), database.CreateRechirpParams{
+			Body:      moderated.Text,
+			UserID:    userID,
+			RechirpOf: original,
+		})
+		if err != nil {
+			return err
+		}
+		if err := recordChirpFlags(r.Context(), q, rechirp.ID, moderated); err != nil {
+			return err
+		}
+		return indexChirpEntities(r.Context(), q, rechirp)
+	})
+	if err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusConflict, fmt.Sprintf("handlerRechirp: chirp ID - %s already rechirped", original), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerRechirp: failed to rechirp %s", err), err)
+		return
+	}
+	cfg.notify(notification.Event{Kind: notification.Rechirp, ActorID: userID, ChirpID: original})
+	if rechirp.Body != "" {
+		cfg.notifyPublished(rechirp.ID)
+		cfg.federateChirp(rechirp.ID)
+	} else {
+		cfg.federateRechirp(userID, original, false)
+	}
+
+	resp := response{Chirp: chirpFromDB(rechirp)}
+	if err := cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, &resp.Chirp); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerRechirp: failed to load chirp details %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusCreated, resp)
+}
+
+// requireRechirpable - the chirp with chirpID when userID may rechirp it; otherwise writes the
+// error response and returns false
+func (cfg *apiConfig) requireRechirpable(w http.ResponseWriter, r *http.Request, chirpID, userID uuid.UUID) (database.Chirp, bool) {
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil && err != sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerRechirp: failed to get chirp %s", err), err)
+		return database.Chirp{}, false
+	}
+	if err == sql.ErrNoRows || !chirpVisibleTo(chirp, uuid.NullUUID{}) {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerRechirp: chirp with ID - %s not exist", chirpID), err)
+		return database.Chirp{}, false
+	}
+
+	if withheld, err := cfg.chirpWithheldFrom(r.Context(), chirp, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerRechirp: failed to check visibility %s", err), err)
+		return database.Chirp{}, false
+	} else if withheld {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerRechirp: chirp with ID - %s not exist", chirpID), nil)
+		return database.Chirp{}, false
+	}
+
+	//* a rechirp is public, so anything narrower would leak past its audience
+	if chirp.Visibility != string(visibility.Public) {
+		helpers.ResponseWithError(w, http.StatusForbidden, fmt.Sprintf("handlerRechirp: chirp ID - %s is not public", chirpID), nil)
+		return database.Chirp{}, false
+	}
+	return chirp, true
+}
+
+func (cfg *apiConfig) handlerUndoRechirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerUndoRechirp: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerUndoRechirp: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerUndoRechirp: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerUndoRechirp", userID); !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteRechirp(r.Context(), database.DeleteRechirpParams{
+		UserID:    userID,
+		RechirpOf: uuid.NullUUID{UUID: chirpID, Valid: true},
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerUndoRechirp: failed to undo rechirp %s", err), err)
+		return
+	}
+	if deleted == 0 {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerUndoRechirp: chirp ID - %s not rechirped", chirpID), nil)
+		return
+	}
+	//* the DELETE logs the removal for the stream through chirps_record_event, as the INSERT
+	//* logged the rechirp; federation is sent from here, as on create
+	cfg.federateRechirp(userID, chirpID, true)
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
+
+// attachOriginals - fills Chirp.Original for rechirps and quotes and returns the originals the
+// viewer may read, to be hydrated with the rest; a deleted original, or one the viewer may not
+// read, keeps its place as a placeholder, as ancestors do in a thread
+func (cfg *apiConfig) attachOriginals(ctx context.Context, viewer uuid.NullUUID, chirps ...*Chirp) ([]*Chirp, error) {
+	var originalIDs []uuid.UUID
+	for _, c := range chirps {
+		if c.RechirpOf != nil {
+			originalIDs = append(originalIDs, *c.RechirpOf)
+		}
+	}
+	if len(originalIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := cfg.db.ListChirpsByIDs(ctx, originalIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var readable []*Chirp
+	byID := make(map[uuid.UUID]*Chirp, len(rows))
+	for _, o := range rows {
+		withheld, err := cfg.chirpWithheldFrom(ctx, o, viewer)
+		if err != nil {
+			return nil, err
+		}
+
+		original := chirpFromDB(o)
+		if withheld || !chirpVisibleTo(o, viewer) {
+			original = chirpPlaceholder(o)
+			original.Hidden = o.HiddenAt.Valid
+		} else {
+			readable = append(readable, &original)
+		}
+		byID[o.ID] = &original
+	}
+
+	for _, c := range chirps {
+		if c.RechirpOf != nil {
+			c.Original = byID[*c.RechirpOf]
+		}
+	}
+	return readable, nil
+}
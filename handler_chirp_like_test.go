@@ -0,0 +1,152 @@
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/visibility"
+)
+
+func TestHandlerRechirpChecksOriginal(t *testing.T) {
+	now := time.Now()
+	user := database.User{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Email: sql.NullString{String: "alice@example.com", Valid: true}}
+
+	tests := []struct {
+		name     string
+		original database.Chirp
+		wantCode int
+	}{
+		{
+			name: "Original hidden",
+			original: database.Chirp{
+				Visibility: string(visibility.Public),
+				HiddenAt:   sql.NullTime{Time: now, Valid: true},
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name: "Original deleted",
+			original: database.Chirp{
+				Visibility: string(visibility.Public),
+				DeletedAt:  sql.NullTime{Time: now, Valid: true},
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Original narrowed to followers the user is one of",
+			original: database.Chirp{Visibility: string(visibility.Followers)},
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := tt.original
+			original.ID, original.UserID, original.ConversationID = uuid.New(), uuid.New(), uuid.New()
+			original.CreatedAt, original.UpdatedAt, original.Status = now, now, chirpStatusPublished
+			original.Body = "the original"
+
+			//* a plain rechirp of it by someone else, still public
+			rechirp := database.Chirp{
+				ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: uuid.New(), ConversationID: uuid.New(),
+				RechirpOf:  uuid.NullUUID{UUID: original.ID, Valid: true},
+				Status:     chirpStatusPublished,
+				Visibility: string(visibility.Public),
+			}
+
+			db := newFakeDB()
+			db.answer("GetUserByID", userRow(user))
+			db.answer("IsBlockedBetween", []driver.Value{false})
+			db.answer("GetChirpAudience", []driver.Value{true, false})
+			db.answerFunc("GetChirp", func(args []driver.NamedValue) [][]driver.Value {
+				for _, c := range []database.Chirp{original, rechirp} {
+					if args[0].Value == c.ID.String() {
+						return [][]driver.Value{chirpRow(c)}
+					}
+				}
+				return nil
+			})
+			cfg := testConfig(t, db)
+
+			r := bearer(t, httptest.NewRequest(http.MethodPost, "/api/chirps/"+rechirp.ID.String()+"/rechirp", nil), user.ID)
+			w := serve(cfg.handlerRechirp, r, map[string]string{"chirpID": rechirp.ID.String()})
+
+			if w.Code != tt.wantCode {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.wantCode)
+			}
+			if db.didRun("CreateRechirp") {
+				t.Error("the original was rechirped")
+			}
+		})
+	}
+}
+
+func TestHandlerGetChirpOriginal(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		original database.Chirp
+		wantBody string
+	}{
+		{
+			name:     "Readable original",
+			original: database.Chirp{Body: "the original"},
+			wantBody: "the original",
+		},
+		{
+			name:     "Hidden original",
+			original: database.Chirp{Body: "the original", HiddenAt: sql.NullTime{Time: now, Valid: true}},
+		},
+		{
+			name:     "Deleted original",
+			original: database.Chirp{DeletedAt: sql.NullTime{Time: now, Valid: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := tt.original
+			original.ID, original.UserID, original.ConversationID = uuid.New(), uuid.New(), uuid.New()
+			original.CreatedAt, original.UpdatedAt, original.Status = now, now, chirpStatusPublished
+			original.Visibility = string(visibility.Public)
+
+			quote := database.Chirp{
+				ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: uuid.New(), ConversationID: uuid.New(),
+				Body:       "look at this",
+				RechirpOf:  uuid.NullUUID{UUID: original.ID, Valid: true},
+				Status:     chirpStatusPublished,
+				Visibility: string(visibility.Public),
+			}
+
+			db := newFakeDB()
+			db.answer("GetChirp", chirpRow(quote))
+			db.answer("ListChirpsByIDs", chirpRow(original))
+			cfg := testConfig(t, db)
+
+			r := httptest.NewRequest(http.MethodGet, "/api/chirps/"+quote.ID.String(), nil)
+			w := serve(cfg.handlerGetChirp, r, map[string]string{"chirpID": quote.ID.String()})
+
+			var resp Chirp
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Original == nil || resp.Original.ID != original.ID {
+				t.Fatalf("got original %+v, want %s", resp.Original, original.ID)
+			}
+			if resp.Original.Body != tt.wantBody {
+				t.Errorf("got body %q, want %q", resp.Original.Body, tt.wantBody)
+			}
+			if resp.Original.Hidden != original.HiddenAt.Valid || resp.Original.Deleted != original.DeletedAt.Valid {
+				t.Errorf("got hidden %t deleted %t", resp.Original.Hidden, resp.Original.Deleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+)
+
+const (
+	maxPinnedChirps = 3
+	// maxPinnedChirpsRed - the limit for is_chirpy_red users
+	maxPinnedChirpsRed = 10
+)
+
+var errPinsMismatch = errors.New("chirp_ids must list every pinned chirp exactly once")
+
+func maxPins(user database.User) int32 {
+	if user.IsChirpyRed {
+		return maxPinnedChirpsRed
+	}
+	return maxPinnedChirps
+}
+
+func (cfg *apiConfig) handlerPinChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerPinChirp: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerPinChirp: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerPinChirp: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerPinChirp", userID); !ok {
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err == sql.ErrNoRows || chirp.DeletedAt.Valid {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerPinChirp: chirp with ID - %s not exist", chirpID), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerPinChirp: failed to get chirp %s", err), err)
+		return
+	}
+	if chirp.UserID != userID {
+		helpers.ResponseWithError(w, http.StatusForbidden, fmt.Sprintf("handlerPinChirp: chirp ID - %s not belong to userID - %s", chirpID, userID), nil)
+		return
+	}
+	if chirp.Status != chirpStatusPublished {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerPinChirp: chirp ID - %s is not published", chirpID), nil)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerPinChirp: failed to get user %s", err), err)
+		return
+	}
+
+	//* pinning again is a no-op, so only a new pin can run into the limit
+	pinned, err := cfg.db.ListPinnedChirpIDs(r.Context(), userID)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerPinChirp: failed to get pins %s", err), err)
+		return
+	}
+	if slices.Contains(pinned, chirpID) {
+		helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+		return
+	}
+
+	inserted, err := cfg.db.PinChirp(r.Context(), database.PinChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+		MaxPins: maxPins(user),
+	})
+	if isUniqueViolation(err) {
+		helpers.ResponseWithError(w, http.StatusConflict, "handlerPinChirp: pins changed concurrently, try again", err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerPinChirp: failed to pin chirp %s", err), err)
+		return
+	}
+	if inserted == 0 {
+		helpers.ResponseWithError(w, http.StatusConflict, fmt.Sprintf("handlerPinChirp: cannot pin more than %d chirps", maxPins(user)), nil)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) handlerUnpinChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerUnpinChirp: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerUnpinChirp: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerUnpinChirp: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerUnpinChirp", userID); !ok {
+		return
+	}
+
+	if err := cfg.db.UnpinChirp(r.Context(), database.UnpinChirpParams{UserID: userID, ChirpID: chirpID}); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerUnpinChirp: failed to unpin chirp %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
+
+// handlerReorderPins - the body lists all of the user's pins, first one on top
+func (cfg *apiConfig) handlerReorderPins(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		ChirpIDs []uuid.UUID `json:"chirp_ids"`
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerReorderPins: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerReorderPins: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerReorderPins", userID); !ok {
+		return
+	}
+
+	params := parameter{}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerReorderPins: failed to read params %s", err), err)
+		return
+	}
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		pinned, err := q.ListPinnedChirpIDs(r.Context(), userID)
+		if err != nil {
+			return err
+		}
+		if !samePins(pinned, params.ChirpIDs) {
+			return errPinsMismatch
+		}
+		return q.ReorderPins(r.Context(), database.ReorderPinsParams{
+			ChirpIds: params.ChirpIDs,
+			UserID:   userID,
+		})
+	})
+	if errors.Is(err, errPinsMismatch) {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerReorderPins: %s", err), err)
+		return
+	}
+	if isUniqueViolation(err) {
+		helpers.ResponseWithError(w, http.StatusConflict, "handlerReorderPins: pins changed concurrently, try again", err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerReorderPins: failed to reorder pins %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
+
+// samePins - whether requested is a permutation of pinned
+func samePins(pinned, requested []uuid.UUID) bool {
+	if len(pinned) != len(requested) {
+		return false
+	}
+
+	remaining := make(map[uuid.UUID]struct{}, len(pinned))
+	for _, id := range pinned {
+		remaining[id] = struct{}{}
+	}
+	for _, id := range requested {
+		if _, ok := remaining[id]; !ok {
+			return false
+		}
+		delete(remaining, id)
+	}
+
+	return true
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/poll"
+)
+
+// pollParameter - the poll part of a POST /api/chirps body
+type pollParameter struct {
+	Options  []string  `json:"options"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+type Poll struct {
+	ClosesAt   time.Time    `json:"closes_at"`
+	Closed     bool         `json:"closed"`
+	TotalVotes *int32       `json:"total_votes,omitempty"`
+	VotedFor   *uuid.UUID   `json:"voted_option_id,omitempty"`
+	Options    []PollOption `json:"options"`
+}
+
+type PollOption struct {
+	ID    uuid.UUID `json:"id"`
+	Label string    `json:"label"`
+	Votes *int32    `json:"votes,omitempty"`
+}
+
+// createChirpPoll - options were already cleaned by poll.Validate
+func createChirpPoll(ctx context.Context, q *database.Queries, chirpID uuid.UUID, options []string, closesAt time.Time) error {
+	if err := q.CreatePoll(ctx, database.CreatePollParams{ChirpID: chirpID, ClosesAt: closesAt}); err != nil {
+		return err
+	}
+	return q.CreatePollOptions(ctx, database.CreatePollOptionsParams{ChirpID: chirpID, Labels: options})
+}
+
+// attachPolls - fills Chirp.Poll for the whole page, tallies only where the viewer may see them
+func (cfg *apiConfig) attachPolls(ctx context.Context, viewer uuid.NullUUID, chirps ...*Chirp) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, len(chirps))
+	for i, c := range chirps {
+		chirpIDs[i] = c.ID
+	}
+
+	polls, err := cfg.db.ListPolls(ctx, chirpIDs)
+	if err != nil || len(polls) == 0 {
+		return err
+	}
+
+	options, err := cfg.db.ListPollOptions(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	votedFor := map[uuid.UUID]uuid.UUID{}
+	if viewer.Valid {
+		votes, err := cfg.db.ListPollVotesByUser(ctx, database.ListPollVotesByUserParams{
+			UserID:   viewer.UUID,
+			ChirpIds: chirpIDs,
+		})
+		if err != nil {
+			return err
+		}
+		for _, v := range votes {
+			votedFor[v.ChirpID] = v.OptionID
+		}
+	}
+
+	optionsByChirp := make(map[uuid.UUID][]database.PollOption, len(polls))
+	for _, o := range options {
+		optionsByChirp[o.ChirpID] = append(optionsByChirp[o.ChirpID], o)
+	}
+
+	now := time.Now().UTC()
+	byChirp := make(map[uuid.UUID]*Poll, len(polls))
+	for _, p := range polls {
+		optionID, voted := votedFor[p.ChirpID]
+		showResults := poll.ResultsVisible(voted, p.ClosesAt, now)
+
+		resp := &Poll{
+			ClosesAt: p.ClosesAt,
+			Closed:   !now.Before(p.ClosesAt),
+		}
+		if voted {
+			resp.VotedFor = &optionID
+		}
+
+		total := int32(0)
+		for _, o := range optionsByChirp[p.ChirpID] {
+			option := PollOption{ID: o.ID, Label: o.Label}
+			if showResults {
+				option.Votes = &o.VoteCount
+			}
+			total += o.VoteCount
+			resp.Options = append(resp.Options, option)
+		}
+		if showResults {
+			resp.TotalVotes = &total
+		}
+
+		byChirp[p.ChirpID] = resp
+	}
+
+	for _, c := range chirps {
+		c.Poll = byChirp[c.ID]
+	}
+
+	return nil
+}
+
+func (cfg *apiConfig) handlerVotePoll(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		OptionID uuid.UUID `json:"option_id"`
+	}
+	type response struct {
+		Chirp
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerVotePoll: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerVotePoll: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerVotePoll: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerVotePoll", userID); !ok {
+		return
+	}
+
+	params := parameter{}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerVotePoll: failed to read params %s", err), err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil && err != sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerVotePoll: failed to get chirp %s", err), err)
+		return
+	}
+	if err == sql.ErrNoRows || !chirpVisibleTo(chirp, uuid.NullUUID{}) {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerVotePoll: chirp with ID - %s not exist", chirpID), err)
+		return
+	}
+
+	if withheld, err := cfg.chirpWithheldFrom(r.Context(), chirp, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerVotePoll: failed to check visibility %s", err), err)
+		return
+	} else if withheld {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerVotePoll: chirp with ID - %s not exist", chirpID), nil)
+		return
+	}
+
+	polls, err := cfg.db.ListPolls(r.Context(), []uuid.UUID{chirpID})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerVotePoll: failed to get poll %s", err), err)
+		return
+	}
+	if len(polls) == 0 {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerVotePoll: chirp ID - %s has no poll", chirpID), nil)
+		return
+	}
+	if !time.Now().UTC().Before(polls[0].ClosesAt) {
+		helpers.ResponseWithError(w, http.StatusConflict, fmt.Sprintf("handlerVotePoll: poll on chirp ID - %s is closed", chirpID), nil)
+		return
+	}
+
+	options, err := cfg.db.ListPollOptions(r.Context(), []uuid.UUID{chirpID})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerVotePoll: failed to get poll options %s", err), err)
+		return
+	}
+	validOption := false
+	for _, o := range options {
+		validOption = validOption || o.ID == params.OptionID
+	}
+	if !validOption {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerVotePoll: option ID - %s not in poll", params.OptionID), nil)
+		return
+	}
+
+	//* nothing inserted means the user already voted (or the poll closed in between)
+	voted, err := cfg.db.CastPollVote(r.Context(), database.CastPollVoteParams{
+		UserID:   userID,
+		OptionID: params.OptionID,
+		ChirpID:  chirpID,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerVotePoll: failed to vote %s", err), err)
+		return
+	}
+	if voted == 0 {
+		helpers.ResponseWithError(w, http.StatusConflict, fmt.Sprintf("handlerVotePoll: userID - %s already voted on chirp ID - %s", userID, chirpID), nil)
+		return
+	}
+
+	resp := response{Chirp: chirpFromDB(chirp)}
+	if err := cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, &resp.Chirp); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerVotePoll: failed to load chirp details %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusCreated, resp)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/visibility"
+)
+
+func (cfg *apiConfig) handlerUpdateChirp(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		Body string `json:"body"`
+	}
+	type response struct {
+		Chirp
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerUpdateChirp: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerUpdateChirp: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerUpdateChirp: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerUpdateChirp", userID); !ok {
+		return
+	}
+
+	params := parameter{}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerUpdateChirp: failed to read params %s", err), err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err == sql.ErrNoRows || chirp.DeletedAt.Valid {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerUpdateChirp: chirp with ID - %s not exist", chirpID), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerUpdateChirp: failed to get chirp %s", err), err)
+		return
+	}
+	if chirp.UserID != userID {
+		helpers.ResponseWithError(w, http.StatusForbidden, fmt.Sprintf("handlerUpdateChirp: chirp ID - %s not belong to userID - %s", chirpID, userID), nil)
+		return
+	}
+	//* a body would turn a plain rechirp into a quote
+	if chirp.RechirpOf.Valid && chirp.Body == "" {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerUpdateChirp: chirp ID - %s is a plain rechirp, it has no text to edit", chirpID), nil)
+		return
+	}
+
+	//* zero window means chirps stay editable forever
+	if cfg.chirpEditWindow > 0 && time.Since(chirp.CreatedAt) > cfg.chirpEditWindow {
+		helpers.ResponseWithError(w, http.StatusForbidden, fmt.Sprintf("handlerUpdateChirp: edit window of %s has expired", cfg.chirpEditWindow), nil)
+		return
+	}
+
+	moderated, err := cfg.moderator.Moderate(params.Body)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerUpdateChirp: %s", err), err)
+		return
+	}
+	//* mentions are re-indexed from the new body, and they are all a direct chirp's audience
+	if directWithoutMentions(visibility.Level(chirp.Visibility), moderated.Text) {
+		helpers.ResponseWithError(w, http.StatusBadRequest, "handlerUpdateChirp: a direct chirp must mention at least one user", nil)
+		return
+	}
+
+	var updatedChirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		updatedChirp, err = q.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+			ID:     chirpID,
+			UserID: userID,
+			Body:   moderated.Text,
+		})
+		if err != nil {
+			return err
+		}
+		if err := recordChirpFlags(r.Context(), q, updatedChirp.ID, moderated); err != nil {
+			return err
+		}
+		return indexChirpEntities(r.Context(), q, updatedChirp)
+	})
+	if err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerUpdateChirp: chirp with ID - %s not exist", chirpID), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerUpdateChirp: failed to update chirp %s", err), err)
+		return
+	}
+
+	resp := response{Chirp: chirpFromDB(updatedChirp)}
+	if err := cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, &resp.Chirp); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerUpdateChirp: failed to load chirp details %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerGetChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	type revision struct {
+		ID        uuid.UUID `json:"id"`
+		Body      string    `json:"body"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetChirpRevisions: %s", err), err)
+		return
+	}
+
+	viewerID, err := cfg.viewerID(r)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetChirpRevisions: %s", err), err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil && err != sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirpRevisions: failed to get chirp %s", err), err)
+		return
+	}
+	if err == sql.ErrNoRows || !chirpVisibleTo(chirp, viewerID) {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetChirpRevisions: chirp with ID - %s not exist", chirpID), err)
+		return
+	}
+
+	if withheld, err := cfg.chirpWithheldFrom(r.Context(), chirp, viewerID); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirpRevisions: failed to check visibility %s", err), err)
+		return
+	} else if withheld {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetChirpRevisions: chirp with ID - %s not exist", chirpID), nil)
+		return
+	}
+
+	revisions, err := cfg.db.GetChirpRevisions(r.Context(), chirpID)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirpRevisions: failed to get revisions %s", err), err)
+		return
+	}
+
+	responses := make([]revision, len(revisions))
+	for i, rev := range revisions {
+		responses[i] = revision{
+			ID:        rev.ID,
+			Body:      rev.Body,
+			CreatedAt: rev.CreatedAt,
+		}
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, responses)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/visibility"
+)
+
+func TestHandlerUpdateChirpRejects(t *testing.T) {
+	now := time.Now()
+	author := database.User{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Email: sql.NullString{String: "alice@example.com", Valid: true}}
+
+	tests := []struct {
+		name    string
+		chirp   database.Chirp
+		body    string
+		wantErr string
+	}{
+		{
+			name: "Plain rechirp",
+			chirp: database.Chirp{
+				RechirpOf:  uuid.NullUUID{UUID: uuid.New(), Valid: true},
+				Visibility: string(visibility.Public),
+			},
+			body:    `{"body":"now a quote"}`,
+			wantErr: "plain rechirp",
+		},
+		{
+			name: "Direct chirp losing its mentions",
+			chirp: database.Chirp{
+				Body:       "hi @bob",
+				Visibility: string(visibility.Direct),
+			},
+			body:    `{"body":"hi everyone"}`,
+			wantErr: "must mention at least one user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chirp := tt.chirp
+			chirp.ID, chirp.UserID, chirp.ConversationID = uuid.New(), author.ID, uuid.New()
+			chirp.CreatedAt, chirp.UpdatedAt, chirp.Status = now, now, chirpStatusPublished
+
+			db := newFakeDB()
+			db.answer("GetUserByID", userRow(author))
+			db.answer("GetChirp", chirpRow(chirp))
+			cfg := testConfig(t, db)
+
+			r := bearer(t, httptest.NewRequest(http.MethodPut, "/api/chirps/"+chirp.ID.String(), strings.NewReader(tt.body)), author.ID)
+			w := serve(cfg.handlerUpdateChirp, r, map[string]string{"chirpID": chirp.ID.String()})
+
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.wantErr) {
+				t.Errorf("got %d %s, want %d %q", w.Code, w.Body, http.StatusBadRequest, tt.wantErr)
+			}
+			if db.didRun("UpdateChirpBody") {
+				t.Error("the chirp was updated")
+			}
+		})
+	}
+}
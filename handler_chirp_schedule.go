@@ -0,0 +1,166 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/pagination"
+)
+
+// chirpStatus - resolves the requested status, a publish_at alone means scheduled
+func chirpStatus(status string, publishAt *time.Time, now time.Time) (string, sql.NullTime, error) {
+	if status == "" {
+		status = chirpStatusPublished
+		if publishAt != nil {
+			status = chirpStatusScheduled
+		}
+	}
+
+	switch status {
+	case chirpStatusScheduled:
+		if publishAt == nil || !publishAt.After(now) {
+			return "", sql.NullTime{}, errors.New("scheduled chirps need a publish_at in the future")
+		}
+		return status, sql.NullTime{Time: publishAt.UTC(), Valid: true}, nil
+	case chirpStatusDraft, chirpStatusPublished:
+		if publishAt != nil {
+			return "", sql.NullTime{}, fmt.Errorf("publish_at is only allowed with status %s", chirpStatusScheduled)
+		}
+		return status, sql.NullTime{}, nil
+	default:
+		return "", sql.NullTime{}, fmt.Errorf("invalid status %s", status)
+	}
+}
+
+func (cfg *apiConfig) handlerGetUnpublishedChirps(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetUnpublishedChirps: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetUnpublishedChirps: %s", err), err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	status := sql.NullString{}
+	switch statusQueryParam := query.Get("status"); statusQueryParam {
+	case "":
+	case chirpStatusDraft, chirpStatusScheduled:
+		status = sql.NullString{String: statusQueryParam, Valid: true}
+	default:
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetUnpublishedChirps: invalid status %s", statusQueryParam), nil)
+		return
+	}
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetUnpublishedChirps: %s", err), err)
+		return
+	}
+
+	cursor, err := pagination.DecodeCursor(query.Get("cursor"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetUnpublishedChirps: %s", err), err)
+		return
+	}
+
+	chirps, err := cfg.db.ListUnpublishedChirps(r.Context(), database.ListUnpublishedChirpsParams{
+		UserID:          userID,
+		Status:          status,
+		CursorCreatedAt: cursor.NullCreatedAt(),
+		CursorID:        cursor.NullID(),
+		PageLimit:       limit + 1,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetUnpublishedChirps: failed to get chirps %s", err), err)
+		return
+	}
+
+	chirps, nextCursor := pagination.Paginate(chirps, limit, chirpCursor)
+
+	responses := make([]Chirp, len(chirps))
+	for i, c := range chirps {
+		responses[i] = chirpFromDB(c)
+	}
+
+	if err := cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirpRefs(responses)...); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetUnpublishedChirps: failed to load chirp details %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Chirps:     responses,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *apiConfig) handlerPublishChirp(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirp
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerPublishChirp: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerPublishChirp: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerPublishChirp: %s", err), err)
+		return
+	}
+
+	user, ok := cfg.requireActiveUser(w, r, "handlerPublishChirp", userID)
+	if !ok || !cfg.requireCanChirp(w, "handlerPublishChirp", user) {
+		return
+	}
+
+	//* no row back means missing, someone else's, or already published
+	if _, err := cfg.db.PublishChirp(r.Context(), database.PublishChirpParams{ID: chirpID, UserID: userID}); err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerPublishChirp: unpublished chirp with ID - %s not exist", chirpID), err)
+		return
+	} else if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerPublishChirp: failed to publish chirp %s", err), err)
+		return
+	}
+	cfg.notifyPublished(chirpID)
+	cfg.federateChirp(chirpID)
+
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerPublishChirp: failed to get chirp %s", err), err)
+		return
+	}
+
+	resp := response{Chirp: chirpFromDB(chirp)}
+	if err := cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, &resp.Chirp); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerPublishChirp: failed to load chirp details %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, resp)
+}
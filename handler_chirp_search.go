@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/pagination"
+	"github.com/trantuvan/chirpy/internal/search"
+)
+
+func (cfg *apiConfig) handlerSearchChirps(w http.ResponseWriter, r *http.Request) {
+	type result struct {
+		Chirp
+		Rank float32 `json:"rank"`
+		//* HTML: the escaped chirp text with matches wrapped in <mark>
+		Snippet string `json:"snippet"`
+	}
+	type response struct {
+		Chirps     []result `json:"chirps"`
+		NextCursor string   `json:"next_cursor,omitempty"`
+	}
+
+	viewerID, err := cfg.viewerID(r)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerSearchChirps: %s", err), err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	tsQuery, err := search.BuildTSQuery(query.Get("q"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerSearchChirps: %s", err), err)
+		return
+	}
+
+	authorID := uuid.NullUUID{}
+	if authorQueryParam := query.Get("author_id"); authorQueryParam != "" {
+		userID, err := uuid.Parse(authorQueryParam)
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerSearchChirps: failed parse userID %s", authorQueryParam), err)
+			return
+		}
+		authorID = uuid.NullUUID{UUID: userID, Valid: true}
+	}
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerSearchChirps: %s", err), err)
+		return
+	}
+
+	cursor, err := pagination.DecodeRankCursor(query.Get("cursor"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerSearchChirps: %s", err), err)
+		return
+	}
+
+	rows, err := cfg.db.SearchChirps(r.Context(), database.SearchChirpsParams{
+		Query:           tsQuery,
+		AuthorID:        authorID,
+		ViewerID:        viewerID,
+		CursorRank:      cursor.NullRank(),
+		CursorCreatedAt: cursor.NullCreatedAt(),
+		CursorID:        cursor.NullID(),
+		PageLimit:       limit + 1,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerSearchChirps: failed to search chirps %s", err), err)
+		return
+	}
+
+	rows, nextCursor := pagination.Paginate(rows, limit, func(row database.SearchChirpsRow) string {
+		return pagination.EncodeRankCursor(pagination.RankCursor{
+			Rank:   row.Rank,
+			Cursor: pagination.Cursor{CreatedAt: row.Chirp.CreatedAt, ID: row.Chirp.ID},
+		})
+	})
+
+	results := make([]result, len(rows))
+	refs := make([]*Chirp, len(rows))
+	for i, row := range rows {
+		results[i] = result{
+			Chirp:   chirpFromDB(row.Chirp),
+			Rank:    row.Rank,
+			Snippet: row.Snippet,
+		}
+		refs[i] = &results[i].Chirp
+	}
+
+	if err := cfg.hydrateChirps(r.Context(), viewerID, refs...); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerSearchChirps: failed to load chirp details %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Chirps:     results,
+		NextCursor: nextCursor,
+	})
+}
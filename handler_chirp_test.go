@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/visibility"
+)
+
+func TestHandlerGetChirpsPinsLead(t *testing.T) {
+	now := time.Now()
+	authorID := uuid.New()
+	chirp := func(body string, createdAt time.Time) database.Chirp {
+		return database.Chirp{
+			ID: uuid.New(), CreatedAt: createdAt, UpdatedAt: createdAt, Body: body,
+			UserID: authorID, ConversationID: uuid.New(),
+			Status: chirpStatusPublished, Visibility: string(visibility.Public),
+		}
+	}
+	first := chirp("first", now.Add(-3*time.Hour))
+	pinned := chirp("pinned", now.Add(-2*time.Hour))
+	last := chirp("last", now.Add(-time.Hour))
+
+	tests := []struct {
+		name     string
+		sort     string
+		query    string
+		listed   []database.Chirp
+		wantBody []string
+	}{
+		{
+			name:     "Default sort",
+			query:    "ListChirpsAsc",
+			listed:   []database.Chirp{first, pinned, last},
+			wantBody: []string{"pinned", "first", "last"},
+		},
+		{
+			name:     "Oldest first",
+			sort:     "asc",
+			query:    "ListChirpsAsc",
+			listed:   []database.Chirp{first, pinned, last},
+			wantBody: []string{"pinned", "first", "last"},
+		},
+		{
+			name:     "Newest first",
+			sort:     "desc",
+			query:    "ListChirpsDesc",
+			listed:   []database.Chirp{last, pinned, first},
+			wantBody: []string{"pinned", "last", "first"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newFakeDB()
+			db.answer("GetPinnedChirps", chirpRow(pinned))
+			rows := make([][]driver.Value, len(tt.listed))
+			for i, c := range tt.listed {
+				rows[i] = chirpRow(c)
+			}
+			db.answer(tt.query, rows...)
+			cfg := testConfig(t, db)
+
+			target := "/api/chirps?author_id=" + authorID.String()
+			if tt.sort != "" {
+				target += "&sort=" + tt.sort
+			}
+			w := serve(cfg.handlerGetChirps, httptest.NewRequest(http.MethodGet, target, nil), nil)
+
+			var resp struct {
+				Chirps []Chirp `json:"chirps"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			var bodies []string
+			for _, c := range resp.Chirps {
+				bodies = append(bodies, c.Body)
+			}
+			if len(bodies) != len(tt.wantBody) {
+				t.Fatalf("got %v, want %v", bodies, tt.wantBody)
+			}
+			for i := range bodies {
+				if bodies[i] != tt.wantBody[i] {
+					t.Fatalf("got %v, want %v", bodies, tt.wantBody)
+				}
+			}
+			if !resp.Chirps[0].Pinned {
+				t.Error("the pinned chirp is not marked pinned")
+			}
+		})
+	}
+}
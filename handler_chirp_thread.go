@@ -0,0 +1,180 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/pagination"
+)
+
+const (
+	defaultThreadDepth int32 = 3
+	maxThreadDepth     int32 = 10
+	maxThreadNodes     int32 = 500
+)
+
+type threadNode struct {
+	Chirp
+	Replies []*threadNode `json:"replies,omitempty"`
+}
+
+func (cfg *apiConfig) handlerGetChirpThread(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Ancestors  []Chirp       `json:"ancestors"`
+		Chirp      Chirp         `json:"chirp"`
+		Replies    []*threadNode `json:"replies"`
+		NextCursor string        `json:"next_cursor,omitempty"`
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetChirpThread: %s", err), err)
+		return
+	}
+
+	viewerID, err := cfg.viewerID(r)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetChirpThread: %s", err), err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	depth := defaultThreadDepth
+	if depthQueryParam := query.Get("depth"); depthQueryParam != "" {
+		d, err := strconv.ParseInt(depthQueryParam, 10, 32)
+		if err != nil || d < 1 {
+			helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetChirpThread: invalid depth %s", depthQueryParam), err)
+			return
+		}
+		depth = min(int32(d), maxThreadDepth)
+	}
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetChirpThread: %s", err), err)
+		return
+	}
+
+	cursor, err := pagination.DecodeCursor(query.Get("cursor"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetChirpThread: %s", err), err)
+		return
+	}
+
+	//* tombstones still anchor their thread, so they are not a 404 here
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil && err != sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirpThread: failed to get chirp %s", err), err)
+		return
+	}
+	if err == sql.ErrNoRows || ((chirp.Status != chirpStatusPublished || chirp.HiddenAt.Valid) && !chirpVisibleTo(chirp, viewerID)) {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetChirpThread: chirp with ID - %s not exist", chirpID), err)
+		return
+	}
+
+	if withheld, err := cfg.chirpWithheldFrom(r.Context(), chirp, viewerID); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirpThread: failed to check visibility %s", err), err)
+		return
+	} else if withheld {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetChirpThread: chirp with ID - %s not exist", chirpID), nil)
+		return
+	}
+
+	ancestors, err := cfg.db.GetChirpAncestors(r.Context(), chirpID)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirpThread: failed to get ancestors %s", err), err)
+		return
+	}
+
+	//* only the direct replies are paginated, their subtrees come along up to depth
+	replies, err := cfg.db.ListChirpReplies(r.Context(), database.ListChirpRepliesParams{
+		ParentID:        uuid.NullUUID{UUID: chirpID, Valid: true},
+		ViewerID:        viewerID,
+		CursorCreatedAt: cursor.NullCreatedAt(),
+		CursorID:        cursor.NullID(),
+		PageLimit:       limit + 1,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirpThread: failed to get replies %s", err), err)
+		return
+	}
+
+	replies, nextCursor := pagination.Paginate(replies, limit, chirpCursor)
+
+	nodes := make(map[uuid.UUID]*threadNode, len(replies))
+	roots := make([]*threadNode, len(replies))
+	parentIDs := make([]uuid.UUID, len(replies))
+	for i, c := range replies {
+		roots[i] = &threadNode{Chirp: chirpFromDB(c)}
+		nodes[c.ID] = roots[i]
+		parentIDs[i] = c.ID
+	}
+
+	if depth > 1 && len(parentIDs) > 0 {
+		descendants, err := cfg.db.GetChirpDescendants(r.Context(), database.GetChirpDescendantsParams{
+			ParentIds: parentIDs,
+			ViewerID:  viewerID,
+			MaxDepth:  depth - 1,
+			MaxNodes:  maxThreadNodes,
+		})
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirpThread: failed to get descendants %s", err), err)
+			return
+		}
+
+		//* rows come ordered by depth, so a parent is always placed before its children
+		for _, row := range descendants {
+			parent, ok := nodes[row.Chirp.InReplyTo.UUID]
+			if !ok {
+				continue
+			}
+			node := &threadNode{Chirp: chirpFromDB(row.Chirp)}
+			parent.Replies = append(parent.Replies, node)
+			nodes[row.Chirp.ID] = node
+		}
+	}
+
+	ancestorResponses := make([]Chirp, len(ancestors))
+	for i, a := range ancestors {
+		ancestorResponses[i] = chirpFromDB(a.Chirp)
+	}
+
+	resp := response{
+		Ancestors:  ancestorResponses,
+		Chirp:      chirpFromDB(chirp),
+		Replies:    roots,
+		NextCursor: nextCursor,
+	}
+
+	refs := append(chirpRefs(resp.Ancestors), &resp.Chirp)
+	for _, node := range nodes {
+		refs = append(refs, &node.Chirp)
+	}
+	if err := cfg.hydrateChirps(r.Context(), viewerID, refs...); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirpThread: failed to load chirp details %s", err), err)
+		return
+	}
+
+	//* a hidden ancestor, or one the viewer may not read, keeps its place in the chain,
+	//* but only as an empty placeholder
+	for i, a := range ancestors {
+		hidden := a.Chirp.HiddenAt.Valid && !chirpVisibleTo(a.Chirp, viewerID)
+		withheld, err := cfg.chirpWithheldFrom(r.Context(), a.Chirp, viewerID)
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetChirpThread: failed to check visibility %s", err), err)
+			return
+		}
+		if hidden || withheld {
+			resp.Ancestors[i] = chirpPlaceholder(a.Chirp)
+			resp.Ancestors[i].Hidden = hidden
+		}
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, resp)
+}
@@ -0,0 +1,478 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/message"
+	"github.com/trantuvan/chirpy/internal/pagination"
+)
+
+// Conversation - a private thread between two or more users; its messages live in their own
+// table and never show up in any chirp query
+type Conversation struct {
+	ID          uuid.UUID            `json:"id"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+	Members     []ConversationMember `json:"members"`
+	UnreadCount int64                `json:"unread_count"`
+}
+
+// ConversationMember - LastReadAt is the member's read receipt
+type ConversationMember struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	JoinedAt   time.Time  `json:"joined_at"`
+	LastReadAt *time.Time `json:"last_read_at,omitempty"`
+}
+
+type Message struct {
+	ID             uuid.UUID `json:"id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Body           string    `json:"body"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func conversationFromDB(c database.Conversation) Conversation {
+	return Conversation{
+		ID:        c.ID,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		Members:   []ConversationMember{},
+	}
+}
+
+func messageFromDB(m database.Message) Message {
+	return Message{
+		ID:             m.ID,
+		ConversationID: m.ConversationID,
+		SenderID:       m.SenderID,
+		Body:           m.Body,
+		CreatedAt:      m.CreatedAt,
+	}
+}
+
+// attachMembers - fills Conversation.Members with one query for the whole page
+func (cfg *apiConfig) attachMembers(ctx context.Context, conversations ...*Conversation) error {
+	if len(conversations) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(conversations))
+	byID := make(map[uuid.UUID]*Conversation, len(conversations))
+	for i, c := range conversations {
+		ids[i] = c.ID
+		byID[c.ID] = c
+	}
+
+	members, err := cfg.db.ListConversationMembers(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range members {
+		member := ConversationMember{UserID: m.UserID, JoinedAt: m.JoinedAt}
+		if m.LastReadAt.Valid {
+			member.LastReadAt = &m.LastReadAt.Time
+		}
+		c := byID[m.ConversationID]
+		c.Members = append(c.Members, member)
+	}
+
+	return nil
+}
+
+// memberOf - a conversation the user is not part of is sql.ErrNoRows just like a missing one
+func (cfg *apiConfig) memberOf(ctx context.Context, conversationID, userID uuid.UUID) error {
+	_, err := cfg.db.GetConversationMember(ctx, database.GetConversationMemberParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	return err
+}
+
+// handlerCreateConversation - starting a one-to-one conversation that already exists returns
+// the existing one with 200; group conversations are always new
+func (cfg *apiConfig) handlerCreateConversation(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		UserIDs []uuid.UUID `json:"user_ids"`
+	}
+	type response struct {
+		Conversation
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerCreateConversation: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerCreateConversation: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerCreateConversation", userID); !ok {
+		return
+	}
+
+	params := parameter{}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerCreateConversation: failed to read params %s", err), err)
+		return
+	}
+
+	members, err := message.Members(userID, params.UserIDs)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerCreateConversation: %s", err), err)
+		return
+	}
+
+	for i, memberID := range members[1:] {
+		user, err := cfg.db.GetUserByID(r.Context(), memberID)
+		if err == sql.ErrNoRows || user.SuspendedAt.Valid {
+			helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerCreateConversation: user with ID - %s not exist", memberID), err)
+			return
+		}
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateConversation: failed to get user %s", err), err)
+			return
+		}
+
+		//* every pair of members, not only the creator and each invitee: nobody is put in a
+		//* conversation with someone they blocked or were blocked by
+		for _, otherID := range members[:i+1] {
+			if blocked, err := cfg.blockedWith(r.Context(), uuid.NullUUID{UUID: otherID, Valid: true}, memberID); err != nil {
+				helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateConversation: failed to check blocks %s", err), err)
+				return
+			} else if blocked {
+				helpers.ResponseWithError(w, http.StatusForbidden, "handlerCreateConversation: cannot message these users together", nil)
+				return
+			}
+		}
+	}
+
+	if len(members) == 2 {
+		existing, err := cfg.db.FindDirectConversation(r.Context(), database.FindDirectConversationParams{
+			UserA: members[0],
+			UserB: members[1],
+		})
+		if err != nil && err != sql.ErrNoRows {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateConversation: failed to find conversation %s", err), err)
+			return
+		}
+		if err == nil {
+			conversation := conversationFromDB(existing)
+			if err := cfg.attachMembers(r.Context(), &conversation); err != nil {
+				helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateConversation: failed to get members %s", err), err)
+				return
+			}
+			helpers.ResponseWithJson(w, http.StatusOK, response{
+				Conversation: conversation,
+			})
+			return
+		}
+	}
+
+	var created database.Conversation
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		created, err = q.CreateConversation(r.Context())
+		if err != nil {
+			return err
+		}
+		return q.AddConversationMembers(r.Context(), database.AddConversationMembersParams{
+			ConversationID: created.ID,
+			UserIds:        members,
+		})
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateConversation: failed to create conversation %s", err), err)
+		return
+	}
+
+	conversation := conversationFromDB(created)
+	if err := cfg.attachMembers(r.Context(), &conversation); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateConversation: failed to get members %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusCreated, response{
+		Conversation: conversation,
+	})
+}
+
+// handlerGetConversations - the caller's conversations, most recently active first, with unread counts
+func (cfg *apiConfig) handlerGetConversations(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Conversations []Conversation `json:"conversations"`
+		NextCursor    string         `json:"next_cursor,omitempty"`
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetConversations: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetConversations: %s", err), err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetConversations: %s", err), err)
+		return
+	}
+
+	cursor, err := pagination.DecodeCursor(query.Get("cursor"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetConversations: %s", err), err)
+		return
+	}
+
+	//* the cursor's CreatedAt carries updated_at here, the column the page is ordered by
+	rows, err := cfg.db.ListConversations(r.Context(), database.ListConversationsParams{
+		UserID:          userID,
+		CursorUpdatedAt: cursor.NullCreatedAt(),
+		CursorID:        cursor.NullID(),
+		PageLimit:       limit + 1,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetConversations: failed to get conversations %s", err), err)
+		return
+	}
+
+	rows, nextCursor := pagination.Paginate(rows, limit, func(row database.ListConversationsRow) string {
+		return pagination.EncodeCursor(pagination.Cursor{CreatedAt: row.Conversation.UpdatedAt, ID: row.Conversation.ID})
+	})
+
+	conversations := make([]Conversation, len(rows))
+	refs := make([]*Conversation, len(rows))
+	for i, row := range rows {
+		conversations[i] = conversationFromDB(row.Conversation)
+		conversations[i].UnreadCount = row.UnreadCount
+		refs[i] = &conversations[i]
+	}
+
+	if err := cfg.attachMembers(r.Context(), refs...); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetConversations: failed to get members %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Conversations: conversations,
+		NextCursor:    nextCursor,
+	})
+}
+
+// handlerGetMessages - newest first; messages from anyone blocked either way are left out
+func (cfg *apiConfig) handlerGetMessages(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Messages   []Message `json:"messages"`
+		NextCursor string    `json:"next_cursor,omitempty"`
+	}
+
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetMessages: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetMessages: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetMessages: %s", err), err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetMessages: %s", err), err)
+		return
+	}
+
+	cursor, err := pagination.DecodeCursor(query.Get("cursor"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetMessages: %s", err), err)
+		return
+	}
+
+	if err := cfg.memberOf(r.Context(), conversationID, userID); err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetMessages: conversation with ID - %s not exist", conversationID), err)
+		return
+	} else if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetMessages: failed to get conversation %s", err), err)
+		return
+	}
+
+	rows, err := cfg.db.ListMessages(r.Context(), database.ListMessagesParams{
+		ConversationID:  conversationID,
+		ViewerID:        userID,
+		CursorCreatedAt: cursor.NullCreatedAt(),
+		CursorID:        cursor.NullID(),
+		PageLimit:       limit + 1,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetMessages: failed to get messages %s", err), err)
+		return
+	}
+
+	rows, nextCursor := pagination.Paginate(rows, limit, func(m database.Message) string {
+		return pagination.EncodeCursor(pagination.Cursor{CreatedAt: m.CreatedAt, ID: m.ID})
+	})
+
+	messages := make([]Message, len(rows))
+	for i, m := range rows {
+		messages[i] = messageFromDB(m)
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Messages:   messages,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *apiConfig) handlerCreateMessage(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		Body string `json:"body"`
+	}
+	type response struct {
+		Message
+	}
+
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerCreateMessage: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerCreateMessage: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerCreateMessage: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerCreateMessage", userID); !ok {
+		return
+	}
+
+	params := parameter{}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerCreateMessage: failed to read params %s", err), err)
+		return
+	}
+
+	body, err := message.ValidateBody(params.Body)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerCreateMessage: %s", err), err)
+		return
+	}
+
+	if err := cfg.memberOf(r.Context(), conversationID, userID); err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerCreateMessage: conversation with ID - %s not exist", conversationID), err)
+		return
+	} else if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateMessage: failed to get conversation %s", err), err)
+		return
+	}
+
+	//* a block placed after the conversation started closes it for both sides
+	if blocked, err := cfg.db.ConversationHasBlock(r.Context(), database.ConversationHasBlockParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	}); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateMessage: failed to check blocks %s", err), err)
+		return
+	} else if blocked {
+		helpers.ResponseWithError(w, http.StatusForbidden, "handlerCreateMessage: cannot message this conversation", nil)
+		return
+	}
+
+	m, err := cfg.db.CreateMessage(r.Context(), database.CreateMessageParams{
+		ConversationID: conversationID,
+		SenderID:       userID,
+		Body:           body,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateMessage: failed to create message %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusCreated, response{
+		Message: messageFromDB(m),
+	})
+}
+
+// handlerReadConversation - moves the caller's read receipt to now, which clears the unread count
+func (cfg *apiConfig) handlerReadConversation(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerReadConversation: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerReadConversation: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerReadConversation: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerReadConversation", userID); !ok {
+		return
+	}
+
+	if err := cfg.memberOf(r.Context(), conversationID, userID); err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerReadConversation: conversation with ID - %s not exist", conversationID), err)
+		return
+	} else if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerReadConversation: failed to get conversation %s", err), err)
+		return
+	}
+
+	if err := cfg.db.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	}); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerReadConversation: failed to mark read %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/mail"
+)
+
+const (
+	// emailVerificationTTL - how long a verification link works
+	emailVerificationTTL = 48 * time.Hour
+	// emailVerificationResendAfter - the least time between two verification mails to a user
+	emailVerificationResendAfter = time.Minute
+	// mailTimeout - how long a handler waits on the mailer
+	mailTimeout = 10 * time.Second
+)
+
+// sendVerification - records a verification for the user's current email and mails them the link;
+// nothing to do without a mailer, verification is off then. The link is built from BASE_URL only,
+// a Host header would let whoever sends the request point the link at their own server.
+func (cfg *apiConfig) sendVerification(ctx context.Context, user database.User) error {
+	if cfg.mailer == nil {
+		return nil
+	}
+
+	verification, err := cfg.db.CreateEmailVerification(ctx, database.CreateEmailVerificationParams{
+		UserID:    user.ID,
+		Email:     user.Email.String,
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/api/users/verify-email?token=%s", cfg.baseURL,
+		url.QueryEscape(auth.MakeVerificationToken(verification.ID, cfg.secretKey)))
+
+	ctx, cancel := context.WithTimeout(ctx, mailTimeout)
+	defer cancel()
+	return cfg.mailer.Send(ctx, mail.Message{
+		To:      user.Email.String,
+		Subject: "Verify your Chirpy email",
+		Body: fmt.Sprintf("Open this link to verify your email and start chirping:\n\n%s\n\n"+
+			"It works once and expires in %s. If you did not sign up for Chirpy, ignore this mail.\n",
+			link, emailVerificationTTL),
+	})
+}
+
+// handlerVerifyEmail - the link in the verification mail; it is opened from a mail client, so
+// the token in the link is all there is to go on
+func (cfg *apiConfig) handlerVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		User
+	}
+
+	id, err := auth.ParseVerificationToken(r.URL.Query().Get("token"), cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerVerifyEmail: %s", err), err)
+		return
+	}
+
+	var user database.User
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		verification, err := q.UseEmailVerification(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		//* no row when the email has changed since the link was sent
+		user, err = q.MarkEmailVerified(r.Context(), database.MarkEmailVerifiedParams{
+			ID:    verification.UserID,
+			Email: verification.Email,
+		})
+		return err
+	})
+	if err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusGone, "handlerVerifyEmail: link is used, expired or for an old email", err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerVerifyEmail: failed to verify email %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		User: User{
+			ID:            user.ID,
+			CreatedAt:     user.CreatedAt,
+			UpdatedAt:     user.UpdatedAt,
+			Email:         user.Email.String,
+			EmailVerified: user.EmailVerifiedAt.Valid,
+			IsChirpyRed:   user.IsChirpyRed,
+			Handle:        user.Handle.String,
+		},
+	})
+}
+
+// handlerResendVerification - mails a new link; links sent before keep working until they expire
+func (cfg *apiConfig) handlerResendVerification(w http.ResponseWriter, r *http.Request) {
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerResendVerification: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerResendVerification: %s", err), err)
+		return
+	}
+
+	user, ok := cfg.requireActiveUser(w, r, "handlerResendVerification", userID)
+	if !ok {
+		return
+	}
+	if cfg.mailer == nil {
+		helpers.ResponseWithError(w, http.StatusServiceUnavailable, "handlerResendVerification: no mails are sent, SMTP_HOST is not set", nil)
+		return
+	}
+	if user.EmailVerifiedAt.Valid {
+		helpers.ResponseWithError(w, http.StatusConflict, "handlerResendVerification: email is already verified", nil)
+		return
+	}
+
+	latest, err := cfg.db.GetLatestEmailVerification(r.Context(), user.ID)
+	if err != nil && err != sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerResendVerification: failed to get verification %s", err), err)
+		return
+	}
+	if err == nil && time.Since(latest.CreatedAt) < emailVerificationResendAfter {
+		w.Header().Set("Retry-After", fmt.Sprintf("%.0f", emailVerificationResendAfter.Seconds()))
+		helpers.ResponseWithError(w, http.StatusTooManyRequests, "handlerResendVerification: a link was just sent", nil)
+		return
+	}
+
+	if err := cfg.sendVerification(r.Context(), user); err != nil {
+		helpers.ResponseWithError(w, http.StatusBadGateway, fmt.Sprintf("handlerResendVerification: failed to send mail %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
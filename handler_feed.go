@@ -0,0 +1,129 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/feed"
+)
+
+// feedSize - the latest chirps a feed carries
+const feedSize = 20
+
+func (cfg *apiConfig) handlerGetUserFeedAtom(w http.ResponseWriter, r *http.Request) {
+	cfg.serveUserFeed(w, r, feed.Atom)
+}
+
+func (cfg *apiConfig) handlerGetUserFeedRSS(w http.ResponseWriter, r *http.Request) {
+	cfg.serveUserFeed(w, r, feed.RSS)
+}
+
+func (cfg *apiConfig) handlerGetUserFeedJSON(w http.ResponseWriter, r *http.Request) {
+	cfg.serveUserFeed(w, r, feed.JSON)
+}
+
+// origin - BASE_URL when set, otherwise the scheme and host the request came in on
+func (cfg *apiConfig) origin(r *http.Request) string {
+	if cfg.baseURL != "" {
+		return cfg.baseURL
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// serveUserFeed - a user's latest chirps for feed readers. Feeds are read without a token, so
+// only public chirps make it in. ETag covers every change to the feed, Last-Modified only new
+// and edited chirps and profile changes.
+func (cfg *apiConfig) serveUserFeed(w http.ResponseWriter, r *http.Request, format feed.Format) {
+	idOrHandle := r.PathValue("idOrHandle")
+
+	user, err := cfg.userByIDOrHandle(r.Context(), idOrHandle)
+	if err == sql.ErrNoRows || user.SuspendedAt.Valid {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("serveUserFeed: user %s not exist", idOrHandle), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("serveUserFeed: failed to get user %s", err), err)
+		return
+	}
+
+	chirps, err := cfg.db.GetLatestChirpsByUserID(r.Context(), database.GetLatestChirpsByUserIDParams{
+		UserID:    user.ID,
+		PageLimit: feedSize,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("serveUserFeed: failed to get chirps %s", err), err)
+		return
+	}
+
+	f := cfg.userFeed(r, user, chirps)
+	body, err := feed.Render(f, format)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("serveUserFeed: failed to render feed %s", err), err)
+		return
+	}
+
+	etag := feed.ETag(body)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", f.Updated.UTC().Format(http.TimeFormat))
+	if feed.NotModified(r.Header, etag, f.Updated) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.Printf("serveUserFeed: %s\n", err)
+	}
+}
+
+// userFeed - chirps as GetLatestChirpsByUserID returns them, newest first and without plain rechirps
+func (cfg *apiConfig) userFeed(r *http.Request, user database.User, chirps []database.Chirp) feed.Feed {
+	origin := cfg.origin(r)
+
+	name := user.DisplayName
+	homeURL := fmt.Sprintf("%s/api/users/%s", origin, user.ID)
+	if user.Handle.Valid {
+		homeURL = fmt.Sprintf("%s/api/users/%s", origin, user.Handle.String)
+		if name == "" {
+			name = "@" + user.Handle.String
+		}
+	}
+	if name == "" {
+		name = "Chirpy user"
+	}
+
+	f := feed.Feed{
+		ID:          fmt.Sprintf("%s/api/users/%s", origin, user.ID),
+		Title:       name,
+		Description: user.Bio,
+		HomeURL:     homeURL,
+		FeedURL:     origin + r.URL.Path,
+		Author:      name,
+		Updated:     user.UpdatedAt,
+	}
+
+	for _, chirp := range chirps {
+		f.Items = append(f.Items, feed.Item{
+			ID:        "urn:uuid:" + chirp.ID.String(),
+			URL:       fmt.Sprintf("%s/api/chirps/%s", origin, chirp.ID),
+			Title:     feed.Title(chirp.Body),
+			Content:   chirp.Body,
+			Published: chirp.CreatedAt,
+			Updated:   chirp.UpdatedAt,
+		})
+		if chirp.UpdatedAt.After(f.Updated) {
+			f.Updated = chirp.UpdatedAt
+		}
+	}
+
+	return f
+}
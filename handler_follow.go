@@ -0,0 +1,189 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/notification"
+	"github.com/trantuvan/chirpy/internal/pagination"
+)
+
+type Follow struct {
+	UserID     uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerFollowUser: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerFollowUser: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerFollowUser: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerFollowUser", userID); !ok {
+		return
+	}
+
+	if followeeID == userID {
+		helpers.ResponseWithError(w, http.StatusBadRequest, "handlerFollowUser: cannot follow yourself", nil)
+		return
+	}
+
+	if _, err := cfg.db.GetUserByID(r.Context(), followeeID); err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerFollowUser: user with ID - %s not exist", followeeID), err)
+		return
+	} else if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerFollowUser: failed to get user %s", err), err)
+		return
+	}
+
+	if blocked, err := cfg.blockedWith(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, followeeID); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerFollowUser: failed to check blocks %s", err), err)
+		return
+	} else if blocked {
+		helpers.ResponseWithError(w, http.StatusForbidden, "handlerFollowUser: cannot follow this user", nil)
+		return
+	}
+
+	followed, err := cfg.db.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerFollowUser: failed to follow user %s", err), err)
+		return
+	}
+	//* following again changes nothing, and tells nobody
+	if followed > 0 {
+		cfg.notify(notification.Event{Kind: notification.Follow, ActorID: userID, UserID: followeeID})
+		cfg.federateFollow(userID, followeeID, false)
+	}
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerUnfollowUser: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerUnfollowUser: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerUnfollowUser: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerUnfollowUser", userID); !ok {
+		return
+	}
+
+	unfollowed, err := cfg.db.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerUnfollowUser: failed to unfollow user %s", err), err)
+		return
+	}
+	if unfollowed > 0 {
+		cfg.federateFollow(userID, followeeID, true)
+	}
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) handlerGetFollowers(w http.ResponseWriter, r *http.Request) {
+	cfg.listFollows(w, r, "handlerGetFollowers", func(params database.ListFollowersParams) ([]Follow, error) {
+		rows, err := cfg.db.ListFollowers(r.Context(), params)
+		follows := make([]Follow, len(rows))
+		for i, row := range rows {
+			follows[i] = Follow{UserID: row.UserID, FollowedAt: row.CreatedAt}
+		}
+		return follows, err
+	})
+}
+
+func (cfg *apiConfig) handlerGetFollowing(w http.ResponseWriter, r *http.Request) {
+	cfg.listFollows(w, r, "handlerGetFollowing", func(params database.ListFollowersParams) ([]Follow, error) {
+		rows, err := cfg.db.ListFollowing(r.Context(), database.ListFollowingParams(params))
+		follows := make([]Follow, len(rows))
+		for i, row := range rows {
+			follows[i] = Follow{UserID: row.UserID, FollowedAt: row.CreatedAt}
+		}
+		return follows, err
+	})
+}
+
+// listFollows - shared by the followers/following endpoints, which only differ in the query
+func (cfg *apiConfig) listFollows(w http.ResponseWriter, r *http.Request, handler string, list func(database.ListFollowersParams) ([]Follow, error)) {
+	type response struct {
+		Users      []Follow `json:"users"`
+		NextCursor string   `json:"next_cursor,omitempty"`
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("%s: %s", handler, err), err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("%s: %s", handler, err), err)
+		return
+	}
+
+	cursor, err := pagination.DecodeCursor(query.Get("cursor"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("%s: %s", handler, err), err)
+		return
+	}
+
+	follows, err := list(database.ListFollowersParams{
+		UserID:          userID,
+		CursorCreatedAt: cursor.NullCreatedAt(),
+		CursorID:        cursor.NullID(),
+		PageLimit:       limit + 1,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s: failed to list follows %s", handler, err), err)
+		return
+	}
+
+	follows, nextCursor := pagination.Paginate(follows, limit, func(f Follow) string {
+		return pagination.EncodeCursor(pagination.Cursor{CreatedAt: f.FollowedAt, ID: f.UserID})
+	})
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Users:      follows,
+		NextCursor: nextCursor,
+	})
+}
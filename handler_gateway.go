@@ -0,0 +1,416 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/gateway"
+	"github.com/trantuvan/chirpy/internal/stream"
+)
+
+const (
+	gatewayWriteWait = 10 * time.Second
+	// gatewayPongWait - a client that answers no ping for this long is gone
+	gatewayPongWait   = 60 * time.Second
+	gatewayPingPeriod = gatewayPongWait * 9 / 10
+	// gatewayAuthTimeout - how long a connection opened without a token has to send an auth frame
+	gatewayAuthTimeout  = 10 * time.Second
+	gatewayMaxFrameSize = 4096
+	// gatewaySendBuffer - frames a client may fall behind by before it is disconnected as a slow consumer
+	gatewaySendBuffer       = 64
+	gatewayMaxSubscriptions = 16
+	// gatewayCloseUnauthorized - the close code for a missing, invalid or expired token; clients
+	// reconnect with a fresh one
+	gatewayCloseUnauthorized = 4001
+)
+
+var gatewayUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// gatewayConn - one WebSocket client. Only run touches the state below; readPump and writePump
+// talk to it through incoming and send.
+type gatewayConn struct {
+	cfg  *apiConfig
+	conn *websocket.Conn
+	send chan []byte
+
+	userID    uuid.UUID
+	expiresAt time.Time
+	// channels - the filter each chirp channel applies, nil for notifications
+	channels map[gateway.Channel]*stream.Filter
+
+	// closeCode and closeText - set by run before it closes send, read by writePump after
+	closeCode int
+	closeText string
+}
+
+// handlerGateway - GET /api/ws. The token comes from the Authorization header or, for clients
+// that cannot set one on the handshake, from an auth frame sent first. A client sends a new auth
+// frame when it gets reauth_required; one that does not is closed with 4001 at expiry.
+func (cfg *apiConfig) handlerGateway(w http.ResponseWriter, r *http.Request) {
+	c := &gatewayConn{
+		cfg:      cfg,
+		send:     make(chan []byte, gatewaySendBuffer),
+		channels: map[gateway.Channel]*stream.Filter{},
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil && !errors.Is(err, auth.ErrNoAuthHeaderIncluded) {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGateway: %s", err), err)
+		return
+	}
+	if err == nil {
+		c.userID, c.expiresAt, err = auth.ValidateJWTExpiry(tokenJWT, cfg.secretKey)
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGateway: %s", err), err)
+			return
+		}
+	}
+
+	//* Upgrade has already answered the request when it fails
+	c.conn, err = gatewayUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("handlerGateway: %s\n", err)
+		return
+	}
+
+	go c.writePump()
+	c.run(r.Context())
+}
+
+func (c *gatewayConn) authenticated() bool {
+	return c.userID != uuid.Nil
+}
+
+func (c *gatewayConn) viewer() uuid.NullUUID {
+	return uuid.NullUUID{UUID: c.userID, Valid: true}
+}
+
+// run - the connection's event loop; returning closes the connection
+func (c *gatewayConn) run(ctx context.Context) {
+	defer close(c.send)
+
+	done := make(chan struct{})
+	defer close(done)
+	incoming := make(chan gateway.ClientFrame)
+	go c.readPump(incoming, done)
+
+	chirps := c.cfg.chirpStream.Subscribe(gatewaySendBuffer)
+	defer chirps.Close()
+	notifications := c.cfg.notificationStream.Subscribe(gatewaySendBuffer)
+	defer notifications.Close()
+
+	reauth := time.NewTimer(0)
+	reauth.Stop()
+	expiry := time.NewTimer(gatewayAuthTimeout)
+	defer reauth.Stop()
+	defer expiry.Stop()
+
+	if c.authenticated() {
+		c.scheduleExpiry(reauth, expiry)
+		if !c.sendFrame(gateway.ServerFrame{Type: gateway.FrameAuthenticated, ExpiresAt: &c.expiresAt}) {
+			return
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case f, ok := <-incoming:
+			if !ok {
+				return
+			}
+			if !c.handleFrame(ctx, f, reauth, expiry) {
+				return
+			}
+		case e, ok := <-chirps.C:
+			//* closed when the hub gave up on us; the client resubscribes after reconnecting
+			if !ok {
+				c.closeWith(websocket.CloseTryAgainLater, "slow consumer")
+				return
+			}
+			if !c.sendChirpEvent(ctx, e) {
+				return
+			}
+		case e, ok := <-notifications.C:
+			if !ok {
+				c.closeWith(websocket.CloseTryAgainLater, "slow consumer")
+				return
+			}
+			if !c.sendNotification(ctx, e) {
+				return
+			}
+		case <-reauth.C:
+			if !c.sendFrame(gateway.ServerFrame{Type: gateway.FrameReauthRequired, ExpiresAt: &c.expiresAt}) {
+				return
+			}
+		case <-expiry.C:
+			if c.authenticated() {
+				c.closeWith(gatewayCloseUnauthorized, "token expired")
+			} else {
+				c.closeWith(gatewayCloseUnauthorized, "authentication timeout")
+			}
+			return
+		}
+	}
+}
+
+// scheduleExpiry - arms reauth_required ahead of c.expiresAt and the close at it
+func (c *gatewayConn) scheduleExpiry(reauth, expiry *time.Timer) {
+	now := time.Now()
+	reauth.Reset(gateway.ReauthDelay(c.expiresAt, now))
+	expiry.Reset(c.expiresAt.Sub(now))
+}
+
+// handleFrame - false when the connection should close
+func (c *gatewayConn) handleFrame(ctx context.Context, f gateway.ClientFrame, reauth, expiry *time.Timer) bool {
+	if f.Type == gateway.FrameAuth {
+		userID, expiresAt, err := auth.ValidateJWTExpiry(f.Token, c.cfg.secretKey)
+		if err != nil {
+			c.closeWith(gatewayCloseUnauthorized, "invalid token")
+			return false
+		}
+		//* subscriptions were checked against the first user, so a token cannot switch users
+		if c.authenticated() && userID != c.userID {
+			c.closeWith(gatewayCloseUnauthorized, "token belongs to another user")
+			return false
+		}
+		c.userID, c.expiresAt = userID, expiresAt
+		c.scheduleExpiry(reauth, expiry)
+		return c.sendFrame(gateway.ServerFrame{Type: gateway.FrameAuthenticated, ExpiresAt: &c.expiresAt})
+	}
+
+	if !c.authenticated() {
+		return c.sendError("", "authenticate first")
+	}
+
+	switch f.Type {
+	case gateway.FrameSubscribe:
+		channel, err := gateway.ParseChannel(f.Channel)
+		if err != nil {
+			return c.sendError(f.Channel, err.Error())
+		}
+		if _, ok := c.channels[channel]; !ok && len(c.channels) >= gatewayMaxSubscriptions {
+			return c.sendError(f.Channel, "too many subscriptions")
+		}
+		filter, err := c.channelFilter(ctx, channel)
+		if err == sql.ErrNoRows {
+			return c.sendError(f.Channel, "chirp not found")
+		}
+		if err != nil {
+			log.Printf("handlerGateway: failed to subscribe to %s %s\n", channel, err)
+			return c.sendError(f.Channel, "failed to subscribe")
+		}
+		c.channels[channel] = filter
+		return c.sendFrame(gateway.ServerFrame{Type: gateway.FrameSubscribed, Channel: channel.String()})
+	case gateway.FrameUnsubscribe:
+		channel, err := gateway.ParseChannel(f.Channel)
+		if err != nil {
+			return c.sendError(f.Channel, err.Error())
+		}
+		delete(c.channels, channel)
+		return c.sendFrame(gateway.ServerFrame{Type: gateway.FrameUnsubscribed, Channel: channel.String()})
+	default:
+		return c.sendError("", fmt.Sprintf("unknown frame type %q", f.Type))
+	}
+}
+
+// channelFilter - what a chirp channel lets through, like the SSE stream's following=true for
+// the timeline; sql.ErrNoRows when a thread's chirp does not exist for the user
+func (c *gatewayConn) channelFilter(ctx context.Context, channel gateway.Channel) (*stream.Filter, error) {
+	switch channel.Kind {
+	case gateway.Timeline:
+		followees, err := c.cfg.db.ListFolloweeIDs(ctx, c.userID)
+		if err != nil {
+			return nil, err
+		}
+		muted, err := c.cfg.db.ListMutedUserIDs(ctx, c.userID)
+		if err != nil {
+			return nil, err
+		}
+		filter := &stream.Filter{
+			Following: map[uuid.UUID]struct{}{c.userID: {}},
+			Muted:     make(map[uuid.UUID]struct{}, len(muted)),
+		}
+		for _, id := range followees {
+			filter.Following[id] = struct{}{}
+		}
+		for _, id := range muted {
+			filter.Muted[id] = struct{}{}
+		}
+		return filter, nil
+	case gateway.Thread:
+		chirp, err := c.cfg.db.GetChirp(ctx, channel.ChirpID)
+		if err != nil {
+			return nil, err
+		}
+		if !chirpVisibleTo(chirp, c.viewer()) {
+			return nil, sql.ErrNoRows
+		}
+		withheld, err := c.cfg.chirpWithheldFrom(ctx, chirp, c.viewer())
+		if err != nil {
+			return nil, err
+		}
+		if withheld {
+			return nil, sql.ErrNoRows
+		}
+		return &stream.Filter{Thread: uuid.NullUUID{UUID: chirp.ConversationID, Valid: true}}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// sendChirpEvent - one frame per subscribed chirp channel the event passes
+func (c *gatewayConn) sendChirpEvent(ctx context.Context, e stream.Event) bool {
+	for channel, filter := range c.channels {
+		if filter == nil {
+			continue
+		}
+		name, data, err := c.cfg.chirpEventPayload(ctx, c.viewer(), *filter, e)
+		if err != nil {
+			log.Printf("handlerGateway: failed to build event %d %s\n", e.ID, err)
+			continue
+		}
+		if name == "" {
+			continue
+		}
+		if !c.sendFrame(gateway.ServerFrame{Type: gateway.FrameEvent, Channel: channel.String(), Event: name, Data: data}) {
+			return false
+		}
+	}
+	return true
+}
+
+// sendNotification - the notification as GET /api/notifications lists it, with the new unread count
+func (c *gatewayConn) sendNotification(ctx context.Context, e stream.NotificationEvent) bool {
+	channel := gateway.Channel{Kind: gateway.Notifications}
+	if _, ok := c.channels[channel]; !ok || e.UserID != c.userID {
+		return true
+	}
+
+	row, err := c.cfg.db.GetNotification(ctx, e.ID)
+	if err == sql.ErrNoRows {
+		return true
+	}
+	if err != nil {
+		log.Printf("handlerGateway: failed to get notification %s %s\n", e.ID, err)
+		return true
+	}
+
+	notifications := []Notification{notificationFromDB(row.Notification, row.ActorCount)}
+	if err := c.cfg.attachActors(ctx, notifications); err != nil {
+		log.Printf("handlerGateway: failed to get actors %s %s\n", e.ID, err)
+		return true
+	}
+	unread, err := c.cfg.db.CountUnreadNotifications(ctx, c.userID)
+	if err != nil {
+		log.Printf("handlerGateway: failed to count unread %s\n", err)
+		return true
+	}
+
+	data, err := json.Marshal(struct {
+		Notification Notification `json:"notification"`
+		UnreadCount  int64        `json:"unread_count"`
+	}{Notification: notifications[0], UnreadCount: unread})
+	if err != nil {
+		log.Printf("handlerGateway: %s\n", err)
+		return true
+	}
+	return c.sendFrame(gateway.ServerFrame{Type: gateway.FrameEvent, Channel: channel.String(), Event: "notification", Data: data})
+}
+
+func (c *gatewayConn) sendError(channel, message string) bool {
+	return c.sendFrame(gateway.ServerFrame{Type: gateway.FrameError, Channel: channel, Message: message})
+}
+
+// sendFrame - never blocks: a client whose send buffer is full is closed as a slow consumer
+func (c *gatewayConn) sendFrame(f gateway.ServerFrame) bool {
+	data, err := json.Marshal(f)
+	if err != nil {
+		log.Printf("handlerGateway: %s\n", err)
+		return true
+	}
+	select {
+	case c.send <- data:
+		return true
+	default:
+		c.closeWith(websocket.CloseTryAgainLater, "slow consumer")
+		return false
+	}
+}
+
+func (c *gatewayConn) closeWith(code int, text string) {
+	c.closeCode, c.closeText = code, text
+}
+
+// readPump - decodes client frames into incoming until the connection fails or run is done
+func (c *gatewayConn) readPump(incoming chan<- gateway.ClientFrame, done <-chan struct{}) {
+	defer close(incoming)
+
+	c.conn.SetReadLimit(gatewayMaxFrameSize)
+	c.conn.SetReadDeadline(time.Now().Add(gatewayPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(gatewayPongWait))
+	})
+
+	for {
+		f := gateway.ClientFrame{}
+		if err := c.conn.ReadJSON(&f); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("handlerGateway: %s\n", err)
+			}
+			return
+		}
+		select {
+		case incoming <- f:
+		case <-done:
+			return
+		}
+	}
+}
+
+// writePump - the only writer on the connection: frames from send plus pings, then the close
+// frame once run closes send
+func (c *gatewayConn) writePump() {
+	ping := time.NewTicker(gatewayPingPeriod)
+	defer func() {
+		ping.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case data, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(gatewayWriteWait))
+			if !ok {
+				code := c.closeCode
+				if code == 0 {
+					code = websocket.CloseNormalClosure
+				}
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, c.closeText))
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ping.C:
+			c.conn.SetWriteDeadline(time.Now().Add(gatewayWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
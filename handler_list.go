@@ -0,0 +1,504 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/pagination"
+)
+
+const maxListNameLength = 50
+
+type List struct {
+	ID        uuid.UUID `json:"id"`
+	OwnerID   uuid.UUID `json:"owner_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func listFromDB(l database.List) List {
+	return List{
+		ID:        l.ID,
+		OwnerID:   l.OwnerID,
+		Name:      l.Name,
+		CreatedAt: l.CreatedAt,
+		UpdatedAt: l.UpdatedAt,
+	}
+}
+
+// ownedList - lists are private, so someone else's list is sql.ErrNoRows just like a missing one
+func (cfg *apiConfig) ownedList(ctx context.Context, listID, userID uuid.UUID) (database.List, error) {
+	list, err := cfg.db.GetList(ctx, listID)
+	if err != nil {
+		return database.List{}, err
+	}
+	if list.OwnerID != userID {
+		return database.List{}, sql.ErrNoRows
+	}
+	return list, nil
+}
+
+func (cfg *apiConfig) handlerCreateList(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		Name string `json:"name"`
+	}
+	type response struct {
+		List
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerCreateList: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerCreateList: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerCreateList", userID); !ok {
+		return
+	}
+
+	params := parameter{}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerCreateList: failed to read params %s", err), err)
+		return
+	}
+
+	name := strings.TrimSpace(params.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxListNameLength {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerCreateList: name must be 1 to %d characters", maxListNameLength), nil)
+		return
+	}
+
+	list, err := cfg.db.CreateList(r.Context(), database.CreateListParams{
+		OwnerID: userID,
+		Name:    name,
+	})
+	if isUniqueViolation(err) {
+		helpers.ResponseWithError(w, http.StatusConflict, fmt.Sprintf("handlerCreateList: list %q already exists", name), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateList: failed to create list %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusCreated, response{
+		List: listFromDB(list),
+	})
+}
+
+func (cfg *apiConfig) handlerGetLists(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Lists      []List `json:"lists"`
+		NextCursor string `json:"next_cursor,omitempty"`
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetLists: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetLists: %s", err), err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetLists: %s", err), err)
+		return
+	}
+
+	cursor, err := pagination.DecodeCursor(query.Get("cursor"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetLists: %s", err), err)
+		return
+	}
+
+	lists, err := cfg.db.ListLists(r.Context(), database.ListListsParams{
+		OwnerID:         userID,
+		CursorCreatedAt: cursor.NullCreatedAt(),
+		CursorID:        cursor.NullID(),
+		PageLimit:       limit + 1,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetLists: failed to get lists %s", err), err)
+		return
+	}
+
+	lists, nextCursor := pagination.Paginate(lists, limit, func(l database.List) string {
+		return pagination.EncodeCursor(pagination.Cursor{CreatedAt: l.CreatedAt, ID: l.ID})
+	})
+
+	responses := make([]List, len(lists))
+	for i, l := range lists {
+		responses[i] = listFromDB(l)
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Lists:      responses,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *apiConfig) handlerGetList(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		List
+	}
+
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetList: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetList: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetList: %s", err), err)
+		return
+	}
+
+	list, err := cfg.ownedList(r.Context(), listID, userID)
+	if err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetList: list with ID - %s not exist", listID), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetList: failed to get list %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		List: listFromDB(list),
+	})
+}
+
+func (cfg *apiConfig) handlerDeleteList(w http.ResponseWriter, r *http.Request) {
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerDeleteList: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerDeleteList: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerDeleteList: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerDeleteList", userID); !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteList(r.Context(), database.DeleteListParams{ID: listID, OwnerID: userID})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerDeleteList: failed to delete list %s", err), err)
+		return
+	}
+	if deleted == 0 {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerDeleteList: list with ID - %s not exist", listID), nil)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) handlerAddListMember(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerAddListMember: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerAddListMember: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerAddListMember: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerAddListMember", userID); !ok {
+		return
+	}
+
+	params := parameter{}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerAddListMember: failed to read params %s", err), err)
+		return
+	}
+
+	if _, err := cfg.ownedList(r.Context(), listID, userID); err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerAddListMember: list with ID - %s not exist", listID), err)
+		return
+	} else if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerAddListMember: failed to get list %s", err), err)
+		return
+	}
+
+	if _, err := cfg.db.GetUserByID(r.Context(), params.UserID); err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerAddListMember: user with ID - %s not exist", params.UserID), err)
+		return
+	} else if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerAddListMember: failed to get user %s", err), err)
+		return
+	}
+
+	if blocked, err := cfg.blockedWith(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, params.UserID); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerAddListMember: failed to check blocks %s", err), err)
+		return
+	} else if blocked {
+		helpers.ResponseWithError(w, http.StatusForbidden, "handlerAddListMember: cannot add this user", nil)
+		return
+	}
+
+	if err := cfg.db.AddListMember(r.Context(), database.AddListMemberParams{
+		ListID: listID,
+		UserID: params.UserID,
+	}); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerAddListMember: failed to add member %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) handlerRemoveListMember(w http.ResponseWriter, r *http.Request) {
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerRemoveListMember: %s", err), err)
+		return
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerRemoveListMember: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerRemoveListMember: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerRemoveListMember: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerRemoveListMember", userID); !ok {
+		return
+	}
+
+	if _, err := cfg.ownedList(r.Context(), listID, userID); err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerRemoveListMember: list with ID - %s not exist", listID), err)
+		return
+	} else if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerRemoveListMember: failed to get list %s", err), err)
+		return
+	}
+
+	if err := cfg.db.RemoveListMember(r.Context(), database.RemoveListMemberParams{
+		ListID: listID,
+		UserID: memberID,
+	}); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerRemoveListMember: failed to remove member %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) handlerGetListMembers(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Users      []Relation `json:"users"`
+		NextCursor string     `json:"next_cursor,omitempty"`
+	}
+
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetListMembers: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetListMembers: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetListMembers: %s", err), err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetListMembers: %s", err), err)
+		return
+	}
+
+	cursor, err := pagination.DecodeCursor(query.Get("cursor"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetListMembers: %s", err), err)
+		return
+	}
+
+	if _, err := cfg.ownedList(r.Context(), listID, userID); err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetListMembers: list with ID - %s not exist", listID), err)
+		return
+	} else if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetListMembers: failed to get list %s", err), err)
+		return
+	}
+
+	rows, err := cfg.db.ListListMembers(r.Context(), database.ListListMembersParams{
+		ListID:          listID,
+		CursorCreatedAt: cursor.NullCreatedAt(),
+		CursorID:        cursor.NullID(),
+		PageLimit:       limit + 1,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetListMembers: failed to get members %s", err), err)
+		return
+	}
+
+	members := make([]Relation, len(rows))
+	for i, row := range rows {
+		members[i] = Relation{UserID: row.UserID, CreatedAt: row.CreatedAt}
+	}
+
+	members, nextCursor := pagination.Paginate(members, limit, func(rel Relation) string {
+		return pagination.EncodeCursor(pagination.Cursor{CreatedAt: rel.CreatedAt, ID: rel.UserID})
+	})
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Users:      members,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *apiConfig) handlerGetListChirps(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetListChirps: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetListChirps: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetListChirps: %s", err), err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetListChirps: %s", err), err)
+		return
+	}
+
+	cursor, err := pagination.DecodeCursor(query.Get("cursor"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetListChirps: %s", err), err)
+		return
+	}
+
+	if _, err := cfg.ownedList(r.Context(), listID, userID); err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetListChirps: list with ID - %s not exist", listID), err)
+		return
+	} else if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetListChirps: failed to get list %s", err), err)
+		return
+	}
+
+	chirps, err := cfg.db.GetListChirps(r.Context(), database.GetListChirpsParams{
+		ListID:          listID,
+		ViewerID:        userID,
+		CursorCreatedAt: cursor.NullCreatedAt(),
+		CursorID:        cursor.NullID(),
+		PageLimit:       limit + 1,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetListChirps: failed to get chirps %s", err), err)
+		return
+	}
+
+	chirps, nextCursor := pagination.Paginate(chirps, limit, chirpCursor)
+
+	responses := make([]Chirp, len(chirps))
+	for i, c := range chirps {
+		responses[i] = chirpFromDB(c)
+	}
+
+	if err := cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirpRefs(responses)...); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetListChirps: failed to load chirp details %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Chirps:     responses,
+		NextCursor: nextCursor,
+	})
+}
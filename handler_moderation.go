@@ -0,0 +1,319 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/pagination"
+)
+
+const (
+	moderationActionClaim       = "claim"
+	moderationActionHideChirp   = "hide_chirp"
+	moderationActionSuspendUser = "suspend_user"
+	moderationActionDismiss     = "dismiss"
+)
+
+type ModerationAction struct {
+	ID        uuid.UUID  `json:"id"`
+	AdminID   *uuid.UUID `json:"admin_id,omitempty"`
+	Action    string     `json:"action"`
+	Note      string     `json:"note,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// adminStatus - a missing or bad token is 401, a valid one without admin rights 403
+func adminStatus(err error) int {
+	if errors.Is(err, errNotAdmin) {
+		return http.StatusForbidden
+	}
+	return http.StatusUnauthorized
+}
+
+func (cfg *apiConfig) handlerListReports(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Reports    []Report `json:"reports"`
+		NextCursor string   `json:"next_cursor,omitempty"`
+	}
+
+	if _, err := cfg.adminID(r); err != nil {
+		helpers.ResponseWithError(w, adminStatus(err), fmt.Sprintf("handlerListReports: %s", err), err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	status := query.Get("status")
+	switch status {
+	case "":
+		status = reportStatusOpen
+	case reportStatusOpen, reportStatusClaimed, reportStatusResolved:
+	default:
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerListReports: invalid status %s", status), nil)
+		return
+	}
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerListReports: %s", err), err)
+		return
+	}
+
+	cursor, err := pagination.DecodeCursor(query.Get("cursor"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerListReports: %s", err), err)
+		return
+	}
+
+	reports, err := cfg.db.ListReports(r.Context(), database.ListReportsParams{
+		Status:          status,
+		CursorCreatedAt: cursor.NullCreatedAt(),
+		CursorID:        cursor.NullID(),
+		PageLimit:       limit + 1,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerListReports: failed to get reports %s", err), err)
+		return
+	}
+
+	reports, nextCursor := pagination.Paginate(reports, limit, func(r database.Report) string {
+		return pagination.EncodeCursor(pagination.Cursor{CreatedAt: r.CreatedAt, ID: r.ID})
+	})
+
+	responses := make([]Report, len(reports))
+	for i, report := range reports {
+		responses[i] = reportFromDB(report)
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Reports:    responses,
+		NextCursor: nextCursor,
+	})
+}
+
+func (cfg *apiConfig) handlerGetReport(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Report
+		Chirp   *Chirp             `json:"chirp,omitempty"`
+		Actions []ModerationAction `json:"actions"`
+	}
+
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetReport: %s", err), err)
+		return
+	}
+
+	adminID, err := cfg.adminID(r)
+	if err != nil {
+		helpers.ResponseWithError(w, adminStatus(err), fmt.Sprintf("handlerGetReport: %s", err), err)
+		return
+	}
+
+	report, err := cfg.db.GetReport(r.Context(), reportID)
+	if err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetReport: report with ID - %s not exist", reportID), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetReport: failed to get report %s", err), err)
+		return
+	}
+
+	actions, err := cfg.db.ListModerationActions(r.Context(), reportID)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetReport: failed to get actions %s", err), err)
+		return
+	}
+
+	resp := response{
+		Report:  reportFromDB(report),
+		Actions: make([]ModerationAction, len(actions)),
+	}
+	for i, a := range actions {
+		resp.Actions[i] = ModerationAction{
+			ID:        a.ID,
+			Action:    a.Action,
+			Note:      a.Note,
+			CreatedAt: a.CreatedAt,
+		}
+		if a.AdminID.Valid {
+			resp.Actions[i].AdminID = &a.AdminID.UUID
+		}
+	}
+
+	//* the reported chirp comes along, hidden or not, so the admin does not need a second request
+	if report.ChirpID.Valid {
+		chirp, err := cfg.db.GetChirp(r.Context(), report.ChirpID.UUID)
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetReport: failed to get chirp %s", err), err)
+			return
+		}
+		c := chirpFromDB(chirp)
+		if err := cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: adminID, Valid: true}, &c); err != nil {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetReport: failed to load chirp details %s", err), err)
+			return
+		}
+		resp.Chirp = &c
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerClaimReport(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Report
+	}
+
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerClaimReport: %s", err), err)
+		return
+	}
+
+	adminID, err := cfg.adminID(r)
+	if err != nil {
+		helpers.ResponseWithError(w, adminStatus(err), fmt.Sprintf("handlerClaimReport: %s", err), err)
+		return
+	}
+
+	report, err := cfg.db.GetReport(r.Context(), reportID)
+	if err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerClaimReport: report with ID - %s not exist", reportID), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerClaimReport: failed to get report %s", err), err)
+		return
+	}
+
+	wasOpen := report.Status == reportStatusOpen
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		report, err = q.ClaimReport(r.Context(), database.ClaimReportParams{
+			AdminID: adminID,
+			ID:      reportID,
+		})
+		//* claiming again is a no-op and stays out of the audit trail
+		if err != nil || !wasOpen {
+			return err
+		}
+		return q.CreateModerationAction(r.Context(), database.CreateModerationActionParams{
+			ReportID: reportID,
+			AdminID:  adminID,
+			Action:   moderationActionClaim,
+		})
+	})
+	if err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusConflict, fmt.Sprintf("handlerClaimReport: report ID - %s is resolved or claimed by another admin", reportID), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerClaimReport: failed to claim report %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Report: reportFromDB(report),
+	})
+}
+
+func (cfg *apiConfig) handlerResolveReport(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		Action string `json:"action"`
+		Note   string `json:"note"`
+	}
+	type response struct {
+		Report
+	}
+
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerResolveReport: %s", err), err)
+		return
+	}
+
+	adminID, err := cfg.adminID(r)
+	if err != nil {
+		helpers.ResponseWithError(w, adminStatus(err), fmt.Sprintf("handlerResolveReport: %s", err), err)
+		return
+	}
+
+	params := parameter{}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerResolveReport: failed to read params %s", err), err)
+		return
+	}
+
+	switch params.Action {
+	case moderationActionHideChirp, moderationActionSuspendUser, moderationActionDismiss:
+	default:
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerResolveReport: invalid action %s", params.Action), nil)
+		return
+	}
+
+	report, err := cfg.db.GetReport(r.Context(), reportID)
+	if err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerResolveReport: report with ID - %s not exist", reportID), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerResolveReport: failed to get report %s", err), err)
+		return
+	}
+	if params.Action == moderationActionHideChirp && !report.ChirpID.Valid {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerResolveReport: report ID - %s is not about a chirp", reportID), nil)
+		return
+	}
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		report, err = q.ResolveReport(r.Context(), database.ResolveReportParams{
+			Resolution: params.Action,
+			AdminID:    adminID,
+			ID:         reportID,
+		})
+		if err != nil {
+			return err
+		}
+
+		//* hiding or suspending twice is not an error, the report still gets resolved
+		switch params.Action {
+		case moderationActionHideChirp:
+			_, err = q.HideChirp(r.Context(), report.ChirpID.UUID)
+		case moderationActionSuspendUser:
+			_, err = q.SuspendUser(r.Context(), report.UserID)
+		}
+		if err != nil {
+			return err
+		}
+
+		return q.CreateModerationAction(r.Context(), database.CreateModerationActionParams{
+			ReportID: reportID,
+			AdminID:  adminID,
+			Action:   params.Action,
+			Note:     params.Note,
+		})
+	})
+	if err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusConflict, fmt.Sprintf("handlerResolveReport: report ID - %s is resolved or claimed by another admin", reportID), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerResolveReport: failed to resolve report %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Report: reportFromDB(report),
+	})
+}
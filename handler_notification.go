@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/notification"
+	"github.com/trantuvan/chirpy/internal/pagination"
+)
+
+// notificationActorLimit - actors listed per notification, the rest only show up in ActorCount
+const notificationActorLimit = 3
+
+// Notification - one group of events, e.g. every like a chirp got since the user last read it
+type Notification struct {
+	ID         uuid.UUID           `json:"id"`
+	Kind       string              `json:"kind"`
+	ChirpID    *uuid.UUID          `json:"chirp_id,omitempty"`
+	Actors     []NotificationActor `json:"actors"`
+	ActorCount int64               `json:"actor_count"`
+	Summary    string              `json:"summary"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+	ReadAt     *time.Time          `json:"read_at,omitempty"`
+}
+
+// NotificationActor - newest first
+type NotificationActor struct {
+	ID          uuid.UUID `json:"id"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name,omitempty"`
+}
+
+// name - how the actor reads in a summary
+func (a NotificationActor) name() string {
+	if a.DisplayName != "" {
+		return a.DisplayName
+	}
+	if a.Handle != "" {
+		return "@" + a.Handle
+	}
+	return "someone"
+}
+
+func notificationFromDB(n database.Notification, actorCount int64) Notification {
+	out := Notification{
+		ID:         n.ID,
+		Kind:       n.Kind,
+		Actors:     []NotificationActor{},
+		ActorCount: actorCount,
+		CreatedAt:  n.CreatedAt,
+		UpdatedAt:  n.UpdatedAt,
+	}
+	if n.ChirpID.Valid {
+		out.ChirpID = &n.ChirpID.UUID
+	}
+	if n.ReadAt.Valid {
+		out.ReadAt = &n.ReadAt.Time
+	}
+	return out
+}
+
+// attachActors - fills Actors and Summary with one query for the whole page
+func (cfg *apiConfig) attachActors(ctx context.Context, notifications []Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(notifications))
+	byID := make(map[uuid.UUID]*Notification, len(notifications))
+	for i := range notifications {
+		ids[i] = notifications[i].ID
+		byID[notifications[i].ID] = &notifications[i]
+	}
+
+	actors, err := cfg.db.ListNotificationActors(ctx, database.ListNotificationActorsParams{
+		NotificationIds: ids,
+		ActorLimit:      notificationActorLimit,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, a := range actors {
+		n := byID[a.NotificationID]
+		n.Actors = append(n.Actors, NotificationActor{
+			ID:          a.ID,
+			Handle:      a.Handle.String,
+			DisplayName: a.DisplayName,
+		})
+	}
+
+	for i := range notifications {
+		n := &notifications[i]
+		names := make([]string, len(n.Actors))
+		for j, a := range n.Actors {
+			names[j] = a.name()
+		}
+		n.Summary = notification.Summary(notification.Kind(n.Kind), names, n.ActorCount)
+	}
+
+	return nil
+}
+
+func (cfg *apiConfig) handlerGetNotifications(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Notifications []Notification `json:"notifications"`
+		UnreadCount   int64          `json:"unread_count"`
+		NextCursor    string         `json:"next_cursor,omitempty"`
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetNotifications: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetNotifications: %s", err), err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := pagination.ParseLimit(query.Get("limit"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetNotifications: %s", err), err)
+		return
+	}
+
+	cursor, err := pagination.DecodeCursor(query.Get("cursor"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetNotifications: %s", err), err)
+		return
+	}
+
+	//* the cursor's CreatedAt carries updated_at here, the column the page is ordered by
+	rows, err := cfg.db.ListNotifications(r.Context(), database.ListNotificationsParams{
+		UserID:          userID,
+		CursorUpdatedAt: cursor.NullCreatedAt(),
+		CursorID:        cursor.NullID(),
+		PageLimit:       limit + 1,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetNotifications: failed to get notifications %s", err), err)
+		return
+	}
+
+	rows, nextCursor := pagination.Paginate(rows, limit, func(row database.ListNotificationsRow) string {
+		return pagination.EncodeCursor(pagination.Cursor{CreatedAt: row.Notification.UpdatedAt, ID: row.Notification.ID})
+	})
+
+	notifications := make([]Notification, len(rows))
+	for i, row := range rows {
+		notifications[i] = notificationFromDB(row.Notification, row.ActorCount)
+	}
+
+	if err := cfg.attachActors(r.Context(), notifications); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetNotifications: failed to get actors %s", err), err)
+		return
+	}
+
+	unread, err := cfg.db.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetNotifications: failed to count unread %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Notifications: notifications,
+		UnreadCount:   unread,
+		NextCursor:    nextCursor,
+	})
+}
+
+// handlerReadNotifications - marks the listed notifications read, or all of them when ids is empty
+func (cfg *apiConfig) handlerReadNotifications(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		IDs []uuid.UUID `json:"ids"`
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerReadNotifications: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerReadNotifications: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerReadNotifications", userID); !ok {
+		return
+	}
+
+	//* body is optional: no body marks everything read
+	params := parameter{}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil && !errors.Is(err, io.EOF) {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerReadNotifications: failed to read params %s", err), err)
+		return
+	}
+
+	if len(params.IDs) == 0 {
+		err = cfg.db.MarkAllNotificationsRead(r.Context(), userID)
+	} else {
+		err = cfg.db.MarkNotificationsRead(r.Context(), database.MarkNotificationsReadParams{
+			UserID: userID,
+			Ids:    params.IDs,
+		})
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerReadNotifications: failed to mark read %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/entities"
+	"github.com/trantuvan/chirpy/internal/profile"
+)
+
+// Profile - the public face of a user; unlike User it never carries the email
+type Profile struct {
+	ID          uuid.UUID    `json:"id"`
+	Handle      string       `json:"handle,omitempty"`
+	DisplayName string       `json:"display_name,omitempty"`
+	Bio         string       `json:"bio,omitempty"`
+	AvatarURL   string       `json:"avatar_url,omitempty"`
+	IsChirpyRed bool         `json:"is_chirpy_red"`
+	JoinedAt    time.Time    `json:"joined_at"`
+	Stats       ProfileStats `json:"stats"`
+}
+
+type ProfileStats struct {
+	ChirpCount     int64 `json:"chirp_count"`
+	FollowerCount  int64 `json:"follower_count"`
+	FollowingCount int64 `json:"following_count"`
+}
+
+func (cfg *apiConfig) profileOf(ctx context.Context, user database.User) (Profile, error) {
+	stats, err := cfg.db.GetUserStats(ctx, user.ID)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	return Profile{
+		ID:          user.ID,
+		Handle:      user.Handle.String,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarURL:   user.AvatarUrl,
+		IsChirpyRed: user.IsChirpyRed,
+		JoinedAt:    user.CreatedAt,
+		Stats: ProfileStats{
+			ChirpCount:     stats.ChirpCount,
+			FollowerCount:  stats.FollowerCount,
+			FollowingCount: stats.FollowingCount,
+		},
+	}, nil
+}
+
+// userByIDOrHandle - a UUID is looked up as an id, anything else as a handle with or without its @
+func (cfg *apiConfig) userByIDOrHandle(ctx context.Context, idOrHandle string) (database.User, error) {
+	if id, err := uuid.Parse(idOrHandle); err == nil {
+		return cfg.db.GetUserByID(ctx, id)
+	}
+
+	handle := strings.TrimPrefix(idOrHandle, "@")
+	if !entities.IsValidHandle(handle) {
+		return database.User{}, sql.ErrNoRows
+	}
+	return cfg.db.GetUserByHandle(ctx, handle)
+}
+
+func (cfg *apiConfig) handlerGetProfile(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Profile
+	}
+
+	idOrHandle := r.PathValue("idOrHandle")
+
+	viewerID, err := cfg.viewerID(r)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerGetProfile: %s", err), err)
+		return
+	}
+
+	//* suspended users are gone as far as their profile is concerned
+	user, err := cfg.userByIDOrHandle(r.Context(), idOrHandle)
+	if err == sql.ErrNoRows || user.SuspendedAt.Valid {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetProfile: user %s not exist", idOrHandle), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetProfile: failed to get user %s", err), err)
+		return
+	}
+
+	if blocked, err := cfg.blockedWith(r.Context(), viewerID, user.ID); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetProfile: failed to check blocks %s", err), err)
+		return
+	} else if blocked {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerGetProfile: user %s not exist", idOrHandle), nil)
+		return
+	}
+
+	p, err := cfg.profileOf(r.Context(), user)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerGetProfile: failed to get stats %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Profile: p,
+	})
+}
+
+// handlerUpdateProfile - replaces display name, bio and avatar url; the handle is changed through PUT /api/users
+func (cfg *apiConfig) handlerUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	type parameter struct {
+		DisplayName string `json:"display_name"`
+		Bio         string `json:"bio"`
+		AvatarURL   string `json:"avatar_url"`
+	}
+	type response struct {
+		Profile
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerUpdateProfile: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerUpdateProfile: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerUpdateProfile", userID); !ok {
+		return
+	}
+
+	params := parameter{}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerUpdateProfile: failed to read params %s", err), err)
+		return
+	}
+
+	fields, err := profile.Validate(profile.Fields{
+		DisplayName: params.DisplayName,
+		Bio:         params.Bio,
+		AvatarURL:   params.AvatarURL,
+	})
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerUpdateProfile: %s", err), err)
+		return
+	}
+
+	user, err := cfg.db.UpdateUserProfile(r.Context(), database.UpdateUserProfileParams{
+		DisplayName: fields.DisplayName,
+		Bio:         fields.Bio,
+		AvatarUrl:   fields.AvatarURL,
+		ID:          userID,
+	})
+	if err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerUpdateProfile: user with ID - %s not exist", userID), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerUpdateProfile: failed to update profile %s", err), err)
+		return
+	}
+
+	p, err := cfg.profileOf(r.Context(), user)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerUpdateProfile: failed to get stats %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Profile: p,
+	})
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+)
+
+const maxReportDetailsLength = 1000
+
+const (
+	reportStatusOpen     = "open"
+	reportStatusClaimed  = "claimed"
+	reportStatusResolved = "resolved"
+)
+
+// reportReasons - the taxonomy reporters pick from, mirrored by the CHECK on reports.reason
+var reportReasons = map[string]struct{}{
+	"spam":           {},
+	"harassment":     {},
+	"hate":           {},
+	"violence":       {},
+	"sexual":         {},
+	"self_harm":      {},
+	"misinformation": {},
+	"impersonation":  {},
+	"other":          {},
+}
+
+type Report struct {
+	ID         uuid.UUID  `json:"id"`
+	ReporterID uuid.UUID  `json:"reporter_id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	ChirpID    *uuid.UUID `json:"chirp_id,omitempty"`
+	Reason     string     `json:"reason"`
+	Details    string     `json:"details,omitempty"`
+	Status     string     `json:"status"`
+	ClaimedBy  *uuid.UUID `json:"claimed_by,omitempty"`
+	ClaimedAt  *time.Time `json:"claimed_at,omitempty"`
+	Resolution string     `json:"resolution,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func reportFromDB(r database.Report) Report {
+	report := Report{
+		ID:         r.ID,
+		ReporterID: r.ReporterID,
+		UserID:     r.UserID,
+		Reason:     r.Reason,
+		Details:    r.Details,
+		Status:     r.Status,
+		Resolution: r.Resolution.String,
+		CreatedAt:  r.CreatedAt,
+		UpdatedAt:  r.UpdatedAt,
+	}
+
+	if r.ChirpID.Valid {
+		report.ChirpID = &r.ChirpID.UUID
+	}
+	if r.ClaimedBy.Valid {
+		report.ClaimedBy = &r.ClaimedBy.UUID
+	}
+	if r.ClaimedAt.Valid {
+		report.ClaimedAt = &r.ClaimedAt.Time
+	}
+	if r.ResolvedAt.Valid {
+		report.ResolvedAt = &r.ResolvedAt.Time
+	}
+
+	return report
+}
+
+type reportParameter struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+func (p reportParameter) validate() error {
+	if _, ok := reportReasons[p.Reason]; !ok {
+		return fmt.Errorf("invalid reason %q", p.Reason)
+	}
+	if utf8.RuneCountInString(p.Details) > maxReportDetailsLength {
+		return errors.New("details are too long")
+	}
+	return nil
+}
+
+func (cfg *apiConfig) handlerReportChirp(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Report
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerReportChirp: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerReportChirp: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerReportChirp: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerReportChirp", userID); !ok {
+		return
+	}
+
+	params := reportParameter{}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerReportChirp: failed to read params %s", err), err)
+		return
+	}
+	if err := params.validate(); err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerReportChirp: %s", err), err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil && err != sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerReportChirp: failed to get chirp %s", err), err)
+		return
+	}
+	if err == sql.ErrNoRows || !chirpVisibleTo(chirp, uuid.NullUUID{UUID: userID, Valid: true}) {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerReportChirp: chirp with ID - %s not exist", chirpID), err)
+		return
+	}
+	if withheld, err := cfg.chirpWithheldFrom(r.Context(), chirp, uuid.NullUUID{UUID: userID, Valid: true}); err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerReportChirp: failed to check visibility %s", err), err)
+		return
+	} else if withheld {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerReportChirp: chirp with ID - %s not exist", chirpID), nil)
+		return
+	}
+	if chirp.UserID == userID {
+		helpers.ResponseWithError(w, http.StatusBadRequest, "handlerReportChirp: cannot report your own chirp", nil)
+		return
+	}
+
+	report, err := cfg.db.CreateReport(r.Context(), database.CreateReportParams{
+		ReporterID: userID,
+		UserID:     chirp.UserID,
+		ChirpID:    uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		Reason:     params.Reason,
+		Details:    params.Details,
+	})
+	if isUniqueViolation(err) {
+		helpers.ResponseWithError(w, http.StatusConflict, fmt.Sprintf("handlerReportChirp: chirp ID - %s already reported", chirpID), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerReportChirp: failed to create report %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusCreated, response{
+		Report: reportFromDB(report),
+	})
+}
+
+func (cfg *apiConfig) handlerReportUser(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Report
+	}
+
+	reportedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerReportUser: %s", err), err)
+		return
+	}
+
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerReportUser: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerReportUser: %s", err), err)
+		return
+	}
+
+	if _, ok := cfg.requireActiveUser(w, r, "handlerReportUser", userID); !ok {
+		return
+	}
+
+	params := reportParameter{}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerReportUser: failed to read params %s", err), err)
+		return
+	}
+	if err := params.validate(); err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerReportUser: %s", err), err)
+		return
+	}
+
+	if reportedID == userID {
+		helpers.ResponseWithError(w, http.StatusBadRequest, "handlerReportUser: cannot report yourself", nil)
+		return
+	}
+
+	if _, err := cfg.db.GetUserByID(r.Context(), reportedID); err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusNotFound, fmt.Sprintf("handlerReportUser: user with ID - %s not exist", reportedID), err)
+		return
+	} else if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerReportUser: failed to get user %s", err), err)
+		return
+	}
+
+	report, err := cfg.db.CreateReport(r.Context(), database.CreateReportParams{
+		ReporterID: userID,
+		UserID:     reportedID,
+		Reason:     params.Reason,
+		Details:    params.Details,
+	})
+	if isUniqueViolation(err) {
+		helpers.ResponseWithError(w, http.StatusConflict, fmt.Sprintf("handlerReportUser: user ID - %s already reported", reportedID), err)
+		return
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerReportUser: failed to create report %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusCreated, response{
+		Report: reportFromDB(report),
+	})
+}
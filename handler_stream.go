@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/entities"
+	"github.com/trantuvan/chirpy/internal/stream"
+)
+
+const (
+	// streamBuffer - events a client may fall behind by before it is disconnected to resume
+	streamBuffer    = 64
+	streamHeartbeat = 15 * time.Second
+)
+
+// handlerStreamChirps - pushes chirp.created and chirp.deleted as Server-Sent Events, filtered by
+// author_id, hashtag or following=true. A Last-Event-ID header replays what the client missed,
+// for as long as chirpEventRetention keeps it.
+func (cfg *apiConfig) handlerStreamChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := cfg.viewerID(r)
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("handlerStreamChirps: %s", err), err)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := stream.Filter{
+		Hashtag: strings.ToLower(strings.TrimPrefix(query.Get("hashtag"), "#")),
+	}
+
+	if authorID := query.Get("author_id"); authorID != "" {
+		id, err := uuid.Parse(authorID)
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerStreamChirps: %s", err), err)
+			return
+		}
+		filter.AuthorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	if query.Get("following") == "true" {
+		if !viewerID.Valid {
+			helpers.ResponseWithError(w, http.StatusUnauthorized, "handlerStreamChirps: following requires a token", nil)
+			return
+		}
+		followees, err := cfg.db.ListFolloweeIDs(r.Context(), viewerID.UUID)
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerStreamChirps: failed to get follows %s", err), err)
+			return
+		}
+		//* like the timeline, the caller's own chirps are part of it
+		filter.Following = map[uuid.UUID]struct{}{viewerID.UUID: {}}
+		for _, id := range followees {
+			filter.Following[id] = struct{}{}
+		}
+	}
+
+	if viewerID.Valid {
+		muted, err := cfg.db.ListMutedUserIDs(r.Context(), viewerID.UUID)
+		if err != nil {
+			helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerStreamChirps: failed to get mutes %s", err), err)
+			return
+		}
+		filter.Muted = make(map[uuid.UUID]struct{}, len(muted))
+		for _, id := range muted {
+			filter.Muted[id] = struct{}{}
+		}
+	}
+
+	lastEventID, err := stream.ParseLastEventID(r.Header.Get("Last-Event-ID"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerStreamChirps: %s", err), err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, "handlerStreamChirps: streaming unsupported", nil)
+		return
+	}
+
+	//* subscribe before replaying so nothing published during the replay is missed
+	sub := cfg.chirpStream.Subscribe(streamBuffer)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	replayedUpTo := lastEventID
+	for lastEventID > 0 {
+		events, err := cfg.db.ListChirpEventsAfter(r.Context(), database.ListChirpEventsAfterParams{
+			AfterID:   replayedUpTo,
+			PageLimit: chirpEventBatchSize,
+		})
+		if err != nil {
+			log.Printf("handlerStreamChirps: failed to replay events %s\n", err)
+			return
+		}
+		for _, e := range events {
+			if err := cfg.writeChirpEvent(r.Context(), w, viewerID, filter, streamEventFromDB(e)); err != nil {
+				log.Printf("handlerStreamChirps: %s\n", err)
+				return
+			}
+			replayedUpTo = e.ID
+		}
+		if int32(len(events)) < chirpEventBatchSize {
+			break
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if err := stream.WriteComment(w, "ping"); err != nil {
+				return
+			}
+		case e, ok := <-sub.C:
+			//* closed when the client fell behind; it reconnects with Last-Event-ID
+			if !ok {
+				return
+			}
+			if e.ID <= replayedUpTo {
+				continue
+			}
+			if err := cfg.writeChirpEvent(r.Context(), w, viewerID, filter, e); err != nil {
+				log.Printf("handlerStreamChirps: %s\n", err)
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeChirpEvent - writes nothing when the event is filtered out or the viewer may not read the chirp
+func (cfg *apiConfig) writeChirpEvent(ctx context.Context, w io.Writer, viewer uuid.NullUUID, filter stream.Filter, e stream.Event) error {
+	name, data, err := cfg.chirpEventPayload(ctx, viewer, filter, e)
+	if err != nil || name == "" {
+		return err
+	}
+	return stream.WriteEvent(w, e.ID, name, data)
+}
+
+// chirpEventPayload - the event name and JSON a viewer gets for e, shared by the SSE stream and
+// the WebSocket gateway; an empty name means the viewer gets nothing
+func (cfg *apiConfig) chirpEventPayload(ctx context.Context, viewer uuid.NullUUID, filter stream.Filter, e stream.Event) (string, []byte, error) {
+	if blocked, err := cfg.blockedWith(ctx, viewer, e.UserID); err != nil || blocked {
+		return "", nil, err
+	}
+
+	if e.Kind == "deleted" {
+		//* the body is gone by now, so deletions skip the hashtag and thread filters;
+		//* clients ignore ids they never got
+		filter.Hashtag = ""
+		filter.Thread = uuid.NullUUID{}
+		if !filter.Matches(e.UserID, uuid.Nil, nil) {
+			return "", nil, nil
+		}
+		data, err := json.Marshal(struct {
+			ID uuid.UUID `json:"id"`
+		}{ID: e.ChirpID})
+		return "chirp.deleted", data, err
+	}
+
+	//* deleted, hidden or unpublished again since the event, a later event covers it
+	chirp, err := cfg.db.GetChirp(ctx, e.ChirpID)
+	if err != nil && err != sql.ErrNoRows {
+		return "", nil, err
+	}
+	if err == sql.ErrNoRows || !chirpVisibleTo(chirp, uuid.NullUUID{}) {
+		return "", nil, nil
+	}
+
+	if !filter.Matches(chirp.UserID, chirp.ConversationID, entities.Hashtags(chirp.Body)) {
+		return "", nil, nil
+	}
+	if allowed, err := cfg.chirpAllows(ctx, chirp, viewer); err != nil || !allowed {
+		return "", nil, err
+	}
+
+	c := chirpFromDB(chirp)
+	if err := cfg.hydrateChirps(ctx, viewer, &c); err != nil {
+		return "", nil, err
+	}
+
+	data, err := json.Marshal(c)
+	return "chirp.created", data, err
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/trending"
+)
+
+// countHashtags - trending.CountFunc backed by chirp_hashtags
+func (cfg *apiConfig) countHashtags(ctx context.Context, windowStart, baselineStart time.Time) ([]trending.Count, error) {
+	rows, err := cfg.db.CountHashtagsSince(ctx, database.CountHashtagsSinceParams{
+		WindowStart:   windowStart,
+		BaselineStart: baselineStart,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]trending.Count, len(rows))
+	for i, row := range rows {
+		counts[i] = trending.Count{
+			Tag:      row.Tag,
+			Recent:   row.RecentCount,
+			Baseline: row.BaselineCount,
+		}
+	}
+	return counts, nil
+}
+
+func (cfg *apiConfig) handlerGetTrending(w http.ResponseWriter, r *http.Request) {
+	type tag struct {
+		Tag      string  `json:"tag"`
+		Count    int64   `json:"count"`
+		Velocity float64 `json:"velocity"`
+	}
+	type response struct {
+		Window     trending.Window `json:"window"`
+		ComputedAt time.Time       `json:"computed_at"`
+		Tags       []tag           `json:"tags"`
+	}
+
+	window, err := trending.ParseWindow(r.URL.Query().Get("window"))
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerGetTrending: %s", err), err)
+		return
+	}
+
+	//* served from the aggregator cache, chirps are never scanned per request
+	snapshot, ok := cfg.trending.Snapshot(window)
+	if !ok {
+		helpers.ResponseWithError(w, http.StatusServiceUnavailable, fmt.Sprintf("handlerGetTrending: window %s not computed yet", window), nil)
+		return
+	}
+
+	tags := make([]tag, len(snapshot.Tags))
+	for i, t := range snapshot.Tags {
+		tags[i] = tag{
+			Tag:      t.Tag,
+			Count:    t.Count,
+			Velocity: t.Velocity,
+		}
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, response{
+		Window:     window,
+		ComputedAt: snapshot.ComputedAt,
+		Tags:       tags,
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
@@ -11,24 +12,29 @@ import (
 	"github.com/trantuvan/chirpy/helpers"
 	"github.com/trantuvan/chirpy/internal/auth"
 	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/entities"
+	"github.com/trantuvan/chirpy/internal/mail"
 )
 
 const ExpiresTime = time.Second * 3600
 
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Email        string    `json:"email"`
-	IsChirpyRed  bool      `json:"is_chirpy_red"`
-	Token        string    `json:"token,omitempty"`
-	RefreshToken string    `json:"refresh_token,omitempty"`
+	ID            uuid.UUID `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	IsChirpyRed   bool      `json:"is_chirpy_red"`
+	Handle        string    `json:"handle,omitempty"`
+	Token         string    `json:"token,omitempty"`
+	RefreshToken  string    `json:"refresh_token,omitempty"`
 }
 
 func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request) {
 	type parameter struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Handle   string `json:"handle"`
 	}
 	type response struct {
 		User
@@ -43,6 +49,11 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if !mail.ValidAddress(params.Email) {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerCreateUser: invalid email %s", params.Email), nil)
+		return
+	}
+
 	hashedPass, err := auth.HashPassword(params.Password)
 
 	if err != nil {
@@ -50,23 +61,51 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	user, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
-		Email:          params.Email,
-		HashedPassword: sql.NullString{String: hashedPass, Valid: err == nil},
+	if params.Handle != "" && !entities.IsValidHandle(params.Handle) {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("handlerCreateUser: invalid handle %s", params.Handle), nil)
+		return
+	}
+
+	var user database.User
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		user, err = q.CreateUser(r.Context(), database.CreateUserParams{
+			Email:          params.Email,
+			HashedPassword: sql.NullString{String: hashedPass, Valid: true},
+		})
+		if err != nil || params.Handle == "" {
+			return err
+		}
+		user, err = q.UpdateUserHandle(r.Context(), database.UpdateUserHandleParams{
+			Handle: sql.NullString{String: params.Handle, Valid: true},
+			ID:     user.ID,
+		})
+		return err
 	})
 
+	if isUniqueViolation(err) {
+		helpers.ResponseWithError(w, http.StatusConflict, "handlerCreateUser: email or handle already taken", err)
+		return
+	}
 	if err != nil {
 		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("handlerCreateUser: failed to create user %s\n", err), err)
 		return
 	}
 
+	//* the account exists either way, a failed mail can be sent again from POST /api/users/verify-email
+	if err := cfg.sendVerification(r.Context(), user); err != nil {
+		log.Printf("handlerCreateUser: failed to send verification %s\n", err)
+	}
+
 	helpers.ResponseWithJson(w, http.StatusCreated, response{
 		User: User{
-			ID:          user.ID,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-			Email:       user.Email,
-			IsChirpyRed: user.IsChirpyRed,
+			ID:            user.ID,
+			CreatedAt:     user.CreatedAt,
+			UpdatedAt:     user.UpdatedAt,
+			Email:         user.Email.String,
+			EmailVerified: user.EmailVerifiedAt.Valid,
+			IsChirpyRed:   user.IsChirpyRed,
+			Handle:        user.Handle.String,
 		},
 	})
 }
@@ -75,6 +114,7 @@ func (cfg *apiConfig) handlerUpdateUserEmailPassword(w http.ResponseWriter, r *h
 	type parameter struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Handle   string `json:"handle"`
 	}
 	type response struct {
 		User
@@ -92,6 +132,10 @@ func (cfg *apiConfig) handlerUpdateUserEmailPassword(w http.ResponseWriter, r *h
 		return
 	}
 
+	if _, ok := cfg.requireActiveUser(w, r, "UpdateUserEmailPassword", userID); !ok {
+		return
+	}
+
 	params := parameter{}
 	defer r.Body.Close()
 	decoder := json.NewDecoder(r.Body)
@@ -100,29 +144,68 @@ func (cfg *apiConfig) handlerUpdateUserEmailPassword(w http.ResponseWriter, r *h
 		return
 	}
 
+	if !mail.ValidAddress(params.Email) {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("UpdateUserEmailPassword: invalid email %s", params.Email), nil)
+		return
+	}
+
 	hasedPass, err := auth.HashPassword(params.Password)
 	if err != nil {
 		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("UpdateUserEmailPassword: bad password %s", err), err)
 		return
 	}
 
-	updatedUser, err := cfg.db.UpdateUserEmailPassword(r.Context(), database.UpdateUserEmailPasswordParams{
-		Email:          params.Email,
-		HashedPassword: sql.NullString{String: hasedPass, Valid: err != nil},
-		ID:             userID,
+	if params.Handle != "" && !entities.IsValidHandle(params.Handle) {
+		helpers.ResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("UpdateUserEmailPassword: invalid handle %s", params.Handle), nil)
+		return
+	}
+
+	var updatedUser database.User
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		updatedUser, err = q.UpdateUserEmailPassword(r.Context(), database.UpdateUserEmailPasswordParams{
+			Email:          params.Email,
+			HashedPassword: sql.NullString{String: hasedPass, Valid: true},
+			ID:             userID,
+		})
+		if err != nil || params.Handle == "" {
+			return err
+		}
+		updatedUser, err = q.UpdateUserHandle(r.Context(), database.UpdateUserHandleParams{
+			Handle: sql.NullString{String: params.Handle, Valid: true},
+			ID:     userID,
+		})
+		return err
 	})
+	if isUniqueViolation(err) {
+		helpers.ResponseWithError(w, http.StatusConflict, "UpdateUserEmailPassword: email or handle already taken", err)
+		return
+	}
 	if err != nil {
 		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("UpdateUserEmailPassword: failed to update user %s", err), err)
 		return
 	}
 
+	//* a new email loses its verification, send a link unless one already went to this address
+	if !updatedUser.EmailVerifiedAt.Valid {
+		latest, err := cfg.db.GetLatestEmailVerification(r.Context(), updatedUser.ID)
+		if err == sql.ErrNoRows || (err == nil && latest.Email != updatedUser.Email.String) {
+			err = cfg.sendVerification(r.Context(), updatedUser)
+		}
+		if err != nil {
+			log.Printf("UpdateUserEmailPassword: failed to send verification %s\n", err)
+		}
+	}
+
 	helpers.ResponseWithJson(w, http.StatusOK, response{
 		User: User{
-			ID:          updatedUser.ID,
-			CreatedAt:   updatedUser.UpdatedAt,
-			UpdatedAt:   updatedUser.UpdatedAt,
-			Email:       updatedUser.Email,
-			IsChirpyRed: updatedUser.IsChirpyRed,
+			ID:            updatedUser.ID,
+			CreatedAt:     updatedUser.UpdatedAt,
+			UpdatedAt:     updatedUser.UpdatedAt,
+			Email:         updatedUser.Email.String,
+			EmailVerified: updatedUser.EmailVerifiedAt.Valid,
+			IsChirpyRed:   updatedUser.IsChirpyRed,
+			Handle:        updatedUser.Handle.String,
 		},
 	})
 }
@@ -210,6 +293,11 @@ func (cfg *apiConfig) hanlderLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user.SuspendedAt.Valid {
+		helpers.ResponseWithError(w, http.StatusForbidden, "handlerLogin: user is suspended", nil)
+		return
+	}
+
 	tokenJWT, errTokenJWT := auth.MakeJWT(user.ID, cfg.secretKey, ExpiresTime)
 
 	if errTokenJWT != nil {
@@ -239,13 +327,15 @@ func (cfg *apiConfig) hanlderLogin(w http.ResponseWriter, r *http.Request) {
 
 	helpers.ResponseWithJson(w, http.StatusOK, response{
 		User: User{
-			ID:           user.ID,
-			CreatedAt:    user.CreatedAt,
-			UpdatedAt:    user.UpdatedAt,
-			Email:        user.Email,
-			IsChirpyRed:  user.IsChirpyRed,
-			Token:        tokenJWT,
-			RefreshToken: refreshToken.Token,
+			ID:            user.ID,
+			CreatedAt:     user.CreatedAt,
+			UpdatedAt:     user.UpdatedAt,
+			Email:         user.Email.String,
+			EmailVerified: user.EmailVerifiedAt.Valid,
+			IsChirpyRed:   user.IsChirpyRed,
+			Handle:        user.Handle.String,
+			Token:         tokenJWT,
+			RefreshToken:  refreshToken.Token,
 		},
 	})
 }
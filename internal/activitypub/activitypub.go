@@ -0,0 +1,194 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// ContentType - what actors, objects and activities are served and delivered as
+	ContentType = "application/activity+json"
+	// JRDContentType - WebFinger responses
+	JRDContentType = "application/jrd+json"
+	// Public - the collection a note is addressed to when anyone may read it
+	Public = "https://www.w3.org/ns/activitystreams#Public"
+
+	activityStreamsContext = "https://www.w3.org/ns/activitystreams"
+	securityContext        = "https://w3id.org/security/v1"
+)
+
+// Activity types Chirpy sends or handles
+const (
+	TypeCreate   = "Create"
+	TypeDelete   = "Delete"
+	TypeFollow   = "Follow"
+	TypeAccept   = "Accept"
+	TypeLike     = "Like"
+	TypeAnnounce = "Announce"
+	TypeUndo     = "Undo"
+	TypeNote     = "Note"
+)
+
+var (
+	ErrInvalidResource = errors.New("invalid webfinger resource")
+	ErrNoObject        = errors.New("activity has no object")
+	ErrPrivateAddress  = errors.New("address is not public")
+)
+
+// Context - the @context of an actor, which also carries its key
+var Context = []string{activityStreamsContext, securityContext}
+
+// Actor - a Person with the key its deliveries are signed with
+type Actor struct {
+	Context           any        `json:"@context,omitempty"`
+	ID                string     `json:"id"`
+	Type              string     `json:"type"`
+	PreferredUsername string     `json:"preferredUsername"`
+	Name              string     `json:"name,omitempty"`
+	Summary           string     `json:"summary,omitempty"`
+	URL               string     `json:"url,omitempty"`
+	Icon              *Image     `json:"icon,omitempty"`
+	Inbox             string     `json:"inbox"`
+	Outbox            string     `json:"outbox"`
+	Followers         string     `json:"followers,omitempty"`
+	Endpoints         *Endpoints `json:"endpoints,omitempty"`
+	PublicKey         PublicKey  `json:"publicKey"`
+}
+
+// Image -
+type Image struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// Endpoints -
+type Endpoints struct {
+	SharedInbox string `json:"sharedInbox,omitempty"`
+}
+
+// PublicKey - ID is the keyId signatures name
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+// Activity - Object is kept raw: it is a bare id for Follow, Like and Delete, and an embedded
+// object for Create, Undo and Accept
+type Activity struct {
+	Context   any             `json:"@context,omitempty"`
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Actor     string          `json:"actor"`
+	Object    json.RawMessage `json:"object"`
+	To        []string        `json:"to,omitempty"`
+	CC        []string        `json:"cc,omitempty"`
+	Published *time.Time      `json:"published,omitempty"`
+}
+
+// NewActivity - object is marshalled into Object; a string becomes a bare id
+func NewActivity(id, typ, actor string, object any) (Activity, error) {
+	raw, err := json.Marshal(object)
+	if err != nil {
+		return Activity{}, err
+	}
+	return Activity{
+		Context: activityStreamsContext,
+		ID:      id,
+		Type:    typ,
+		Actor:   actor,
+		Object:  raw,
+	}, nil
+}
+
+// ObjectID - the id of Object, whether it is a bare id or an embedded object
+func (a Activity) ObjectID() (string, error) {
+	if len(a.Object) == 0 {
+		return "", ErrNoObject
+	}
+	var id string
+	if err := json.Unmarshal(a.Object, &id); err == nil {
+		return id, nil
+	}
+	object := struct {
+		ID string `json:"id"`
+	}{}
+	if err := json.Unmarshal(a.Object, &object); err != nil {
+		return "", err
+	}
+	if object.ID == "" {
+		return "", ErrNoObject
+	}
+	return object.ID, nil
+}
+
+// Note - a chirp; Content is HTML, see Content and PlainText
+type Note struct {
+	Context      any       `json:"@context,omitempty"`
+	ID           string    `json:"id"`
+	Type         string    `json:"type"`
+	AttributedTo string    `json:"attributedTo"`
+	Content      string    `json:"content"`
+	InReplyTo    string    `json:"inReplyTo,omitempty"`
+	Published    time.Time `json:"published"`
+	URL          string    `json:"url,omitempty"`
+	To           []string  `json:"to"`
+	CC           []string  `json:"cc,omitempty"`
+}
+
+// IsPublic - addressed to Public, directly or as a cc
+func (n Note) IsPublic() bool {
+	return slices.Contains(n.To, Public) || slices.Contains(n.CC, Public)
+}
+
+// Tombstone - what a deleted note is replaced by in a Delete
+type Tombstone struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// OrderedCollection - outboxes and follower collections
+type OrderedCollection struct {
+	Context      any    `json:"@context,omitempty"`
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	TotalItems   int64  `json:"totalItems"`
+	OrderedItems []any  `json:"orderedItems,omitempty"`
+}
+
+// WebFinger - the JRD document served at /.well-known/webfinger
+type WebFinger struct {
+	Subject string          `json:"subject"`
+	Aliases []string        `json:"aliases,omitempty"`
+	Links   []WebFingerLink `json:"links"`
+}
+
+// WebFingerLink -
+type WebFingerLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href,omitempty"`
+}
+
+// ActorURL - the self link pointing at the actor document, "" when there is none
+func (w WebFinger) ActorURL() string {
+	for _, link := range w.Links {
+		if link.Rel == "self" && (link.Type == ContentType || strings.HasPrefix(link.Type, "application/ld+json")) {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// ParseAcct - "acct:alice@example.com", "alice@example.com" or "@alice@example.com"
+func ParseAcct(resource string) (user, host string, err error) {
+	acct := strings.TrimPrefix(strings.TrimPrefix(resource, "acct:"), "@")
+	user, host, ok := strings.Cut(acct, "@")
+	if !ok || user == "" || host == "" || strings.ContainsAny(host, "@/") {
+		return "", "", ErrInvalidResource
+	}
+	return user, host, nil
+}
@@ -0,0 +1,445 @@
+package activitypub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAcct(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource string
+		wantUser string
+		wantHost string
+		wantErr  error
+	}{
+		{
+			name:     "Acct URI",
+			resource: "acct:alice@chirpy.example",
+			wantUser: "alice",
+			wantHost: "chirpy.example",
+			wantErr:  nil,
+		},
+		{
+			name:     "Bare with port",
+			resource: "alice@localhost:8081",
+			wantUser: "alice",
+			wantHost: "localhost:8081",
+			wantErr:  nil,
+		},
+		{
+			name:     "Leading @",
+			resource: "@alice@chirpy.example",
+			wantUser: "alice",
+			wantHost: "chirpy.example",
+			wantErr:  nil,
+		},
+		{
+			name:     "No host",
+			resource: "acct:alice",
+			wantErr:  ErrInvalidResource,
+		},
+		{
+			name:     "URL",
+			resource: "https://chirpy.example/ap/users/alice",
+			wantErr:  ErrInvalidResource,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, host, err := ParseAcct(tt.resource)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseAcct() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if user != tt.wantUser || host != tt.wantHost {
+				t.Errorf("ParseAcct() = %q, %q, want %q, %q", user, host, tt.wantUser, tt.wantHost)
+			}
+		})
+	}
+}
+
+func TestObjectID(t *testing.T) {
+	tests := []struct {
+		name    string
+		object  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "Bare id",
+			object: `"https://a.example/notes/1"`,
+			want:   "https://a.example/notes/1",
+		},
+		{
+			name:   "Embedded",
+			object: `{"id":"https://a.example/notes/1","type":"Note"}`,
+			want:   "https://a.example/notes/1",
+		},
+		{
+			name:    "Embedded without id",
+			object:  `{"type":"Note"}`,
+			wantErr: true,
+		},
+		{
+			name:    "Missing",
+			object:  ``,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Activity{Object: json.RawMessage(tt.object)}.ObjectID()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ObjectID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ObjectID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoteIsPublic(t *testing.T) {
+	tests := []struct {
+		name string
+		note Note
+		want bool
+	}{
+		{name: "To public", note: Note{To: []string{Public}}, want: true},
+		{name: "Unlisted", note: Note{To: []string{"https://a.example/followers"}, CC: []string{Public}}, want: true},
+		{name: "Followers only", note: Note{To: []string{"https://a.example/followers"}}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.note.IsPublic(); got != tt.want {
+				t.Errorf("IsPublic() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "Round trip",
+			content: Content("a < b & c\nsecond line"),
+			want:    "a < b & c\nsecond line",
+		},
+		{
+			name:    "Mastodon markup",
+			content: `<p>hi <span class="h-card"><a href="https://a.example/@bob" class="u-url mention">@<span>bob</span></a></span></p><p>bye<br/>now</p>`,
+			want:    "hi @bob\n\nbye\nnow",
+		},
+		{
+			name:    "Unclosed tag",
+			content: "text <a href=",
+			want:    "text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlainText(tt.content); got != tt.want {
+				t.Errorf("PlainText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignature(t *testing.T) {
+	privatePEM, publicPEM, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	key, err := ParsePrivateKey(privatePEM)
+	if err != nil {
+		t.Fatalf("ParsePrivateKey() error = %v", err)
+	}
+	public, err := ParsePublicKey(publicPEM)
+	if err != nil {
+		t.Fatalf("ParsePublicKey() error = %v", err)
+	}
+	_, otherPEM, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	other, err := ParsePublicKey(otherPEM)
+	if err != nil {
+		t.Fatalf("ParsePublicKey() error = %v", err)
+	}
+
+	body := []byte(`{"type":"Follow"}`)
+	signed := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "https://b.example/ap/inbox", strings.NewReader(string(body)))
+		if err := Sign(r, "https://a.example/ap/users/1#main-key", key, body); err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		return r
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		r := signed()
+		sig, err := ParseSignature(r.Header)
+		if err != nil {
+			t.Fatalf("ParseSignature() error = %v", err)
+		}
+		if sig.KeyID != "https://a.example/ap/users/1#main-key" {
+			t.Errorf("KeyID = %q", sig.KeyID)
+		}
+		if err := sig.Verify(r, public, time.Now()); err != nil {
+			t.Errorf("Verify() error = %v", err)
+		}
+		if err := VerifyDigest(r.Header, body); err != nil {
+			t.Errorf("VerifyDigest() error = %v", err)
+		}
+	})
+
+	t.Run("Other key", func(t *testing.T) {
+		r := signed()
+		sig, _ := ParseSignature(r.Header)
+		if err := sig.Verify(r, other, time.Now()); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Verify() error = %v, want %v", err, ErrInvalidSignature)
+		}
+	})
+
+	t.Run("Tampered path", func(t *testing.T) {
+		r := signed()
+		sig, _ := ParseSignature(r.Header)
+		r.URL.Path = "/ap/users/2/inbox"
+		if err := sig.Verify(r, public, time.Now()); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Verify() error = %v, want %v", err, ErrInvalidSignature)
+		}
+	})
+
+	t.Run("Tampered body", func(t *testing.T) {
+		r := signed()
+		if err := VerifyDigest(r.Header, []byte(`{"type":"Delete"}`)); !errors.Is(err, ErrDigestMismatch) {
+			t.Errorf("VerifyDigest() error = %v, want %v", err, ErrDigestMismatch)
+		}
+	})
+
+	t.Run("Stale date", func(t *testing.T) {
+		r := signed()
+		sig, _ := ParseSignature(r.Header)
+		if err := sig.Verify(r, public, time.Now().Add(2*MaxClockSkew)); !errors.Is(err, ErrStaleDate) {
+			t.Errorf("Verify() error = %v, want %v", err, ErrStaleDate)
+		}
+		if err := VerifyDate(r.Header, time.Now().Add(2*MaxClockSkew)); !errors.Is(err, ErrStaleDate) {
+			t.Errorf("VerifyDate() error = %v, want %v", err, ErrStaleDate)
+		}
+	})
+
+	t.Run("Digest not signed", func(t *testing.T) {
+		r := signed()
+		sig, _ := ParseSignature(r.Header)
+		sig.Headers = signedHeadersGet
+		if err := sig.Verify(r, public, time.Now()); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Verify() error = %v, want %v", err, ErrInvalidSignature)
+		}
+	})
+
+	t.Run("Unsigned", func(t *testing.T) {
+		if _, err := ParseSignature(http.Header{}); !errors.Is(err, ErrNoSignature) {
+			t.Errorf("ParseSignature() error = %v, want %v", err, ErrNoSignature)
+		}
+	})
+}
+
+func TestDeliver(t *testing.T) {
+	privatePEM, publicPEM, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	key, _ := ParsePrivateKey(privatePEM)
+	public, _ := ParsePublicKey(publicPEM)
+
+	activity := []byte(`{"type":"Like"}`)
+	received := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sig, err := ParseSignature(r.Header)
+		if err == nil {
+			err = sig.Verify(r, public, time.Now())
+		}
+		if err == nil {
+			err = VerifyDigest(r.Header, body)
+		}
+		received <- err
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	if err := Deliver(context.Background(), server.Client(), server.URL+"/ap/inbox", activity, "key", key); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if err := <-received; err != nil {
+		t.Errorf("inbox rejected the delivery: %v", err)
+	}
+}
+
+func TestLookupActor(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/webfinger":
+			if got, want := r.URL.Query().Get("resource"), "acct:alice@"+r.Host; got != want {
+				http.Error(w, "unknown resource", http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(WebFinger{
+				Subject: r.URL.Query().Get("resource"),
+				Links:   []WebFingerLink{{Rel: "self", Type: ContentType, Href: server.URL + "/ap/users/alice"}},
+			})
+		case "/ap/users/alice":
+			json.NewEncoder(w).Encode(Actor{
+				ID:        server.URL + "/ap/users/alice",
+				Type:      "Person",
+				Inbox:     server.URL + "/ap/users/alice/inbox",
+				PublicKey: PublicKey{ID: server.URL + "/ap/users/alice#main-key", PublicKeyPem: "pem"},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	actorURL, err := LookupActor(context.Background(), server.Client(), "http", "alice", host)
+	if err != nil {
+		t.Fatalf("LookupActor() error = %v", err)
+	}
+	actor, err := FetchActor(context.Background(), server.Client(), actorURL)
+	if err != nil {
+		t.Fatalf("FetchActor() error = %v", err)
+	}
+	if actor.Inbox != server.URL+"/ap/users/alice/inbox" {
+		t.Errorf("Inbox = %q", actor.Inbox)
+	}
+
+	if _, err := LookupActor(context.Background(), server.Client(), "http", "bob", host); err == nil {
+		t.Error("LookupActor() error = nil for an unknown user")
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "93.184.216.34", want: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{addr: "127.0.0.1", want: false},
+		{addr: "::1", want: false},
+		{addr: "10.1.2.3", want: false},
+		{addr: "172.16.0.1", want: false},
+		{addr: "192.168.1.1", want: false},
+		{addr: "100.64.0.1", want: false},
+		{addr: "169.254.169.254", want: false},
+		{addr: "fe80::1", want: false},
+		{addr: "fd00::1", want: false},
+		{addr: "0.0.0.0", want: false},
+		{addr: "::", want: false},
+		{addr: "224.0.0.1", want: false},
+		{addr: "::ffff:127.0.0.1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := PublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("PublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(WebFinger{Subject: r.URL.Query().Get("resource")})
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	tests := []struct {
+		name         string
+		allowPrivate bool
+		wantErr      error
+	}{
+		{name: "Loopback refused", wantErr: ErrPrivateAddress},
+		{name: "Loopback allowed for local testing", allowPrivate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(time.Second, tt.allowPrivate)
+			_, err := LookupActor(context.Background(), client, "http", "alice", host)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("LookupActor() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			//* the server answers, with a WebFinger that has no actor
+			if err == nil || !strings.Contains(err.Error(), "has no actor") {
+				t.Fatalf("LookupActor() error = %v, want the missing actor", err)
+			}
+		})
+	}
+}
+
+func TestQueue(t *testing.T) {
+	ran := make(chan int)
+	q := NewQueue(2)
+
+	job := func(n int) Job {
+		return func(ctx context.Context) error {
+			ran <- n
+			return nil
+		}
+	}
+	if !q.Enqueue(job(1)) || !q.Enqueue(job(2)) {
+		t.Fatal("Enqueue() = false before the queue is full")
+	}
+	if q.Enqueue(job(3)) {
+		t.Error("Enqueue() = true on a full queue")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+
+	for _, want := range []int{1, 2} {
+		select {
+		case got := <-ran:
+			if got != want {
+				t.Errorf("ran job %d, want %d", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("job not run")
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not stop after ctx was done")
+	}
+}
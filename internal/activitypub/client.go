@@ -0,0 +1,141 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// maxDocumentSize - the most read of a remote actor or WebFinger response
+const maxDocumentSize = 1 << 20
+
+// sharedAddressSpace - carrier-grade NAT, as internal as the private ranges
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// NewClient - the client other instances are reached with. The URLs it is given come from remote
+// documents and from users, so unless allowPrivate (for instances federating over localhost) it
+// refuses to connect to anything but public addresses. The check runs on the address dialed,
+// after DNS and on every redirect, so a name resolving to 127.0.0.1 does not get past it.
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		//* through a proxy the dialed address would be the proxy's, not the instance's
+		transport.Proxy = nil
+		transport.DialContext = (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   dialControl,
+		}).DialContext
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+func dialControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !PublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// PublicAddr - false for loopback, private, link-local, unspecified and multicast addresses
+func PublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsUnspecified() &&
+		!addr.IsMulticast() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// FetchActor - GETs an actor document; its id must be the URL it was fetched from
+func FetchActor(ctx context.Context, client *http.Client, actorURL string) (Actor, error) {
+	actor := Actor{}
+	if err := getJSON(ctx, client, actorURL, ContentType, &actor); err != nil {
+		return Actor{}, err
+	}
+	if actor.ID != actorURL {
+		return Actor{}, fmt.Errorf("actor %s claims id %s", actorURL, actor.ID)
+	}
+	if actor.Inbox == "" || actor.PublicKey.PublicKeyPem == "" {
+		return Actor{}, fmt.Errorf("actor %s has no inbox or key", actorURL)
+	}
+	return actor, nil
+}
+
+// LookupActor - resolves user@host to an actor URL through WebFinger; scheme is how the host is
+// reached, "https" outside of local testing
+func LookupActor(ctx context.Context, client *http.Client, scheme, user, host string) (string, error) {
+	endpoint := url.URL{
+		Scheme:   scheme,
+		Host:     host,
+		Path:     "/.well-known/webfinger",
+		RawQuery: url.Values{"resource": {"acct:" + user + "@" + host}}.Encode(),
+	}
+	finger := WebFinger{}
+	if err := getJSON(ctx, client, endpoint.String(), JRDContentType, &finger); err != nil {
+		return "", err
+	}
+	actorURL := finger.ActorURL()
+	if actorURL == "" {
+		return "", fmt.Errorf("webfinger for %s@%s has no actor", user, host)
+	}
+	return actorURL, nil
+}
+
+// Deliver - POSTs activity to inbox signed with key; any 2xx is success
+func Deliver(ctx context.Context, client *http.Client, inbox string, activity []byte, keyID string, key *rsa.PrivateKey) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(activity))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	if err := Sign(req, keyID, key, activity); err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDocumentSize))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("inbox %s answered %s", inbox, resp.Status)
+	}
+	return nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, target, accept string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", accept)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %s", target, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxDocumentSize)).Decode(v)
+}
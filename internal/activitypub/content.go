@@ -0,0 +1,44 @@
+package activitypub
+
+import (
+	"html"
+	"strings"
+)
+
+// Content - a chirp's plain text as the HTML notes carry: escaped, one paragraph, line breaks kept
+func Content(body string) string {
+	escaped := html.EscapeString(body)
+	return "<p>" + strings.ReplaceAll(escaped, "\n", "<br>") + "</p>"
+}
+
+// PlainText - the text of a note's HTML content: tags dropped, <br> and paragraph breaks kept
+// as newlines, entities decoded
+func PlainText(content string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(content, '<')
+		if start < 0 {
+			b.WriteString(content)
+			break
+		}
+		b.WriteString(content[:start])
+		end := strings.IndexByte(content[start:], '>')
+		if end < 0 {
+			break
+		}
+		switch tagName(content[start+1 : start+end]) {
+		case "br":
+			b.WriteString("\n")
+		case "/p":
+			b.WriteString("\n\n")
+		}
+		content = content[start+end+1:]
+	}
+	return strings.TrimSpace(html.UnescapeString(b.String()))
+}
+
+// tagName - "br" for "br/", "BR class=x"; "/p" for a closing p
+func tagName(tag string) string {
+	name, _, _ := strings.Cut(strings.TrimSpace(tag), " ")
+	return strings.ToLower(strings.TrimSuffix(name, "/"))
+}
@@ -0,0 +1,48 @@
+package activitypub
+
+import (
+	"context"
+	"log"
+)
+
+// DefaultQueueSize - jobs buffered before Enqueue starts dropping them
+const DefaultQueueSize = 1024
+
+// Job - builds activities and delivers them; errors are only logged
+type Job func(ctx context.Context) error
+
+// Queue - runs deliveries on a single background worker, so a slow or unreachable remote
+// instance never holds up the request that caused the delivery
+type Queue struct {
+	jobs chan Job
+}
+
+// NewQueue -
+func NewQueue(size int) *Queue {
+	return &Queue{jobs: make(chan Job, size)}
+}
+
+// Enqueue - never blocks; when the queue is full the job is dropped and false returned,
+// federation being best effort
+func (q *Queue) Enqueue(job Job) bool {
+	select {
+	case q.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run - runs jobs one at a time until ctx is done
+func (q *Queue) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-q.jobs:
+			if err := job(ctx); err != nil {
+				log.Printf("activitypub: delivery failed %s\n", err)
+			}
+		}
+	}
+}
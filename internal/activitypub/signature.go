@@ -0,0 +1,236 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// HTTP Signatures as Mastodon and most of the fediverse use them:
+// draft-cavage-http-signatures-12 with rsa-sha256, plus a SHA-256 Digest of the body.
+
+// MaxClockSkew - how far a signed request's Date may be from now
+const MaxClockSkew = time.Hour
+
+const keyBits = 2048
+
+var (
+	ErrNoSignature      = errors.New("request is not signed")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrDigestMismatch   = errors.New("digest does not match body")
+	ErrStaleDate        = errors.New("signed date is too far from now")
+	ErrInvalidKey       = errors.New("invalid key")
+)
+
+// signedHeaders - a POST signs its body through the digest, a GET has none
+var (
+	signedHeadersPost = []string{"(request-target)", "host", "date", "digest"}
+	signedHeadersGet  = []string{"(request-target)", "host", "date"}
+)
+
+// GenerateKey - a new key pair as PEM: PKCS#8 private, PKIX public
+func GenerateKey() (privatePEM, publicPEM string, err error) {
+	key, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		return "", "", err
+	}
+	private, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: private})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public})), nil
+}
+
+// ParsePrivateKey - PKCS#8 or PKCS#1
+func ParsePrivateKey(s string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, ErrInvalidKey
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	return rsaKey, nil
+}
+
+// ParsePublicKey - PKIX or PKCS#1
+func ParsePublicKey(s string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, ErrInvalidKey
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	return rsaKey, nil
+}
+
+// Digest - the Digest header value for body
+func Digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Sign - sets Date, Digest (when body is not nil) and Signature on an outgoing request
+func Sign(r *http.Request, keyID string, key *rsa.PrivateKey, body []byte) error {
+	r.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	headers := signedHeadersGet
+	if body != nil {
+		r.Header.Set("Digest", Digest(body))
+		headers = signedHeadersPost
+	}
+
+	hashed := sha256.Sum256([]byte(signingString(r, headers)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return err
+	}
+
+	r.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyID, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(signature)))
+	return nil
+}
+
+// Signature - a parsed Signature header
+type Signature struct {
+	KeyID     string
+	Algorithm string
+	Headers   []string
+	Value     []byte
+}
+
+// ParseSignature - reads the Signature header of an incoming request
+func ParseSignature(h http.Header) (Signature, error) {
+	header := h.Get("Signature")
+	if header == "" {
+		return Signature{}, ErrNoSignature
+	}
+
+	sig := Signature{Headers: []string{"date"}}
+	for _, param := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok {
+			return Signature{}, ErrInvalidSignature
+		}
+		value = strings.Trim(value, `"`)
+		switch name {
+		case "keyId":
+			sig.KeyID = value
+		case "algorithm":
+			sig.Algorithm = value
+		case "headers":
+			sig.Headers = strings.Fields(strings.ToLower(value))
+		case "signature":
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return Signature{}, ErrInvalidSignature
+			}
+			sig.Value = decoded
+		}
+	}
+
+	if sig.KeyID == "" || len(sig.Value) == 0 {
+		return Signature{}, ErrInvalidSignature
+	}
+	//* hs2019 is what newer servers send for the same RSA signature
+	if sig.Algorithm != "" && sig.Algorithm != "rsa-sha256" && sig.Algorithm != "hs2019" {
+		return Signature{}, ErrInvalidSignature
+	}
+	return sig, nil
+}
+
+// Verify - the signature covers the request target, host and date, and the digest when the
+// request has a body, and was made with key. It does not check the digest against the body,
+// see VerifyDigest.
+func (s Signature) Verify(r *http.Request, key *rsa.PublicKey, now time.Time) error {
+	required := signedHeadersGet
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		required = signedHeadersPost
+	}
+	for _, header := range required {
+		if !slices.Contains(s.Headers, header) {
+			return ErrInvalidSignature
+		}
+	}
+
+	if err := VerifyDate(r.Header, now); err != nil {
+		return err
+	}
+
+	hashed := sha256.Sum256([]byte(signingString(r, s.Headers)))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], s.Value); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// VerifyDate - the Date header is within MaxClockSkew of now; it needs no key, so a stale request
+// can be turned away before its actor is fetched
+func VerifyDate(h http.Header, now time.Time) error {
+	date, err := http.ParseTime(h.Get("Date"))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if skew := now.Sub(date); skew > MaxClockSkew || skew < -MaxClockSkew {
+		return ErrStaleDate
+	}
+	return nil
+}
+
+// VerifyDigest - the Digest header matches body
+func VerifyDigest(h http.Header, body []byte) error {
+	if h.Get("Digest") != Digest(body) {
+		return ErrDigestMismatch
+	}
+	return nil
+}
+
+func signingString(r *http.Request, headers []string) string {
+	lines := make([]string, len(headers))
+	for i, header := range headers {
+		switch header {
+		case "(request-target)":
+			lines[i] = fmt.Sprintf("(request-target): %s %s", strings.ToLower(r.Method), r.URL.RequestURI())
+		case "host":
+			host := r.Host
+			if host == "" {
+				host = r.URL.Host
+			}
+			lines[i] = "host: " + host
+		default:
+			lines[i] = header + ": " + strings.Join(r.Header.Values(header), ", ")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
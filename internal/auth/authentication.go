@@ -86,32 +86,57 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 
 // ValidateJWT -
 func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+	id, _, err := parseAccessToken(tokenString, tokenSecret)
+	return id, err
+}
+
+// ValidateJWTExpiry - ValidateJWT that also returns when the token expires, for connections
+// that outlive it; a token without an expiry would keep such a connection open forever, so it
+// is refused here, while ValidateJWT accepts it as it always has
+func ValidateJWTExpiry(tokenString, tokenSecret string) (uuid.UUID, time.Time, error) {
+	id, expiresAt, err := parseAccessToken(tokenString, tokenSecret)
+	if err != nil {
+		return uuid.Nil, time.Time{}, err
+	}
+	if expiresAt == nil {
+		return uuid.Nil, time.Time{}, errors.New("token has no expiry")
+	}
+	return id, expiresAt.Time, nil
+}
+
+// parseAccessToken - the user and the expiry, nil when the token has none, of a valid access token
+func parseAccessToken(tokenString, tokenSecret string) (uuid.UUID, *jwt.NumericDate, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &chirpyClaims{}, func(t *jwt.Token) (interface{}, error) {
 		return []byte(tokenSecret), nil
 	})
 
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, nil, err
 	}
 
 	userIDString, err := token.Claims.GetSubject()
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, nil, err
 	}
 
 	issuer, err := token.Claims.GetIssuer()
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, nil, err
 	}
 	if issuer != string(TokenTypeAccess) {
-		return uuid.Nil, errors.New("invalid issuer")
+		return uuid.Nil, nil, errors.New("invalid issuer")
+	}
+
+	expiresAt, err := token.Claims.GetExpirationTime()
+	if err != nil {
+		return uuid.Nil, nil, err
 	}
 
 	id, err := uuid.Parse(userIDString)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid user ID: %w", err)
+		return uuid.Nil, nil, fmt.Errorf("invalid user ID: %w", err)
 	}
-	return id, nil
+	return id, expiresAt, nil
 }
 
 // MakeRefreshToken -
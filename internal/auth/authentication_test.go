@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
@@ -63,6 +64,15 @@ func TestCheckPasswordHash(t *testing.T) {
 	}
 }
 
+// makeJWTWithoutExpiry - an access token the way older clients were handed them, with no exp
+func makeJWTWithoutExpiry(userID uuid.UUID, tokenSecret string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, chirpyClaims{
+		jwt.RegisteredClaims{Issuer: string(TokenTypeAccess), Subject: userID.String()},
+	})
+	tokenString, _ := token.SignedString([]byte(tokenSecret))
+	return tokenString
+}
+
 func TestValidateJWT(t *testing.T) {
 	userID := uuid.New()
 	validToken, _ := MakeJWT(userID, "secret", time.Hour)
@@ -95,6 +105,13 @@ func TestValidateJWT(t *testing.T) {
 			wantUserID:  uuid.Nil,
 			wantErr:     true,
 		},
+		{
+			name:        "Token without expiry",
+			tokenString: makeJWTWithoutExpiry(userID, "secret"),
+			tokenSecret: "secret",
+			wantUserID:  userID,
+			wantErr:     false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -111,6 +128,56 @@ func TestValidateJWT(t *testing.T) {
 	}
 }
 
+func TestValidateJWTExpiry(t *testing.T) {
+	userID := uuid.New()
+	before := time.Now().Add(time.Hour).Truncate(time.Second)
+	validToken, _ := MakeJWT(userID, "secret", time.Hour)
+	after := time.Now().Add(time.Hour)
+	expiredToken, _ := MakeJWT(userID, "secret", -time.Minute)
+
+	tests := []struct {
+		name        string
+		tokenString string
+		wantUserID  uuid.UUID
+		wantErr     bool
+	}{
+		{
+			name:        "Valid token",
+			tokenString: validToken,
+			wantUserID:  userID,
+			wantErr:     false,
+		},
+		{
+			name:        "Expired token",
+			tokenString: expiredToken,
+			wantUserID:  uuid.Nil,
+			wantErr:     true,
+		},
+		{
+			name:        "Token without expiry",
+			tokenString: makeJWTWithoutExpiry(userID, "secret"),
+			wantUserID:  uuid.Nil,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUserID, gotExpiresAt, err := ValidateJWTExpiry(tt.tokenString, "secret")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateJWTExpiry() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotUserID != tt.wantUserID {
+				t.Errorf("ValidateJWTExpiry() gotUserID = %v, want %v", gotUserID, tt.wantUserID)
+			}
+			if !tt.wantErr && (gotExpiresAt.Before(before) || gotExpiresAt.After(after)) {
+				t.Errorf("ValidateJWTExpiry() gotExpiresAt = %v, want between %v and %v", gotExpiresAt, before, after)
+			}
+		})
+	}
+}
+
 func TestGetBearerToken(t *testing.T) {
 	tests := []struct {
 		name      string
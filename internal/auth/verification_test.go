@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParseVerificationToken(t *testing.T) {
+	id := uuid.New()
+	token := MakeVerificationToken(id, "secret")
+	idString, mac, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		secret  string
+		wantID  uuid.UUID
+		wantErr bool
+	}{
+		{
+			name:   "Valid token",
+			token:  token,
+			secret: "secret",
+			wantID: id,
+		},
+		{
+			name:    "Wrong secret",
+			token:   token,
+			secret:  "other",
+			wantErr: true,
+		},
+		{
+			name:    "MAC of another id",
+			token:   uuid.New().String() + "." + mac,
+			secret:  "secret",
+			wantErr: true,
+		},
+		{
+			name:    "No MAC",
+			token:   idString,
+			secret:  "secret",
+			wantErr: true,
+		},
+		{
+			name:    "Not an id",
+			token:   "nope." + mac,
+			secret:  "secret",
+			wantErr: true,
+		},
+		{
+			name:    "Empty",
+			token:   "",
+			secret:  "secret",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotID, err := ParseVerificationToken(tt.token, tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseVerificationToken() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotID != tt.wantID {
+				t.Errorf("ParseVerificationToken() gotID = %v, want %v", gotID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestVerificationPolicyCanChirp(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		policy    VerificationPolicy
+		createdAt time.Time
+		verified  bool
+		want      bool
+	}{
+		{
+			name:      "Off",
+			policy:    VerificationPolicy{Mode: VerificationOff},
+			createdAt: now.Add(-48 * time.Hour),
+			want:      true,
+		},
+		{
+			name:      "Required and verified",
+			policy:    VerificationPolicy{Mode: VerificationRequired},
+			createdAt: now,
+			verified:  true,
+			want:      true,
+		},
+		{
+			name:      "Required and unverified",
+			policy:    VerificationPolicy{Mode: VerificationRequired, Grace: time.Hour},
+			createdAt: now,
+			want:      false,
+		},
+		{
+			name:      "Within grace",
+			policy:    VerificationPolicy{Mode: VerificationGrace, Grace: 24 * time.Hour},
+			createdAt: now.Add(-23 * time.Hour),
+			want:      true,
+		},
+		{
+			name:      "Grace over",
+			policy:    VerificationPolicy{Mode: VerificationGrace, Grace: 24 * time.Hour},
+			createdAt: now.Add(-24 * time.Hour),
+			want:      false,
+		},
+		{
+			name:      "Grace over but verified",
+			policy:    VerificationPolicy{Mode: VerificationGrace, Grace: 24 * time.Hour},
+			createdAt: now.Add(-48 * time.Hour),
+			verified:  true,
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.CanChirp(tt.createdAt, tt.verified, now); got != tt.want {
+				t.Errorf("CanChirp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerificationPolicyCutoff(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		policy     VerificationPolicy
+		wantCutoff time.Time
+		wantOK     bool
+	}{
+		{name: "Off", policy: VerificationPolicy{Mode: VerificationOff, Grace: time.Hour}},
+		{name: "Grace", policy: VerificationPolicy{Mode: VerificationGrace, Grace: 24 * time.Hour}, wantCutoff: now.Add(-24 * time.Hour), wantOK: true},
+		{name: "Required", policy: VerificationPolicy{Mode: VerificationRequired, Grace: time.Hour}, wantCutoff: now, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cutoff, ok := tt.policy.Cutoff(now)
+			if ok != tt.wantOK || !cutoff.Equal(tt.wantCutoff) {
+				t.Fatalf("Cutoff() = %v, %v, want %v, %v", cutoff, ok, tt.wantCutoff, tt.wantOK)
+			}
+			//* agrees with CanChirp for an unverified account on either side of the cutoff
+			for _, createdAt := range []time.Time{now.Add(-48 * time.Hour), now.Add(-time.Hour), now} {
+				held := ok && !createdAt.After(cutoff)
+				if canChirp := tt.policy.CanChirp(createdAt, false, now); canChirp == held {
+					t.Errorf("created %v: CanChirp() = %v, but Cutoff() holds = %v", createdAt, canChirp, held)
+				}
+			}
+		})
+	}
+}
+
+func TestParseVerificationMode(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    VerificationMode
+		wantErr bool
+	}{
+		{in: "off", want: VerificationOff},
+		{in: "grace", want: VerificationGrace},
+		{in: "required", want: VerificationRequired},
+		{in: "Required", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseVerificationMode(tt.in)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseVerificationMode(%q) = %v, %v, want %v, wantErr %v", tt.in, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package blob
+
+import (
+	"context"
+	"io"
+)
+
+// BlobStore - where uploaded media bytes live, chirps only keep the key
+type BlobStore interface {
+	// Put - stores size bytes from r under key
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Delete - removing a missing key is not an error
+	Delete(ctx context.Context, key string) error
+	// URL - address clients fetch the blob from; handed out only with a chirp the viewer may
+	// see, so it must not outlive that check for long
+	URL(key string) string
+}
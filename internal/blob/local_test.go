@@ -0,0 +1,98 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewLocalStore(root, "/media/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	data := []byte("png bytes")
+
+	tests := []struct {
+		name     string
+		key      string
+		wantPath string
+		wantURL  string
+	}{
+		{
+			name:     "Nested key",
+			key:      "chirps/a.png",
+			wantPath: filepath.Join(root, "chirps", "a.png"),
+			wantURL:  "/media/chirps/a.png",
+		},
+		{
+			name:     "Traversal stays under root",
+			key:      "../../etc/b.png",
+			wantPath: filepath.Join(root, "etc", "b.png"),
+			wantURL:  "/media/etc/b.png",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := store.Put(ctx, tt.key, bytes.NewReader(data), int64(len(data)), "image/png"); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			got, err := os.ReadFile(tt.wantPath)
+			if err != nil || !bytes.Equal(got, data) {
+				t.Errorf("Put() wrote %q, %v", got, err)
+			}
+			if url := store.URL(tt.key); url != tt.wantURL {
+				t.Errorf("URL() = %v, want %v", url, tt.wantURL)
+			}
+			if err := store.Delete(ctx, tt.key); err != nil {
+				t.Errorf("Delete() error = %v", err)
+			}
+			if err := store.Delete(ctx, tt.key); err != nil {
+				t.Errorf("Delete() missing key error = %v", err)
+			}
+		})
+	}
+}
+
+func TestFileSystem(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "chirps"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"chirps/a.png", "chirps/.upload-123"} {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte("png bytes"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server := http.FileServer(FileSystem(root))
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{name: "Blob", path: "/chirps/a.png", wantStatus: http.StatusOK},
+		{name: "Directory is not listed", path: "/chirps/", wantStatus: http.StatusNotFound},
+		{name: "Root is not listed", path: "/", wantStatus: http.StatusNotFound},
+		{name: "Temp file", path: "/chirps/.upload-123", wantStatus: http.StatusNotFound},
+		{name: "Missing", path: "/chirps/b.png", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("GET %s status = %v, want %v", tt.path, rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
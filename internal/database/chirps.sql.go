@@ -7,23 +7,82 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirps = `-- name: CreateChirps :one
-INSERT INTO chirps(id, created_at, updated_at, body, user_id)
-VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2)
-RETURNING id, created_at, updated_at, body, user_id
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, status, publish_at, visibility)
+SELECT new.id, NOW(), NOW(), $1::text, $2::uuid,
+    $3::uuid, COALESCE(parent.conversation_id, new.id),
+    $4::text, $5::timestamp, $6::text
+FROM (SELECT gen_random_uuid() AS id) AS new
+LEFT JOIN chirps AS parent ON parent.id = $3::uuid
+RETURNING id, created_at, updated_at, body, user_id, search_vector, revision_count, in_reply_to, conversation_id, deleted_at, like_count, rechirp_count, rechirp_of, status, publish_at, hidden_at, visibility
 `
 
 type CreateChirpsParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body       string
+	UserID     uuid.UUID
+	InReplyTo  uuid.NullUUID
+	Status     string
+	PublishAt  sql.NullTime
+	Visibility string
 }
 
+// a reply joins its parent's conversation, anything else starts a new one
 func (q *Queries) CreateChirps(ctx context.Context, arg CreateChirpsParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirps, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirps,
+		arg.Body,
+		arg.UserID,
+		arg.InReplyTo,
+		arg.Status,
+		arg.PublishAt,
+		arg.Visibility,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.RevisionCount,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpCount,
+		&i.RechirpOf,
+		&i.Status,
+		&i.PublishAt,
+		&i.HiddenAt,
+		&i.Visibility,
+	)
+	return i, err
+}
+
+const createRechirp = `-- name: CreateRechirp :one
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, conversation_id, rechirp_of)
+SELECT new.id, NOW(), NOW(), $1::text, $2::uuid,
+    new.id, $3::uuid
+FROM (SELECT gen_random_uuid() AS id) AS new
+ON CONFLICT (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL AND body = '' DO NOTHING
+RETURNING id, created_at, updated_at, body, user_id, search_vector, revision_count, in_reply_to, conversation_id, deleted_at, like_count, rechirp_count, rechirp_of, status, publish_at, hidden_at, visibility
+`
+
+type CreateRechirpParams struct {
+	Body      string
+	UserID    uuid.UUID
+	RechirpOf uuid.UUID
+}
+
+// a plain rechirp (empty body) is idempotent per user, a quote always creates a chirp
+func (q *Queries) CreateRechirp(ctx context.Context, arg CreateRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRechirp, arg.Body, arg.UserID, arg.RechirpOf)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -31,12 +90,35 @@ func (q *Queries) CreateChirps(ctx context.Context, arg CreateChirpsParams) (Chi
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.RevisionCount,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpCount,
+		&i.RechirpOf,
+		&i.Status,
+		&i.PublishAt,
+		&i.HiddenAt,
+		&i.Visibility,
 	)
 	return i, err
 }
 
 const deleteChirpByID = `-- name: DeleteChirpByID :exec
-DELETE FROM chirps WHERE id = $1 AND user_id = $2
+WITH tombstone AS (
+    UPDATE chirps
+    SET body = '', deleted_at = NOW(), updated_at = NOW()
+    WHERE chirps.id = $1 AND chirps.user_id = $2
+    AND (EXISTS (SELECT 1 FROM chirps AS reply WHERE reply.in_reply_to = $1)
+        OR EXISTS (SELECT 1 FROM chirps AS quote WHERE quote.rechirp_of = $1 AND quote.body <> '')
+        OR EXISTS (SELECT 1 FROM bookmarks WHERE bookmarks.chirp_id = $1))
+    RETURNING chirps.id
+)
+DELETE FROM chirps
+WHERE chirps.id = $1 AND chirps.user_id = $2
+AND NOT EXISTS (SELECT 1 FROM tombstone)
 `
 
 type DeleteChirpByIDParams struct {
@@ -44,13 +126,50 @@ type DeleteChirpByIDParams struct {
 	UserID uuid.UUID
 }
 
+// chirps with replies, quotes or bookmarks become tombstones so the thread below them,
+// the quotes and the bookmarks survive
 func (q *Queries) DeleteChirpByID(ctx context.Context, arg DeleteChirpByIDParams) error {
 	_, err := q.db.ExecContext(ctx, deleteChirpByID, arg.ID, arg.UserID)
 	return err
 }
 
+const deleteRechirp = `-- name: DeleteRechirp :execrows
+DELETE FROM chirps WHERE user_id = $1 AND rechirp_of = $2 AND body = ''
+`
+
+type DeleteRechirpParams struct {
+	UserID    uuid.UUID
+	RechirpOf uuid.NullUUID
+}
+
+func (q *Queries) DeleteRechirp(ctx context.Context, arg DeleteRechirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteRechirp, arg.UserID, arg.RechirpOf)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRechirpsOf = `-- name: DeleteRechirpsOf :exec
+DELETE FROM chirps AS rechirp
+USING chirps AS original
+WHERE rechirp.rechirp_of = original.id AND original.id = $1 AND original.user_id = $2
+AND rechirp.body = '' AND rechirp.deleted_at IS NULL
+`
+
+type DeleteRechirpsOfParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+// the plain rechirps of a chirp its author is deleting; tombstones of deleted quotes keep their place
+func (q *Queries) DeleteRechirpsOf(ctx context.Context, arg DeleteRechirpsOfParams) error {
+	_, err := q.db.ExecContext(ctx, deleteRechirpsOf, arg.ID, arg.UserID)
+	return err
+}
+
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE id = $1
+SELECT id, created_at, updated_at, body, user_id, search_vector, revision_count, in_reply_to, conversation_id, deleted_at, like_count, rechirp_count, rechirp_of, status, publish_at, hidden_at, visibility FROM chirps WHERE id = $1
 `
 
 func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -62,16 +181,198 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.RevisionCount,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpCount,
+		&i.RechirpOf,
+		&i.Status,
+		&i.PublishAt,
+		&i.HiddenAt,
+		&i.Visibility,
 	)
 	return i, err
 }
 
+const getChirpAncestors = `-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors(id, in_reply_to, depth) AS (
+    SELECT c.id, c.in_reply_to, 0 FROM chirps AS c WHERE c.id = $1
+    UNION ALL
+    SELECT p.id, p.in_reply_to, a.depth + 1
+    FROM chirps AS p
+    JOIN ancestors AS a ON p.id = a.in_reply_to
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.revision_count, chirps.in_reply_to, chirps.conversation_id, chirps.deleted_at, chirps.like_count, chirps.rechirp_count, chirps.rechirp_of, chirps.status, chirps.publish_at, chirps.hidden_at, chirps.visibility FROM chirps
+JOIN ancestors ON chirps.id = ancestors.id
+WHERE ancestors.depth > 0
+ORDER BY ancestors.depth DESC
+`
+
+type GetChirpAncestorsRow struct {
+	Chirp Chirp
+}
+
+// walks in_reply_to up to the conversation root, root first
+func (q *Queries) GetChirpAncestors(ctx context.Context, id uuid.UUID) ([]GetChirpAncestorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAncestors, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpAncestorsRow
+	for rows.Next() {
+		var i GetChirpAncestorsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.SearchVector,
+			&i.Chirp.RevisionCount,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.ConversationID,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.LikeCount,
+			&i.Chirp.RechirpCount,
+			&i.Chirp.RechirpOf,
+			&i.Chirp.Status,
+			&i.Chirp.PublishAt,
+			&i.Chirp.HiddenAt,
+			&i.Chirp.Visibility,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpAudience = `-- name: GetChirpAudience :one
+SELECT
+    EXISTS (SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2)::bool AS is_follower,
+    EXISTS (SELECT 1 FROM chirp_mentions WHERE chirp_id = $3 AND user_id = $1)::bool AS is_recipient
+`
+
+type GetChirpAudienceParams struct {
+	ViewerID uuid.UUID
+	AuthorID uuid.UUID
+	ChirpID  uuid.UUID
+}
+
+type GetChirpAudienceRow struct {
+	IsFollower  bool
+	IsRecipient bool
+}
+
+// how the viewer relates to a chirp, for the visibility levels that depend on it
+func (q *Queries) GetChirpAudience(ctx context.Context, arg GetChirpAudienceParams) (GetChirpAudienceRow, error) {
+	row := q.db.QueryRowContext(ctx, getChirpAudience, arg.ViewerID, arg.AuthorID, arg.ChirpID)
+	var i GetChirpAudienceRow
+	err := row.Scan(&i.IsFollower, &i.IsRecipient)
+	return i, err
+}
+
+const getChirpDescendants = `-- name: GetChirpDescendants :many
+WITH RECURSIVE tree(id, depth) AS (
+    SELECT c.id, 1 FROM chirps AS c WHERE c.in_reply_to = ANY($1::uuid[]) AND c.status = 'published' AND c.hidden_at IS NULL
+    AND chirp_visible_to(c.id, c.user_id, c.visibility, $2)
+    AND NOT blocked_between(c.user_id, $2)
+    UNION ALL
+    SELECT c.id, t.depth + 1
+    FROM chirps AS c
+    JOIN tree AS t ON c.in_reply_to = t.id
+    WHERE t.depth < $3::int AND c.status = 'published' AND c.hidden_at IS NULL
+    AND chirp_visible_to(c.id, c.user_id, c.visibility, $2)
+    AND NOT blocked_between(c.user_id, $2)
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.revision_count, chirps.in_reply_to, chirps.conversation_id, chirps.deleted_at, chirps.like_count, chirps.rechirp_count, chirps.rechirp_of, chirps.status, chirps.publish_at, chirps.hidden_at, chirps.visibility, tree.depth::int AS depth FROM chirps
+JOIN tree ON chirps.id = tree.id
+ORDER BY tree.depth, chirps.created_at, chirps.id
+LIMIT $4
+`
+
+type GetChirpDescendantsParams struct {
+	ParentIds []uuid.UUID
+	ViewerID  uuid.NullUUID
+	MaxDepth  int32
+	MaxNodes  int32
+}
+
+type GetChirpDescendantsRow struct {
+	Chirp Chirp
+	Depth int32
+}
+
+// replies below the given parents, at most max_depth levels and max_nodes rows;
+// a reply the viewer may not see takes its whole subtree with it
+func (q *Queries) GetChirpDescendants(ctx context.Context, arg GetChirpDescendantsParams) ([]GetChirpDescendantsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpDescendants,
+		pq.Array(arg.ParentIds),
+		arg.ViewerID,
+		arg.MaxDepth,
+		arg.MaxNodes,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpDescendantsRow
+	for rows.Next() {
+		var i GetChirpDescendantsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.SearchVector,
+			&i.Chirp.RevisionCount,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.ConversationID,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.LikeCount,
+			&i.Chirp.RechirpCount,
+			&i.Chirp.RechirpOf,
+			&i.Chirp.Status,
+			&i.Chirp.PublishAt,
+			&i.Chirp.HiddenAt,
+			&i.Chirp.Visibility,
+			&i.Depth,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps ORDER BY created_at
+SELECT id, created_at, updated_at, body, user_id, search_vector, revision_count, in_reply_to, conversation_id, deleted_at, like_count, rechirp_count, rechirp_of, status, publish_at, hidden_at, visibility FROM chirps
+WHERE deleted_at IS NULL AND status = 'published' AND hidden_at IS NULL
+AND NOT blocked_between(chirps.user_id, $1)
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $1)
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = $1 AND muted_id = chirps.user_id)
+ORDER BY created_at
 `
 
-func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps)
+func (q *Queries) GetChirps(ctx context.Context, viewerID uuid.NullUUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirps, viewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +386,18 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.RevisionCount,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpCount,
+			&i.RechirpOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.HiddenAt,
+			&i.Visibility,
 		); err != nil {
 			return nil, err
 		}
@@ -100,11 +413,21 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpsByUserID = `-- name: GetChirpsByUserID :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE user_id = $1 ORDER BY created_at
+SELECT id, created_at, updated_at, body, user_id, search_vector, revision_count, in_reply_to, conversation_id, deleted_at, like_count, rechirp_count, rechirp_of, status, publish_at, hidden_at, visibility FROM chirps
+WHERE user_id = $1 AND deleted_at IS NULL AND status = 'published' AND hidden_at IS NULL
+AND NOT blocked_between(chirps.user_id, $2)
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $2)
+ORDER BY created_at
 `
 
-func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByUserID, userID)
+type GetChirpsByUserIDParams struct {
+	UserID   uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+// asking for one author's chirps by name goes past a mute, not past a block
+func (q *Queries) GetChirpsByUserID(ctx context.Context, arg GetChirpsByUserIDParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByUserID, arg.UserID, arg.ViewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -118,6 +441,18 @@ func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Ch
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.RevisionCount,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpCount,
+			&i.RechirpOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.HiddenAt,
+			&i.Visibility,
 		); err != nil {
 			return nil, err
 		}
@@ -131,3 +466,634 @@ func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Ch
 	}
 	return items, nil
 }
+
+const getLatestChirpsByUserID = `-- name: GetLatestChirpsByUserID :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, revision_count, in_reply_to, conversation_id, deleted_at, like_count, rechirp_count, rechirp_of, status, publish_at, hidden_at, visibility FROM chirps
+WHERE user_id = $1 AND deleted_at IS NULL AND status = 'published' AND hidden_at IS NULL
+AND (rechirp_of IS NULL OR body <> '')
+AND NOT blocked_between(chirps.user_id, $2)
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $2)
+ORDER BY created_at DESC, id DESC
+LIMIT $3
+`
+
+type GetLatestChirpsByUserIDParams struct {
+	UserID    uuid.UUID
+	ViewerID  uuid.NullUUID
+	PageLimit int32
+}
+
+// an author's newest chirps for feeds, plain rechirps left out as they have no text of their own
+func (q *Queries) GetLatestChirpsByUserID(ctx context.Context, arg GetLatestChirpsByUserIDParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getLatestChirpsByUserID, arg.UserID, arg.ViewerID, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.RevisionCount,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpCount,
+			&i.RechirpOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.HiddenAt,
+			&i.Visibility,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hideChirp = `-- name: HideChirp :execrows
+UPDATE chirps SET hidden_at = NOW() WHERE id = $1 AND hidden_at IS NULL
+`
+
+func (q *Queries) HideChirp(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, hideChirp, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listChirpReplies = `-- name: ListChirpReplies :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, revision_count, in_reply_to, conversation_id, deleted_at, like_count, rechirp_count, rechirp_of, status, publish_at, hidden_at, visibility FROM chirps
+WHERE in_reply_to = $1
+AND status = 'published'
+AND hidden_at IS NULL
+AND NOT blocked_between(chirps.user_id, $2)
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $2)
+AND ($3::timestamp IS NULL
+    OR (created_at, id) > ($3::timestamp, $4::uuid))
+ORDER BY created_at, id
+LIMIT $5
+`
+
+type ListChirpRepliesParams struct {
+	ParentID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+func (q *Queries) ListChirpReplies(ctx context.Context, arg ListChirpRepliesParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpReplies,
+		arg.ParentID,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.RevisionCount,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpCount,
+			&i.RechirpOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.HiddenAt,
+			&i.Visibility,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsAsc = `-- name: ListChirpsAsc :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, revision_count, in_reply_to, conversation_id, deleted_at, like_count, rechirp_count, rechirp_of, status, publish_at, hidden_at, visibility FROM chirps
+WHERE deleted_at IS NULL
+AND status = 'published'
+AND ($1::uuid IS NULL OR user_id = $1)
+AND (hidden_at IS NULL OR user_id = $2 OR $3::bool)
+AND NOT blocked_between(chirps.user_id, $2)
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $2)
+AND ($1::uuid IS NOT NULL
+    OR NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = $2 AND muted_id = chirps.user_id))
+AND ($4::timestamp IS NULL
+    OR (created_at, id) > ($4::timestamp, $5::uuid))
+ORDER BY created_at, id
+LIMIT $6
+`
+
+type ListChirpsAscParams struct {
+	AuthorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	IncludeHidden   bool
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+// hidden chirps are only listed for their author and for admins (include_hidden),
+// mutes apply unless the list is already narrowed to one author
+func (q *Queries) ListChirpsAsc(ctx context.Context, arg ListChirpsAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsAsc,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.IncludeHidden,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.RevisionCount,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpCount,
+			&i.RechirpOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.HiddenAt,
+			&i.Visibility,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsByIDs = `-- name: ListChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, revision_count, in_reply_to, conversation_id, deleted_at, like_count, rechirp_count, rechirp_of, status, publish_at, hidden_at, visibility FROM chirps WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) ListChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.RevisionCount,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpCount,
+			&i.RechirpOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.HiddenAt,
+			&i.Visibility,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsDesc = `-- name: ListChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, revision_count, in_reply_to, conversation_id, deleted_at, like_count, rechirp_count, rechirp_of, status, publish_at, hidden_at, visibility FROM chirps
+WHERE deleted_at IS NULL
+AND status = 'published'
+AND ($1::uuid IS NULL OR user_id = $1)
+AND (hidden_at IS NULL OR user_id = $2 OR $3::bool)
+AND NOT blocked_between(chirps.user_id, $2)
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $2)
+AND ($1::uuid IS NOT NULL
+    OR NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = $2 AND muted_id = chirps.user_id))
+AND ($4::timestamp IS NULL
+    OR (created_at, id) < ($4::timestamp, $5::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $6
+`
+
+type ListChirpsDescParams struct {
+	AuthorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	IncludeHidden   bool
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+// hidden chirps are only listed for their author and for admins (include_hidden),
+// mutes apply unless the list is already narrowed to one author
+func (q *Queries) ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDesc,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.IncludeHidden,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.RevisionCount,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpCount,
+			&i.RechirpOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.HiddenAt,
+			&i.Visibility,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnpublishedChirps = `-- name: ListUnpublishedChirps :many
+SELECT id, created_at, updated_at, body, user_id, search_vector, revision_count, in_reply_to, conversation_id, deleted_at, like_count, rechirp_count, rechirp_of, status, publish_at, hidden_at, visibility FROM chirps
+WHERE user_id = $1
+AND status <> 'published'
+AND deleted_at IS NULL
+AND ($2::text IS NULL OR status = $2)
+AND ($3::timestamp IS NULL
+    OR (created_at, id) < ($3::timestamp, $4::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type ListUnpublishedChirpsParams struct {
+	UserID          uuid.UUID
+	Status          sql.NullString
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+// drafts and scheduled chirps, only ever listed for their author
+func (q *Queries) ListUnpublishedChirps(ctx context.Context, arg ListUnpublishedChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listUnpublishedChirps,
+		arg.UserID,
+		arg.Status,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.SearchVector,
+			&i.RevisionCount,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpCount,
+			&i.RechirpOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.HiddenAt,
+			&i.Visibility,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const publishChirp = `-- name: PublishChirp :one
+WITH published AS (
+    UPDATE chirps
+    SET status = 'published', created_at = NOW(), updated_at = NOW()
+    WHERE chirps.id = $1 AND chirps.user_id = $2
+    AND chirps.status <> 'published' AND chirps.deleted_at IS NULL
+    RETURNING chirps.id, chirps.created_at
+), hashtags AS (
+    UPDATE chirp_hashtags SET created_at = published.created_at
+    FROM published WHERE chirp_hashtags.chirp_id = published.id
+), mentions AS (
+    UPDATE chirp_mentions SET created_at = published.created_at
+    FROM published WHERE chirp_mentions.chirp_id = published.id
+)
+SELECT id FROM published
+`
+
+type PublishChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+// publishing stamps created_at, so the chirp shows up at the top of timelines
+func (q *Queries) PublishChirp(ctx context.Context, arg PublishChirpParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, publishChirp, arg.ID, arg.UserID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const publishDueChirps = `-- name: PublishDueChirps :many
+WITH due AS (
+    SELECT id FROM chirps
+    WHERE status = 'scheduled' AND publish_at <= NOW() AND deleted_at IS NULL
+    -- chirps of suspended users wait, they go out if the suspension is lifted
+    AND user_id NOT IN (SELECT users.id FROM users WHERE suspended_at IS NOT NULL)
+    -- and so do those of users email verification keeps from chirping, until they verify
+    AND ($1::timestamp IS NULL
+        OR user_id NOT IN (SELECT users.id FROM users
+            WHERE email_verified_at IS NULL AND created_at <= $1::timestamp))
+    ORDER BY publish_at
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+), published AS (
+    -- stamped when it actually goes out, like PublishChirp: a chirp held back past publish_at
+    -- would otherwise be backdated below chirps that readers have already paged past
+    UPDATE chirps
+    SET status = 'published', created_at = NOW(), updated_at = NOW()
+    FROM due
+    WHERE chirps.id = due.id AND chirps.status = 'scheduled'
+    RETURNING chirps.id, chirps.created_at
+), hashtags AS (
+    UPDATE chirp_hashtags SET created_at = published.created_at
+    FROM published WHERE chirp_hashtags.chirp_id = published.id
+), mentions AS (
+    UPDATE chirp_mentions SET created_at = published.created_at
+    FROM published WHERE chirp_mentions.chirp_id = published.id
+)
+SELECT id FROM published
+`
+
+type PublishDueChirpsParams struct {
+	UnverifiedCutoff sql.NullTime
+	BatchSize        int32
+}
+
+// SKIP LOCKED lets every replica run the scheduler, each due chirp is claimed by exactly one of them
+func (q *Queries) PublishDueChirps(ctx context.Context, arg PublishDueChirpsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, publishDueChirps, arg.UnverifiedCutoff, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT c.id, c.created_at, c.updated_at, c.body, c.user_id, c.search_vector, c.revision_count, c.in_reply_to, c.conversation_id, c.deleted_at, c.like_count, c.rechirp_count, c.rechirp_of, c.status, c.publish_at, c.hidden_at, c.visibility,
+    ts_rank(c.search_vector, to_tsquery('english', $1))::real AS rank,
+    ts_headline('english', html_escape(c.body), to_tsquery('english', $1),
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2')::text AS snippet
+FROM chirps AS c
+WHERE c.search_vector @@ to_tsquery('english', $1)
+AND c.deleted_at IS NULL
+AND c.status = 'published'
+AND c.hidden_at IS NULL
+AND ($2::uuid IS NULL OR c.user_id = $2)
+AND NOT blocked_between(c.user_id, $3)
+AND chirp_visible_to(c.id, c.user_id, c.visibility, $3)
+AND ($2::uuid IS NOT NULL
+    OR NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = $3 AND muted_id = c.user_id))
+AND ($4::real IS NULL
+    OR (ts_rank(c.search_vector, to_tsquery('english', $1)), c.created_at, c.id)
+        < ($4::real, $5::timestamp, $6::uuid))
+ORDER BY rank DESC, c.created_at DESC, c.id DESC
+LIMIT $7
+`
+
+type SearchChirpsParams struct {
+	Query           string
+	AuthorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	CursorRank      sql.NullFloat64
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+type SearchChirpsRow struct {
+	Chirp   Chirp
+	Rank    float32
+	Snippet string
+}
+
+// snippet is HTML: the body is escaped first, so <mark> is the only markup in it
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.CursorRank,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.SearchVector,
+			&i.Chirp.RevisionCount,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.ConversationID,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.LikeCount,
+			&i.Chirp.RechirpCount,
+			&i.Chirp.RechirpOf,
+			&i.Chirp.Status,
+			&i.Chirp.PublishAt,
+			&i.Chirp.HiddenAt,
+			&i.Chirp.Visibility,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+WITH prev AS (
+    SELECT id, body, updated_at FROM chirps
+    WHERE chirps.id = $1 AND chirps.user_id = $2
+    FOR UPDATE
+), revision AS (
+    INSERT INTO chirp_revisions(id, chirp_id, body, created_at)
+    SELECT gen_random_uuid(), prev.id, prev.body, prev.updated_at FROM prev
+)
+UPDATE chirps
+SET body = $3,
+    updated_at = NOW(),
+    revision_count = chirps.revision_count + 1
+FROM prev
+WHERE chirps.id = prev.id
+RETURNING chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search_vector, chirps.revision_count, chirps.in_reply_to, chirps.conversation_id, chirps.deleted_at, chirps.like_count, chirps.rechirp_count, chirps.rechirp_of, chirps.status, chirps.publish_at, chirps.hidden_at, chirps.visibility
+`
+
+type UpdateChirpBodyParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Body   string
+}
+
+// keeps the previous body in chirp_revisions, stamped with the time it was written
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.ID, arg.UserID, arg.Body)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.SearchVector,
+		&i.RevisionCount,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpCount,
+		&i.RechirpOf,
+		&i.Status,
+		&i.PublishAt,
+		&i.HiddenAt,
+		&i.Visibility,
+	)
+	return i, err
+}
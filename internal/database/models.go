@@ -11,12 +11,208 @@ import (
 	"github.com/google/uuid"
 )
 
+type ActorKey struct {
+	UserID        uuid.UUID
+	PublicKeyPem  string
+	PrivateKeyPem string
+	CreatedAt     time.Time
+}
+
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Body           string
+	UserID         uuid.UUID
+	SearchVector   interface{}
+	RevisionCount  int32
+	InReplyTo      uuid.NullUUID
+	ConversationID uuid.UUID
+	DeletedAt      sql.NullTime
+	LikeCount      int32
+	RechirpCount   int32
+	RechirpOf      uuid.NullUUID
+	Status         string
+	PublishAt      sql.NullTime
+	HiddenAt       sql.NullTime
+	Visibility     string
+}
+
+type ChirpAttachment struct {
+	ID          uuid.UUID
+	ChirpID     uuid.UUID
+	Position    int32
+	BlobKey     string
+	ContentType string
+	SizeBytes   int64
+	CreatedAt   time.Time
+}
+
+type ChirpEvent struct {
+	ID        int64
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Kind      string
+	CreatedAt time.Time
+}
+
+type ChirpFlag struct {
+	ChirpID   uuid.UUID
+	Rule      string
+	Match     string
+	CreatedAt time.Time
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type ChirpLike struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpMention struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpPin struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	Position  int32
+	CreatedAt time.Time
+}
+
+type ChirpRevision struct {
+	ID        uuid.UUID
+	ChirpID   uuid.UUID
+	Body      string
+	CreatedAt time.Time
+}
+
+type Conversation struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+}
+
+type ConversationMember struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+}
+
+type EmailVerification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Email     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type List struct {
+	ID        uuid.UUID
+	OwnerID   uuid.UUID
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type ListMember struct {
+	ListID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type Message struct {
+	ID             uuid.UUID
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+	CreatedAt      time.Time
+}
+
+type ModerationAction struct {
+	ID        uuid.UUID
+	ReportID  uuid.UUID
+	AdminID   uuid.NullUUID
+	Action    string
+	Note      string
+	CreatedAt time.Time
+}
+
+type ModerationWord struct {
+	Word      string
+	Action    string
+	CreatedAt time.Time
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Kind      string
+	ChirpID   uuid.NullUUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ReadAt    sql.NullTime
+}
+
+type NotificationActor struct {
+	NotificationID uuid.UUID
+	ActorID        uuid.UUID
+	CreatedAt      time.Time
+}
+
+type Poll struct {
+	ChirpID   uuid.UUID
+	ClosesAt  time.Time
+	CreatedAt time.Time
+}
+
+type PollOption struct {
+	ID        uuid.UUID
+	ChirpID   uuid.UUID
+	Position  int32
+	Label     string
+	VoteCount int32
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
 	UserID    uuid.UUID
+	OptionID  uuid.UUID
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,11 +224,50 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type RemoteActor struct {
+	UserID         uuid.UUID
+	ActorUrl       string
+	InboxUrl       string
+	SharedInboxUrl sql.NullString
+	KeyID          string
+	PublicKeyPem   string
+	FetchedAt      time.Time
+}
+
+type RemoteChirp struct {
+	ChirpID   uuid.UUID
+	ObjectUrl string
+}
+
+type Report struct {
+	ID         uuid.UUID
+	ReporterID uuid.UUID
+	UserID     uuid.UUID
+	ChirpID    uuid.NullUUID
+	Reason     string
+	Details    string
+	Status     string
+	ClaimedBy  uuid.NullUUID
+	ClaimedAt  sql.NullTime
+	Resolution sql.NullString
+	ResolvedAt sql.NullTime
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
 type User struct {
-	ID             uuid.UUID
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword sql.NullString
-	IsChirpyRed    bool
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Email           sql.NullString
+	HashedPassword  sql.NullString
+	IsChirpyRed     bool
+	IsAdmin         bool
+	SuspendedAt     sql.NullTime
+	Handle          sql.NullString
+	DisplayName     string
+	Bio             string
+	AvatarUrl       string
+	EmailVerifiedAt sql.NullTime
+	ActorUri        sql.NullString
 }
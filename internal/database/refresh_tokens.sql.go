@@ -48,13 +48,15 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 }
 
 const getUserFromRefreshToken = `-- name: GetUserFromRefreshToken :one
-SELECT u.id, u.created_at, u.updated_at, u.email, u.hashed_password, u.is_chirpy_red FROM users AS u
+SELECT u.id, u.created_at, u.updated_at, u.email, u.hashed_password, u.is_chirpy_red, u.is_admin, u.suspended_at, u.handle, u.display_name, u.bio, u.avatar_url, u.email_verified_at, u.actor_uri FROM users AS u
 JOIN refresh_tokens AS rt ON u.id = rt.user_id
 WHERE rt.token = $1
 AND rt.revoked_at IS NULL
 AND rt.expires_at > NOW()
+AND u.suspended_at IS NULL
 `
 
+// suspended users keep their tokens but cannot refresh them
 func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (User, error) {
 	row := q.db.QueryRowContext(ctx, getUserFromRefreshToken, token)
 	var i User
@@ -65,6 +67,14 @@ func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (Us
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.SuspendedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.EmailVerifiedAt,
+		&i.ActorUri,
 	)
 	return i, err
 }
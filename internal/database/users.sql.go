@@ -14,8 +14,8 @@ import (
 
 const createUser = `-- name: CreateUser :one
 INSERT INTO users(id, created_at, updated_at, email, hashed_password)
-VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2)
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+VALUES (gen_random_uuid(), NOW(), NOW(), $1::text, $2)
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, suspended_at, handle, display_name, bio, avatar_url, email_verified_at, actor_uri
 `
 
 type CreateUserParams struct {
@@ -33,14 +33,23 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.SuspendedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.EmailVerifiedAt,
+		&i.ActorUri,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users WHERE email = $1
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, suspended_at, handle, display_name, bio, avatar_url, email_verified_at, actor_uri FROM users WHERE email = $1::text
 `
 
+// remote users have no email, only an actor_uri
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
 	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
 	var i User
@@ -51,6 +60,125 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.SuspendedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.EmailVerifiedAt,
+		&i.ActorUri,
+	)
+	return i, err
+}
+
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, suspended_at, handle, display_name, bio, avatar_url, email_verified_at, actor_uri FROM users WHERE LOWER(handle) = LOWER($1)
+`
+
+// handles are unique ignoring case, see users_handle_lower_idx
+func (q *Queries) GetUserByHandle(ctx context.Context, lower string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, lower)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.SuspendedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.EmailVerifiedAt,
+		&i.ActorUri,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, suspended_at, handle, display_name, bio, avatar_url, email_verified_at, actor_uri FROM users WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.SuspendedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.EmailVerifiedAt,
+		&i.ActorUri,
+	)
+	return i, err
+}
+
+const getUserStats = `-- name: GetUserStats :one
+SELECT
+    (SELECT COUNT(*) FROM chirps
+        WHERE chirps.user_id = $1 AND deleted_at IS NULL AND status = 'published' AND hidden_at IS NULL)::bigint AS chirp_count,
+    (SELECT COUNT(*) FROM follows WHERE followee_id = $1)::bigint AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follower_id = $1)::bigint AS following_count
+`
+
+type GetUserStatsRow struct {
+	ChirpCount     int64
+	FollowerCount  int64
+	FollowingCount int64
+}
+
+// the chirp count leaves out drafts, deleted and hidden chirps
+func (q *Queries) GetUserStats(ctx context.Context, userID uuid.UUID) (GetUserStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserStats, userID)
+	var i GetUserStatsRow
+	err := row.Scan(&i.ChirpCount, &i.FollowerCount, &i.FollowingCount)
+	return i, err
+}
+
+const markEmailVerified = `-- name: MarkEmailVerified :one
+UPDATE users
+SET email_verified_at = COALESCE(email_verified_at, NOW()),
+    updated_at = NOW()
+WHERE id = $1 AND email = $2::text
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, suspended_at, handle, display_name, bio, avatar_url, email_verified_at, actor_uri
+`
+
+type MarkEmailVerifiedParams struct {
+	ID    uuid.UUID
+	Email string
+}
+
+// only while the address is still the one the link was sent to
+func (q *Queries) MarkEmailVerified(ctx context.Context, arg MarkEmailVerifiedParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, markEmailVerified, arg.ID, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.SuspendedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.EmailVerifiedAt,
+		&i.ActorUri,
 	)
 	return i, err
 }
@@ -64,13 +192,29 @@ func (q *Queries) ResetUsers(ctx context.Context) error {
 	return err
 }
 
+const suspendUser = `-- name: SuspendUser :execrows
+UPDATE users
+SET suspended_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND suspended_at IS NULL
+`
+
+func (q *Queries) SuspendUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, suspendUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateUserEmailPassword = `-- name: UpdateUserEmailPassword :one
 UPDATE users
-SET email = $1,
+SET email = $1::text,
     hashed_password = $2,
+    email_verified_at = CASE WHEN email = $1::text THEN email_verified_at END,
     updated_at = NOW()
 WHERE id = $3
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, suspended_at, handle, display_name, bio, avatar_url, email_verified_at, actor_uri
 `
 
 type UpdateUserEmailPasswordParams struct {
@@ -79,6 +223,7 @@ type UpdateUserEmailPasswordParams struct {
 	ID             uuid.UUID
 }
 
+// a new address has to be verified again
 func (q *Queries) UpdateUserEmailPassword(ctx context.Context, arg UpdateUserEmailPasswordParams) (User, error) {
 	row := q.db.QueryRowContext(ctx, updateUserEmailPassword, arg.Email, arg.HashedPassword, arg.ID)
 	var i User
@@ -89,6 +234,93 @@ func (q *Queries) UpdateUserEmailPassword(ctx context.Context, arg UpdateUserEma
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.SuspendedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.EmailVerifiedAt,
+		&i.ActorUri,
+	)
+	return i, err
+}
+
+const updateUserHandle = `-- name: UpdateUserHandle :one
+UPDATE users
+SET handle = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, suspended_at, handle, display_name, bio, avatar_url, email_verified_at, actor_uri
+`
+
+type UpdateUserHandleParams struct {
+	Handle sql.NullString
+	ID     uuid.UUID
+}
+
+func (q *Queries) UpdateUserHandle(ctx context.Context, arg UpdateUserHandleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserHandle, arg.Handle, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.SuspendedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.EmailVerifiedAt,
+		&i.ActorUri,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET display_name = $1,
+    bio = $2,
+    avatar_url = $3,
+    updated_at = NOW()
+WHERE id = $4
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, suspended_at, handle, display_name, bio, avatar_url, email_verified_at, actor_uri
+`
+
+type UpdateUserProfileParams struct {
+	DisplayName string
+	Bio         string
+	AvatarUrl   string
+	ID          uuid.UUID
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.DisplayName,
+		arg.Bio,
+		arg.AvatarUrl,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.SuspendedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.EmailVerifiedAt,
+		&i.ActorUri,
 	)
 	return i, err
 }
@@ -0,0 +1,46 @@
+package entities
+
+import (
+	"regexp"
+	"strings"
+)
+
+// hashtags need at least one letter so "#1" or "#2024" stay plain text,
+// and URL fragments (example.com/#top) are not tags
+var hashtagRegex = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/])#([\p{L}\p{N}_]*\p{L}[\p{L}\p{N}_]*)`)
+
+// the leading class keeps emails like a@b.com from being read as mentions
+var mentionRegex = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@.])@([A-Za-z0-9_]{1,30})\b`)
+
+var handleRegex = regexp.MustCompile(`^[A-Za-z0-9_]{3,30}$`)
+
+// Hashtags - lowercased, de-duplicated tags in order of appearance, without '#'
+func Hashtags(body string) []string {
+	return collect(hashtagRegex, body)
+}
+
+// Mentions - lowercased, de-duplicated handles in order of appearance, without '@'
+func Mentions(body string) []string {
+	return collect(mentionRegex, body)
+}
+
+// IsValidHandle - 3 to 30 ASCII letters, digits or underscores
+func IsValidHandle(handle string) bool {
+	return handleRegex.MatchString(handle)
+}
+
+func collect(re *regexp.Regexp, body string) []string {
+	found := []string{}
+	seen := map[string]struct{}{}
+
+	for _, match := range re.FindAllStringSubmatch(body, -1) {
+		value := strings.ToLower(match[1])
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		found = append(found, value)
+	}
+
+	return found
+}
@@ -0,0 +1,74 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{name: "Single tag", body: "learning #golang today", want: []string{"golang"}},
+		{name: "Lowercased and de-duplicated", body: "#Go #go #GO", want: []string{"go"}},
+		{name: "Punctuation ends the tag", body: "love #bootdev! and (#chirpy)", want: []string{"bootdev", "chirpy"}},
+		{name: "Unicode tag", body: "#café au lait", want: []string{"café"}},
+		{name: "Numbers only is not a tag", body: "issue #1234", want: []string{}},
+		{name: "Anchor inside word is not a tag", body: "c#sharp and url.com/#section", want: []string{}},
+		{name: "No tags", body: "just a chirp", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Hashtags(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Hashtags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{name: "Single mention", body: "hi @alice", want: []string{"alice"}},
+		{name: "Lowercased and de-duplicated", body: "@Bob and @bob, @carol_1", want: []string{"bob", "carol_1"}},
+		{name: "Email is not a mention", body: "mail me at dev@chirpy.com", want: []string{}},
+		{name: "Start of body", body: "@alice: thanks", want: []string{"alice"}},
+		{name: "Double at", body: "@@alice", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Mentions(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Mentions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValidHandle(t *testing.T) {
+	tests := []struct {
+		handle string
+		want   bool
+	}{
+		{handle: "alice", want: true},
+		{handle: "Alice_99", want: true},
+		{handle: "al", want: false},
+		{handle: "has space", want: false},
+		{handle: "émile", want: false},
+		{handle: "this_handle_is_way_too_long_for_us", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.handle, func(t *testing.T) {
+			if got := IsValidHandle(tt.handle); got != tt.want {
+				t.Errorf("IsValidHandle(%q) = %v, want %v", tt.handle, got, tt.want)
+			}
+		})
+	}
+}
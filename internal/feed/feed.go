@@ -0,0 +1,114 @@
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// TitleLength - runes of a chirp's body used as its item title
+const TitleLength = 80
+
+// ErrUnknownFormat -
+var ErrUnknownFormat = errors.New("unknown feed format")
+
+// Format -
+type Format string
+
+const (
+	Atom Format = "atom"
+	RSS  Format = "rss"
+	JSON Format = "json"
+)
+
+// ContentType - the media type a feed in f is served as
+func (f Format) ContentType() string {
+	switch f {
+	case Atom:
+		return "application/atom+xml; charset=utf-8"
+	case RSS:
+		return "application/rss+xml; charset=utf-8"
+	default:
+		return "application/feed+json; charset=utf-8"
+	}
+}
+
+// Feed - one author's chirps, newest first; URLs are absolute
+type Feed struct {
+	// ID - stable across formats, so a reader switching formats sees the same feed
+	ID          string
+	Title       string
+	Description string
+	HomeURL     string
+	FeedURL     string
+	Author      string
+	Updated     time.Time
+	Items       []Item
+}
+
+// Item -
+type Item struct {
+	ID        string
+	URL       string
+	Title     string
+	Content   string
+	Published time.Time
+	Updated   time.Time
+}
+
+// Render -
+func Render(f Feed, format Format) ([]byte, error) {
+	switch format {
+	case Atom:
+		return renderAtom(f)
+	case RSS:
+		return renderRSS(f)
+	case JSON:
+		return renderJSON(f)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// Title - the first line of body, cut to TitleLength runes
+func Title(body string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(body), "\n")
+	line = strings.TrimSpace(line)
+	if utf8.RuneCountInString(line) <= TitleLength {
+		return line
+	}
+	runes := []rune(line)
+	return strings.TrimSpace(string(runes[:TitleLength-1])) + "…"
+}
+
+// ETag - a strong validator over the rendered feed, so any change to it, deletions included,
+// gives a new one
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// NotModified - whether the request's conditional headers say the client already has this
+// version. If-None-Match wins over If-Modified-Since when both are sent (RFC 9110 13.2.2).
+func NotModified(h http.Header, etag string, lastModified time.Time) bool {
+	if inm := h.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims, err := http.ParseTime(h.Get("If-Modified-Since"))
+	if err != nil || lastModified.IsZero() {
+		return false
+	}
+	//* HTTP dates have no sub-second part
+	return !lastModified.Truncate(time.Second).After(ims)
+}
@@ -0,0 +1,210 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFeed() Feed {
+	published := time.Date(2024, 12, 1, 10, 0, 0, 0, time.UTC)
+	return Feed{
+		ID:          "https://chirpy.example/api/users/alice",
+		Title:       "Alice",
+		Description: "chirps & more",
+		HomeURL:     "https://chirpy.example/api/users/alice",
+		FeedURL:     "https://chirpy.example/users/alice/feed.atom",
+		Author:      "Alice",
+		Updated:     published.Add(time.Hour),
+		Items: []Item{
+			{
+				ID:        "urn:uuid:6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+				URL:       "https://chirpy.example/api/chirps/6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+				Title:     "<b>hi</b>",
+				Content:   "<b>hi</b> & bye",
+				Published: published,
+				Updated:   published.Add(time.Hour),
+			},
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		check  func(t *testing.T, body []byte)
+	}{
+		{
+			name:   "Atom",
+			format: Atom,
+			check: func(t *testing.T, body []byte) {
+				var got atomFeed
+				if err := xml.Unmarshal(body, &got); err != nil {
+					t.Fatalf("xml.Unmarshal() error = %v", err)
+				}
+				if got.XMLName.Space != "http://www.w3.org/2005/Atom" {
+					t.Errorf("namespace = %q", got.XMLName.Space)
+				}
+				if len(got.Entries) != 1 || got.Entries[0].Content.Body != "<b>hi</b> & bye" {
+					t.Errorf("entries = %+v", got.Entries)
+				}
+				if got.Entries[0].Published != "2024-12-01T10:00:00Z" {
+					t.Errorf("published = %q", got.Entries[0].Published)
+				}
+			},
+		},
+		{
+			name:   "RSS",
+			format: RSS,
+			check: func(t *testing.T, body []byte) {
+				var got rssFeed
+				if err := xml.Unmarshal(body, &got); err != nil {
+					t.Fatalf("xml.Unmarshal() error = %v", err)
+				}
+				if got.Version != "2.0" || len(got.Channel.Items) != 1 {
+					t.Fatalf("rss = %+v", got)
+				}
+				if got.Channel.Items[0].PubDate != "Sun, 01 Dec 2024 10:00:00 +0000" {
+					t.Errorf("pubDate = %q", got.Channel.Items[0].PubDate)
+				}
+				if !strings.Contains(string(body), `<atom:link rel="self"`) {
+					t.Errorf("missing atom:link self in %s", body)
+				}
+			},
+		},
+		{
+			name:   "JSON",
+			format: JSON,
+			check: func(t *testing.T, body []byte) {
+				var got jsonFeed
+				if err := json.Unmarshal(body, &got); err != nil {
+					t.Fatalf("json.Unmarshal() error = %v", err)
+				}
+				if got.Version != jsonFeedVersion || len(got.Items) != 1 {
+					t.Fatalf("feed = %+v", got)
+				}
+				if got.Items[0].ContentText != "<b>hi</b> & bye" {
+					t.Errorf("content_text = %q", got.Items[0].ContentText)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := Render(testFeed(), tt.format)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			tt.check(t, body)
+		})
+	}
+
+	if _, err := Render(testFeed(), "yaml"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Render() error = %v, want %v", err, ErrUnknownFormat)
+	}
+}
+
+func TestTitle(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "Short",
+			body: "hello world",
+			want: "hello world",
+		},
+		{
+			name: "First line only",
+			body: "  first\nsecond",
+			want: "first",
+		},
+		{
+			name: "Cut",
+			body: strings.Repeat("é", TitleLength+5),
+			want: strings.Repeat("é", TitleLength-1) + "…",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Title(tt.body); got != tt.want {
+				t.Errorf("Title() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	lastModified := time.Date(2024, 12, 1, 10, 0, 0, 500, time.UTC)
+	etag := ETag([]byte("feed"))
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{
+			name:    "No conditions",
+			headers: nil,
+			want:    false,
+		},
+		{
+			name:    "Matching ETag",
+			headers: map[string]string{"If-None-Match": etag},
+			want:    true,
+		},
+		{
+			name:    "Matching weak ETag in a list",
+			headers: map[string]string{"If-None-Match": `"other", W/` + etag},
+			want:    true,
+		},
+		{
+			name:    "Stale ETag",
+			headers: map[string]string{"If-None-Match": ETag([]byte("old feed"))},
+			want:    false,
+		},
+		{
+			name: "Stale ETag wins over a fresh date",
+			headers: map[string]string{
+				"If-None-Match":     ETag([]byte("old feed")),
+				"If-Modified-Since": lastModified.Format(http.TimeFormat),
+			},
+			want: false,
+		},
+		{
+			name:    "Same second",
+			headers: map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)},
+			want:    true,
+		},
+		{
+			name:    "Modified since",
+			headers: map[string]string{"If-Modified-Since": lastModified.Add(-time.Minute).Format(http.TimeFormat)},
+			want:    false,
+		},
+		{
+			name:    "Bad date",
+			headers: map[string]string{"If-Modified-Since": "yesterday"},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.headers {
+				h.Set(k, v)
+			}
+			if got := NotModified(h, etag, lastModified); got != tt.want {
+				t.Errorf("NotModified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
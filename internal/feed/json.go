@@ -0,0 +1,55 @@
+package feed
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// jsonFeedVersion - https://www.jsonfeed.org/version/1.1/
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+type jsonFeed struct {
+	Version     string       `json:"version"`
+	Title       string       `json:"title"`
+	HomePageURL string       `json:"home_page_url"`
+	FeedURL     string       `json:"feed_url"`
+	Description string       `json:"description,omitempty"`
+	Authors     []jsonAuthor `json:"authors"`
+	Items       []jsonItem   `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// jsonItem - no title: JSON Feed leaves it out for microblog posts
+type jsonItem struct {
+	ID            string    `json:"id"`
+	URL           string    `json:"url"`
+	ContentText   string    `json:"content_text"`
+	DatePublished time.Time `json:"date_published"`
+	DateModified  time.Time `json:"date_modified"`
+}
+
+func renderJSON(f Feed) ([]byte, error) {
+	out := jsonFeed{
+		Version:     jsonFeedVersion,
+		Title:       f.Title,
+		HomePageURL: f.HomeURL,
+		FeedURL:     f.FeedURL,
+		Description: f.Description,
+		Authors:     []jsonAuthor{{Name: f.Author, URL: f.HomeURL}},
+		Items:       []jsonItem{},
+	}
+	for _, item := range f.Items {
+		out.Items = append(out.Items, jsonItem{
+			ID:            item.ID,
+			URL:           item.URL,
+			ContentText:   item.Content,
+			DatePublished: item.Published.UTC(),
+			DateModified:  item.Updated.UTC(),
+		})
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
@@ -0,0 +1,63 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Self          atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func renderRSS(f Feed) ([]byte, error) {
+	out := rssFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.HomeURL,
+			Description:   f.Description,
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+			Self:          atomLink{Rel: "self", Type: "application/rss+xml", Href: f.FeedURL},
+		},
+	}
+	//* RSS requires a description, Atom and JSON Feed do not
+	if out.Channel.Description == "" {
+		out.Channel.Description = f.Title
+	}
+	for _, item := range f.Items {
+		out.Channel.Items = append(out.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			Description: item.Content,
+			GUID:        rssGUID{IsPermaLink: false, Value: item.ID},
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+		})
+	}
+	return marshalXML(out)
+}
@@ -0,0 +1,103 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReauthWindow - how long before the access token expires the client is asked for a new one
+const ReauthWindow = time.Minute
+
+// ErrUnknownChannel -
+var ErrUnknownChannel = errors.New("unknown channel")
+
+// Frame types a client sends
+const (
+	FrameAuth        = "auth"
+	FrameSubscribe   = "subscribe"
+	FrameUnsubscribe = "unsubscribe"
+)
+
+// Frame types the server sends
+const (
+	FrameAuthenticated  = "authenticated"
+	FrameSubscribed     = "subscribed"
+	FrameUnsubscribed   = "unsubscribed"
+	FrameEvent          = "event"
+	FrameReauthRequired = "reauth_required"
+	FrameError          = "error"
+)
+
+// ClientFrame - {"type":"auth","token":"..."} or {"type":"subscribe","channel":"timeline"}
+type ClientFrame struct {
+	Type    string `json:"type"`
+	Token   string `json:"token,omitempty"`
+	Channel string `json:"channel,omitempty"`
+}
+
+// ServerFrame - Event and Data are only set on event frames, ExpiresAt on authenticated
+// and reauth_required
+type ServerFrame struct {
+	Type      string          `json:"type"`
+	Channel   string          `json:"channel,omitempty"`
+	Event     string          `json:"event,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+	Message   string          `json:"message,omitempty"`
+}
+
+// ChannelKind -
+type ChannelKind string
+
+const (
+	// Timeline - chirps from the user and everyone they follow
+	Timeline ChannelKind = "timeline"
+	// Thread - chirps in one conversation, named thread:<root chirp id>
+	Thread ChannelKind = "thread"
+	// Notifications - the user's own notifications
+	Notifications ChannelKind = "notifications"
+)
+
+// Channel - ChirpID is only set for Thread
+type Channel struct {
+	Kind    ChannelKind
+	ChirpID uuid.UUID
+}
+
+// ParseChannel - "timeline", "notifications" or "thread:<chirp id>"
+func ParseChannel(s string) (Channel, error) {
+	kind, id, hasID := strings.Cut(s, ":")
+	switch ChannelKind(kind) {
+	case Timeline, Notifications:
+		if hasID {
+			return Channel{}, ErrUnknownChannel
+		}
+		return Channel{Kind: ChannelKind(kind)}, nil
+	case Thread:
+		chirpID, err := uuid.Parse(id)
+		if err != nil {
+			return Channel{}, ErrUnknownChannel
+		}
+		return Channel{Kind: Thread, ChirpID: chirpID}, nil
+	default:
+		return Channel{}, ErrUnknownChannel
+	}
+}
+
+// String - the inverse of ParseChannel
+func (c Channel) String() string {
+	if c.Kind == Thread {
+		return string(c.Kind) + ":" + c.ChirpID.String()
+	}
+	return string(c.Kind)
+}
+
+// ReauthDelay - how long from now until the client should be asked to re-authenticate;
+// 0 when that moment has already passed
+func ReauthDelay(expiresAt, now time.Time) time.Duration {
+	return max(expiresAt.Sub(now)-ReauthWindow, 0)
+}
@@ -0,0 +1,112 @@
+package gateway
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParseChannel(t *testing.T) {
+	chirpID := uuid.New()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    Channel
+		wantErr error
+	}{
+		{
+			name:    "Timeline",
+			input:   "timeline",
+			want:    Channel{Kind: Timeline},
+			wantErr: nil,
+		},
+		{
+			name:    "Notifications",
+			input:   "notifications",
+			want:    Channel{Kind: Notifications},
+			wantErr: nil,
+		},
+		{
+			name:    "Thread",
+			input:   "thread:" + chirpID.String(),
+			want:    Channel{Kind: Thread, ChirpID: chirpID},
+			wantErr: nil,
+		},
+		{
+			name:    "Thread without id",
+			input:   "thread",
+			want:    Channel{},
+			wantErr: ErrUnknownChannel,
+		},
+		{
+			name:    "Thread with bad id",
+			input:   "thread:abc",
+			want:    Channel{},
+			wantErr: ErrUnknownChannel,
+		},
+		{
+			name:    "Timeline with id",
+			input:   "timeline:" + chirpID.String(),
+			want:    Channel{},
+			wantErr: ErrUnknownChannel,
+		},
+		{
+			name:    "Unknown",
+			input:   "mentions",
+			want:    Channel{},
+			wantErr: ErrUnknownChannel,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseChannel(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseChannel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseChannel() = %+v, want %+v", got, tt.want)
+			}
+			if err == nil && got.String() != tt.input {
+				t.Errorf("String() = %q, want %q", got.String(), tt.input)
+			}
+		})
+	}
+}
+
+func TestReauthDelay(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		want      time.Duration
+	}{
+		{
+			name:      "Well before expiry",
+			expiresAt: now.Add(time.Hour),
+			want:      time.Hour - ReauthWindow,
+		},
+		{
+			name:      "Inside the window",
+			expiresAt: now.Add(ReauthWindow / 2),
+			want:      0,
+		},
+		{
+			name:      "Already expired",
+			expiresAt: now.Add(-time.Minute),
+			want:      0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReauthDelay(tt.expiresAt, now); got != tt.want {
+				t.Errorf("ReauthDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
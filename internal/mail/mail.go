@@ -0,0 +1,68 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	netmail "net/mail"
+	"strings"
+	"time"
+)
+
+// ErrInvalidAddress -
+var ErrInvalidAddress = errors.New("invalid email address")
+
+// ErrInvalidHeader - a subject or address with a line break would let it add headers of its own
+var ErrInvalidHeader = errors.New("header contains a line break")
+
+// Message - a plain text mail to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer - how mails leave the server
+type Mailer interface {
+	// Send - returns once the message is handed over, not when it is delivered
+	Send(ctx context.Context, msg Message) error
+}
+
+// ValidAddress - a bare address like user@example.com, without a display name
+func ValidAddress(addr string) bool {
+	parsed, err := netmail.ParseAddress(addr)
+	return err == nil && parsed.Name == "" && parsed.Address == addr
+}
+
+func (m Message) validate() error {
+	if !ValidAddress(m.To) {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, m.To)
+	}
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return ErrInvalidHeader
+	}
+	return nil
+}
+
+// render - the message as it goes over the wire, CRLF line endings and a Q-encoded subject
+func (m Message) render(from string, now time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+
+	body := strings.ReplaceAll(m.Body, "\r\n", "\n")
+	for _, line := range strings.Split(body, "\n") {
+		//* lines starting with "." are left alone, the DATA writer of net/smtp dot-stuffs them
+		b.WriteString(line)
+		b.WriteString("\r\n")
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,109 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPConfig - any SMTP server or relay; a local mail catcher (MailHog, Mailpit, ...) only needs
+// Host, Port and From
+type SMTPConfig struct {
+	Host string
+	Port int // defaults to 587
+	// Username and Password - PLAIN auth, only sent once the connection is encrypted or to localhost
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPMailer - Mailer speaking SMTP, upgraded with STARTTLS whenever the server offers it
+type SMTPMailer struct {
+	cfg SMTPConfig
+	now func() time.Time
+}
+
+// NewSMTPMailer -
+func NewSMTPMailer(cfg SMTPConfig) (*SMTPMailer, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("smtp: host must be set")
+	}
+	if !ValidAddress(cfg.From) {
+		return nil, fmt.Errorf("smtp: from %w: %q", ErrInvalidAddress, cfg.From)
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	return &SMTPMailer{cfg: cfg, now: time.Now}, nil
+}
+
+// Send - one connection per message; without a deadline on ctx the whole exchange gets 30s
+func (s *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port)))
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	//* cancelling ctx unblocks whatever read or write is in flight
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer c.Close()
+
+	if err := s.send(c, msg); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return nil
+}
+
+func (s *SMTPMailer) send(c *smtp.Client, msg Message) error {
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(s.cfg.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg.render(s.cfg.From, s.now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
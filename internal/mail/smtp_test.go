@@ -0,0 +1,223 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP - answers a single session the way a mail catcher does, rejecting RCPT for
+// addresses in reject, and reports what it was sent
+type fakeSMTP struct {
+	addr   string
+	reject map[string]bool
+	got    chan fakeMail
+}
+
+type fakeMail struct {
+	from, to, data string
+}
+
+func newFakeSMTP(t *testing.T, reject ...string) *fakeSMTP {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	s := &fakeSMTP{addr: l.Addr().String(), reject: map[string]bool{}, got: make(chan fakeMail, 1)}
+	for _, r := range reject {
+		s.reject[r] = true
+	}
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.serve(textproto.NewConn(conn))
+	}()
+	return s
+}
+
+func (s *fakeSMTP) serve(c *textproto.Conn) {
+	var mail fakeMail
+	c.PrintfLine("220 localhost fake ESMTP")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO", "HELO":
+			c.PrintfLine("250-localhost")
+			c.PrintfLine("250 8BITMIME")
+		case "MAIL":
+			mail.from = envelopeAddr(arg, "FROM:")
+			c.PrintfLine("250 ok")
+		case "RCPT":
+			mail.to = envelopeAddr(arg, "TO:")
+			if s.reject[mail.to] {
+				c.PrintfLine("550 no such user")
+				continue
+			}
+			c.PrintfLine("250 ok")
+		case "DATA":
+			c.PrintfLine("354 go ahead")
+			data, err := c.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.data = string(data)
+			c.PrintfLine("250 queued")
+			s.got <- mail
+		case "QUIT":
+			c.PrintfLine("221 bye")
+			return
+		default:
+			c.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+// envelopeAddr - the address of a MAIL or RCPT argument, ESMTP parameters like BODY=8BITMIME dropped
+func envelopeAddr(arg, prefix string) string {
+	addr, _, _ := strings.Cut(strings.TrimPrefix(arg, prefix), " ")
+	return strings.Trim(addr, "<>")
+}
+
+func TestSMTPMailer(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      Message
+		reject   []string
+		wantErr  bool
+		wantData []string
+	}{
+		{
+			name: "Delivered",
+			msg: Message{
+				To:      "alice@example.com",
+				Subject: "Verify your email",
+				Body:    "Open this link:\nhttp://localhost:8080/api/users/verify-email?token=abc",
+			},
+			wantData: []string{
+				"From: chirpy@example.com",
+				"To: alice@example.com",
+				"Subject: Verify your email",
+				"Content-Type: text/plain; charset=utf-8",
+				"\nOpen this link:\nhttp://localhost:8080/api/users/verify-email?token=abc\n",
+			},
+		},
+		{
+			name: "Non-ASCII subject is encoded",
+			msg:  Message{To: "bob@example.com", Subject: "Xác minh email", Body: "hi"},
+			wantData: []string{
+				"Subject: =?utf-8?q?X=C3=A1c_minh_email?=",
+			},
+		},
+		{
+			name: "Leading dot survives",
+			msg:  Message{To: "carol@example.com", Subject: "dots", Body: ".\n..two"},
+			wantData: []string{
+				"\n.\n..two\n",
+			},
+		},
+		{
+			name:    "Recipient rejected",
+			msg:     Message{To: "ghost@example.com", Subject: "hi", Body: "hi"},
+			reject:  []string{"ghost@example.com"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTP(t, tt.reject...)
+			host, port, _ := net.SplitHostPort(server.addr)
+			portNum, _ := strconv.Atoi(port)
+
+			m, err := NewSMTPMailer(SMTPConfig{Host: host, Port: portNum, From: "chirpy@example.com"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err = m.Send(ctx, tt.msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got := <-server.got
+			if got.from != "chirpy@example.com" || got.to != tt.msg.To {
+				t.Errorf("envelope = %s -> %s, want chirpy@example.com -> %s", got.from, got.to, tt.msg.To)
+			}
+			for _, want := range tt.wantData {
+				if !strings.Contains(got.data, want) {
+					t.Errorf("DATA = %q, want it to contain %q", got.data, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewSMTPMailer(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SMTPConfig
+		wantErr bool
+	}{
+		{name: "Catcher", cfg: SMTPConfig{Host: "localhost", Port: 1025, From: "chirpy@localhost"}},
+		{name: "No host", cfg: SMTPConfig{From: "chirpy@example.com"}, wantErr: true},
+		{name: "Bad from", cfg: SMTPConfig{Host: "localhost", From: "chirpy"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSMTPMailer(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("NewSMTPMailer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestSMTPMailerCatcher - sends to a real mail catcher, e.g. Mailpit or MailHog on its default
+// port: CHIRPY_SMTP_TEST_ADDR=localhost:1025 go test ./internal/mail
+func TestSMTPMailerCatcher(t *testing.T) {
+	addr := os.Getenv("CHIRPY_SMTP_TEST_ADDR")
+	if addr == "" {
+		t.Skip("CHIRPY_SMTP_TEST_ADDR not set")
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewSMTPMailer(SMTPConfig{Host: host, Port: portNum, From: "chirpy@localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := Message{
+		To:      "test@localhost",
+		Subject: "chirpy test " + time.Now().Format(time.RFC3339),
+		Body:    "sent by TestSMTPMailerCatcher",
+	}
+	if err := m.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+}
@@ -0,0 +1,307 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net/http"
+)
+
+// MaxAttachments - images allowed on one chirp
+const MaxAttachments = 4
+
+// MaxAttachmentSize - per image, in bytes
+const MaxAttachmentSize = 5 << 20
+
+// ErrUnsupportedType -
+var ErrUnsupportedType = errors.New("unsupported media type")
+
+// ErrTooLarge -
+var ErrTooLarge = errors.New("attachment too large")
+
+// ErrMalformed -
+var ErrMalformed = errors.New("malformed image")
+
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Sniff - content type from the bytes themselves, the client supplied header is ignored
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if _, ok := extensions[contentType]; !ok {
+		return "", ErrUnsupportedType
+	}
+	return contentType, nil
+}
+
+// Extension - file extension for a sniffed content type
+func Extension(contentType string) string {
+	return extensions[contentType]
+}
+
+// StripMetadata - drops EXIF/XMP/text metadata without re-encoding the image
+func StripMetadata(contentType string, data []byte) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	case "image/gif":
+		return stripGIF(data)
+	default:
+		return nil, ErrUnsupportedType
+	}
+}
+
+// stripJPEG - removes APP1 (EXIF, XMP), APP13 (IPTC) and comment segments.
+// APP0 (JFIF) and APP2 (ICC profile) are kept so colors still render the same.
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, ErrMalformed
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+
+	i := 2
+	for i < len(data) {
+		if data[i] != 0xFF {
+			return nil, ErrMalformed
+		}
+		//* fill bytes
+		if i+1 < len(data) && data[i+1] == 0xFF {
+			i++
+			continue
+		}
+		if i+1 >= len(data) {
+			return nil, ErrMalformed
+		}
+		marker := data[i+1]
+
+		//* markers without a length
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out.Write(data[i : i+2])
+			i += 2
+			continue
+		}
+		if marker == 0xD9 {
+			out.Write(data[i : i+2])
+			return out.Bytes(), nil
+		}
+
+		if i+4 > len(data) {
+			return nil, ErrMalformed
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:i+4]))
+		if end > len(data) || end < i+4 {
+			return nil, ErrMalformed
+		}
+
+		//* start of scan, the entropy coded data runs to the end of the file
+		if marker == 0xDA {
+			out.Write(data[i:])
+			return out.Bytes(), nil
+		}
+
+		switch marker {
+		case 0xE1, 0xED, 0xFE:
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+
+	return out.Bytes(), nil
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"iTXt": true,
+	"zTXt": true,
+	"tIME": true,
+}
+
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrMalformed
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+
+	i := len(pngSignature)
+	for i < len(data) {
+		if i+8 > len(data) {
+			return nil, ErrMalformed
+		}
+		length := int(binary.BigEndian.Uint32(data[i : i+4]))
+		chunkType := string(data[i+4 : i+8])
+		end := i + 12 + length
+		if length < 0 || end > len(data) || end < i {
+			return nil, ErrMalformed
+		}
+
+		if !pngMetadataChunks[chunkType] {
+			out.Write(data[i:end])
+		}
+		i = end
+
+		if chunkType == "IEND" {
+			break
+		}
+	}
+
+	return out.Bytes(), nil
+}
+
+const (
+	gifExtension        = 0x21
+	gifImageDescriptor  = 0x2C
+	gifTrailer          = 0x3B
+	gifCommentLabel     = 0xFE
+	gifApplicationLabel = 0xFF
+)
+
+// gifLoopApplications - application extensions that only say how often an animation loops
+var gifLoopApplications = map[string]bool{
+	"NETSCAPE2.0": true,
+	"ANIMEXTS1.0": true,
+}
+
+// stripGIF - removes comment extensions and every application extension (XMP among them)
+// but the looping ones, which animations need; GIF has no EXIF block
+func stripGIF(data []byte) ([]byte, error) {
+	//* header and logical screen descriptor, then the global color table if there is one
+	if len(data) < 13 || (string(data[:6]) != "GIF87a" && string(data[:6]) != "GIF89a") {
+		return nil, ErrMalformed
+	}
+	i := 13 + gifColorTableSize(data[10])
+	if i > len(data) {
+		return nil, ErrMalformed
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:i])
+
+	for i < len(data) {
+		switch data[i] {
+		case gifTrailer:
+			out.WriteByte(gifTrailer)
+			return out.Bytes(), nil
+
+		case gifExtension:
+			if i+2 > len(data) {
+				return nil, ErrMalformed
+			}
+			label := data[i+1]
+			end, err := gifSubBlocksEnd(data, i+2)
+			if err != nil {
+				return nil, err
+			}
+			keep := label != gifCommentLabel
+			if label == gifApplicationLabel {
+				//* the first sub-block is the 8 byte identifier and 3 byte authentication code
+				keep = i+14 <= end && data[i+2] == 11 && gifLoopApplications[string(data[i+3:i+14])]
+			}
+			if keep {
+				out.Write(data[i:end])
+			}
+			i = end
+
+		case gifImageDescriptor:
+			//* descriptor, local color table, LZW minimum code size, then the image data
+			if i+10 > len(data) {
+				return nil, ErrMalformed
+			}
+			start := i + 10 + gifColorTableSize(data[i+9]) + 1
+			if start > len(data) {
+				return nil, ErrMalformed
+			}
+			end, err := gifSubBlocksEnd(data, start)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(data[i:end])
+			i = end
+
+		default:
+			return nil, ErrMalformed
+		}
+	}
+
+	return nil, ErrMalformed
+}
+
+// gifColorTableSize - bytes of the color table a descriptor's packed field announces
+func gifColorTableSize(flags byte) int {
+	if flags&0x80 == 0 {
+		return 0
+	}
+	return 3 << ((flags & 0x07) + 1)
+}
+
+// gifSubBlocksEnd - the index right after the terminator of the sub-blocks starting at i
+func gifSubBlocksEnd(data []byte, i int) (int, error) {
+	for i < len(data) {
+		size := int(data[i])
+		i++
+		if size == 0 {
+			return i, nil
+		}
+		i += size
+	}
+	return 0, ErrMalformed
+}
+
+const (
+	webpFlagXMP  = 0x04
+	webpFlagEXIF = 0x08
+)
+
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrMalformed
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:12])
+
+	i := 12
+	for i < len(data) {
+		if i+8 > len(data) {
+			return nil, ErrMalformed
+		}
+		fourCC := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4 : i+8]))
+		end := i + 8 + size + size%2
+		if size < 0 || end > len(data) || end < i {
+			return nil, ErrMalformed
+		}
+
+		switch fourCC {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), data[i:end]...)
+			if size > 0 {
+				chunk[8] &^= webpFlagEXIF | webpFlagXMP
+			}
+			out.Write(chunk)
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+
+	result := out.Bytes()
+	binary.LittleEndian.PutUint32(result[4:8], uint32(len(result)-8))
+	return result, nil
+}
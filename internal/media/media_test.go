@@ -0,0 +1,236 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+	return img
+}
+
+func jpegWithEXIF(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte("Exif\x00\x00GPS secret")
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+func pngWithText(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte("tEXtComment\x00GPS secret")
+	chunk := make([]byte, 4)
+	binary.BigEndian.PutUint32(chunk, uint32(len(payload)-4))
+	chunk = append(chunk, payload...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(payload))
+
+	//* right after the signature and the 25 byte IHDR chunk
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:33]...), chunk...), data[33:]...)
+}
+
+func webpWithEXIF() []byte {
+	chunk := func(fourCC string, payload []byte) []byte {
+		b := append([]byte(fourCC), 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(b[4:], uint32(len(payload)))
+		b = append(b, payload...)
+		if len(payload)%2 == 1 {
+			b = append(b, 0)
+		}
+		return b
+	}
+
+	body := []byte("WEBP")
+	body = append(body, chunk("VP8X", []byte{webpFlagEXIF | webpFlagXMP, 0, 0, 0, 3, 0, 0, 3, 0, 0})...)
+	body = append(body, chunk("VP8L", []byte{0x2f, 0x03, 0xc0, 0x00, 0x00})...)
+	body = append(body, chunk("EXIF", []byte("GPS secret"))...)
+	body = append(body, chunk("XMP ", []byte("<x>GPS secret</x>"))...)
+
+	data := append([]byte("RIFF\x00\x00\x00\x00"), body...)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(body)))
+	return data
+}
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name            string
+		data            []byte
+		wantContentType string
+		wantErr         bool
+	}{
+		{
+			name:            "JPEG",
+			data:            jpegWithEXIF(t),
+			wantContentType: "image/jpeg",
+			wantErr:         false,
+		},
+		{
+			name:            "PNG",
+			data:            pngWithText(t),
+			wantContentType: "image/png",
+			wantErr:         false,
+		},
+		{
+			name:            "WebP",
+			data:            webpWithEXIF(),
+			wantContentType: "image/webp",
+			wantErr:         false,
+		},
+		{
+			name:            "HTML named .png",
+			data:            []byte("<html><script>alert(1)</script></html>"),
+			wantContentType: "",
+			wantErr:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotContentType, err := Sniff(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Sniff() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotContentType != tt.wantContentType {
+				t.Errorf("Sniff() gotContentType = %v, want %v", gotContentType, tt.wantContentType)
+			}
+		})
+	}
+}
+
+// gifWithComment - a two frame animation with a comment and an XMP application extension
+func gifWithComment(t *testing.T) []byte {
+	paletted := func() *image.Paletted {
+		img := image.NewPaletted(image.Rect(0, 0, 4, 4), color.Palette{color.Black, color.White})
+		img.SetColorIndex(1, 1, 1)
+		return img
+	}
+	var buf bytes.Buffer
+	anim := &gif.GIF{Image: []*image.Paletted{paletted(), paletted()}, Delay: []int{10, 10}}
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatal(err)
+	}
+
+	comment := append([]byte{0x21, gifCommentLabel, 10}, "GPS secret"...)
+	comment = append(comment, 0)
+	xmp := append([]byte{0x21, gifApplicationLabel, 11}, "XMP DataXMP"...)
+	xmp = append(xmp, 10)
+	xmp = append(xmp, "GPS secret"...)
+	xmp = append(xmp, 0)
+
+	//* right before the trailer
+	data := buf.Bytes()
+	end := len(data) - 1
+	return append(append(append(append([]byte{}, data[:end]...), comment...), xmp...), data[end:]...)
+}
+
+func TestStripMetadata(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		data        []byte
+		decode      func([]byte) error
+		wantErr     bool
+	}{
+		{
+			name:        "JPEG drops APP1",
+			contentType: "image/jpeg",
+			data:        jpegWithEXIF(t),
+			decode: func(b []byte) error {
+				_, err := jpeg.Decode(bytes.NewReader(b))
+				return err
+			},
+			wantErr: false,
+		},
+		{
+			name:        "PNG drops tEXt",
+			contentType: "image/png",
+			data:        pngWithText(t),
+			decode: func(b []byte) error {
+				_, err := png.Decode(bytes.NewReader(b))
+				return err
+			},
+			wantErr: false,
+		},
+		{
+			name:        "WebP drops EXIF and XMP",
+			contentType: "image/webp",
+			data:        webpWithEXIF(),
+			decode: func(b []byte) error {
+				if got := binary.LittleEndian.Uint32(b[4:8]); int(got) != len(b)-8 {
+					t.Errorf("RIFF size = %d, want %d", got, len(b)-8)
+				}
+				if flags := b[20]; flags&(webpFlagEXIF|webpFlagXMP) != 0 {
+					t.Errorf("VP8X flags = %#x still announce metadata", flags)
+				}
+				return nil
+			},
+			wantErr: false,
+		},
+		{
+			name:        "GIF drops comments and XMP, keeps the loop",
+			contentType: "image/gif",
+			data:        gifWithComment(t),
+			decode: func(b []byte) error {
+				if !bytes.Contains(b, []byte("NETSCAPE2.0")) {
+					t.Errorf("StripMetadata() dropped the looping extension")
+				}
+				anim, err := gif.DecodeAll(bytes.NewReader(b))
+				if err == nil && len(anim.Image) != 2 {
+					t.Errorf("StripMetadata() left %d frames, want 2", len(anim.Image))
+				}
+				return err
+			},
+			wantErr: false,
+		},
+		{
+			name:        "Truncated GIF",
+			contentType: "image/gif",
+			data:        []byte("GIF89a\x04\x00\x04\x00\x00\x00\x00\x21\xfe\x05GPS"),
+			wantErr:     true,
+		},
+		{
+			name:        "Truncated JPEG",
+			contentType: "image/jpeg",
+			data:        []byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StripMetadata(tt.contentType, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StripMetadata() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if bytes.Contains(got, []byte("GPS secret")) {
+				t.Errorf("StripMetadata() kept the metadata")
+			}
+			if err := tt.decode(got); err != nil {
+				t.Errorf("StripMetadata() result does not decode: %v", err)
+			}
+		})
+	}
+}
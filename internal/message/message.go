@@ -0,0 +1,63 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxBodyLength - in runes; messages are not held to the chirp limit
+	MaxBodyLength = 2000
+	// MaxMembers - including the user who starts the conversation
+	MaxMembers = 50
+)
+
+// ErrEmptyBody -
+var ErrEmptyBody = errors.New("message body is empty")
+
+// ErrBodyTooLong -
+var ErrBodyTooLong = fmt.Errorf("message body must be at most %d characters", MaxBodyLength)
+
+// ErrNoRecipients -
+var ErrNoRecipients = errors.New("a conversation needs at least one other user")
+
+// ErrTooManyMembers -
+var ErrTooManyMembers = fmt.Errorf("a conversation has at most %d members", MaxMembers)
+
+// ValidateBody - trims the body and checks it is neither empty nor too long
+func ValidateBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", ErrEmptyBody
+	}
+	if utf8.RuneCountInString(body) > MaxBodyLength {
+		return "", ErrBodyTooLong
+	}
+	return body, nil
+}
+
+// Members - the sender followed by the recipients in request order, without duplicates
+// and without the sender listed twice
+func Members(sender uuid.UUID, recipients []uuid.UUID) ([]uuid.UUID, error) {
+	members := []uuid.UUID{sender}
+	seen := map[uuid.UUID]struct{}{sender: {}}
+	for _, id := range recipients {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		members = append(members, id)
+	}
+
+	if len(members) < 2 {
+		return nil, ErrNoRecipients
+	}
+	if len(members) > MaxMembers {
+		return nil, ErrTooManyMembers
+	}
+	return members, nil
+}
@@ -0,0 +1,134 @@
+package message
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantBody string
+		wantErr  error
+	}{
+		{
+			name:     "Trimmed",
+			body:     "  hi there \n",
+			wantBody: "hi there",
+			wantErr:  nil,
+		},
+		{
+			name:     "Empty",
+			body:     "",
+			wantBody: "",
+			wantErr:  ErrEmptyBody,
+		},
+		{
+			name:     "Only whitespace",
+			body:     " \n\t",
+			wantBody: "",
+			wantErr:  ErrEmptyBody,
+		},
+		{
+			name:     "Counted in runes",
+			body:     strings.Repeat("é", MaxBodyLength),
+			wantBody: strings.Repeat("é", MaxBodyLength),
+			wantErr:  nil,
+		},
+		{
+			name:     "Too long",
+			body:     strings.Repeat("a", MaxBodyLength+1),
+			wantBody: "",
+			wantErr:  ErrBodyTooLong,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := ValidateBody(tt.body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateBody() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if body != tt.wantBody {
+				t.Errorf("ValidateBody() = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestMembers(t *testing.T) {
+	sender := uuid.New()
+	a, b := uuid.New(), uuid.New()
+
+	tooMany := make([]uuid.UUID, MaxMembers)
+	for i := range tooMany {
+		tooMany[i] = uuid.New()
+	}
+
+	tests := []struct {
+		name        string
+		recipients  []uuid.UUID
+		wantMembers []uuid.UUID
+		wantErr     error
+	}{
+		{
+			name:        "One to one",
+			recipients:  []uuid.UUID{a},
+			wantMembers: []uuid.UUID{sender, a},
+			wantErr:     nil,
+		},
+		{
+			name:        "Group keeps request order",
+			recipients:  []uuid.UUID{b, a},
+			wantMembers: []uuid.UUID{sender, b, a},
+			wantErr:     nil,
+		},
+		{
+			name:        "Duplicates and sender dropped",
+			recipients:  []uuid.UUID{a, sender, a, b},
+			wantMembers: []uuid.UUID{sender, a, b},
+			wantErr:     nil,
+		},
+		{
+			name:        "No recipients",
+			recipients:  nil,
+			wantMembers: nil,
+			wantErr:     ErrNoRecipients,
+		},
+		{
+			name:        "Only the sender",
+			recipients:  []uuid.UUID{sender},
+			wantMembers: nil,
+			wantErr:     ErrNoRecipients,
+		},
+		{
+			name:        "Limit includes the sender",
+			recipients:  tooMany[:MaxMembers-1],
+			wantMembers: append([]uuid.UUID{sender}, tooMany[:MaxMembers-1]...),
+			wantErr:     nil,
+		},
+		{
+			name:        "Too many",
+			recipients:  tooMany,
+			wantMembers: nil,
+			wantErr:     ErrTooManyMembers,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members, err := Members(sender, tt.recipients)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Members() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(members, tt.wantMembers) {
+				t.Errorf("Members() = %v, want %v", members, tt.wantMembers)
+			}
+		})
+	}
+}
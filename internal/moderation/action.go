@@ -0,0 +1,54 @@
+package moderation
+
+import (
+	"fmt"
+)
+
+// Action - what a matching rule does with the chirp, ordered by severity
+type Action int
+
+const (
+	ActionAllow Action = iota
+	// ActionFlag - chirp goes through unchanged and is queued for review
+	ActionFlag
+	// ActionMask - matched text is replaced with ****
+	ActionMask
+	// ActionReject - chirp is refused
+	ActionReject
+)
+
+var actionNames = map[Action]string{
+	ActionAllow:  "allow",
+	ActionFlag:   "flag",
+	ActionMask:   "mask",
+	ActionReject: "reject",
+}
+
+// ParseAction -
+func ParseAction(s string) (Action, error) {
+	for a, name := range actionNames {
+		if name == s {
+			return a, nil
+		}
+	}
+	return ActionAllow, fmt.Errorf("unknown moderation action %q", s)
+}
+
+func (a Action) String() string {
+	return actionNames[a]
+}
+
+// MarshalText -
+func (a Action) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText -
+func (a *Action) UnmarshalText(text []byte) error {
+	parsed, err := ParseAction(string(text))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
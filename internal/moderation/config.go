@@ -0,0 +1,81 @@
+package moderation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// Config - everything the pipeline is built from, usually loaded from MODERATION_CONFIG
+type Config struct {
+	MaxLength           int               `json:"max_length"`
+	Words               map[string]Action `json:"words"`
+	Regex               []RegexConfig     `json:"regex"`
+	BlockedDomains      []string          `json:"blocked_domains"`
+	BlockedDomainAction Action            `json:"blocked_domain_action"`
+}
+
+// RegexConfig -
+type RegexConfig struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	Action  Action `json:"action"`
+}
+
+// DefaultConfig - the rules chirpy always had: three masked words and 140 characters
+func DefaultConfig() Config {
+	return Config{
+		MaxLength: DefaultMaxLength,
+		Words: map[string]Action{
+			"kerfuffle": ActionMask,
+			"sharbert":  ActionMask,
+			"fornax":    ActionMask,
+		},
+		BlockedDomainAction: ActionReject,
+	}
+}
+
+// LoadConfig - JSON file; fields it leaves out keep their DefaultConfig value and
+// words are merged into the default ones (map a default word to "allow" to drop it)
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("moderation config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// New - Folder, WordList, RegexFilter, URLBlocklist, in that order.
+// The WordList is returned too so words from other sources can be swapped in later.
+func New(cfg Config) (*Pipeline, *WordList, error) {
+	rules := make([]RegexRule, len(cfg.Regex))
+	for i, rc := range cfg.Regex {
+		pattern, err := regexp.Compile(rc.Pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("moderation rule %s: %w", rc.Name, err)
+		}
+		rules[i] = RegexRule{Name: rc.Name, Pattern: pattern, Action: rc.Action}
+	}
+
+	maxLength := cfg.MaxLength
+	if maxLength <= 0 {
+		maxLength = DefaultMaxLength
+	}
+
+	words := NewWordList(cfg.Words)
+	pipeline := NewPipeline(maxLength,
+		Folder{},
+		words,
+		RegexFilter{Rules: rules},
+		NewURLBlocklist(cfg.BlockedDomains, cfg.BlockedDomainAction),
+	)
+
+	return pipeline, words, nil
+}
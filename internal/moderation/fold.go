@@ -0,0 +1,99 @@
+package moderation
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Folder - Unicode normalization and confusables folding, meant to run first.
+// It drops invisible and combining characters, maps fullwidth, circled, mathematical
+// and accented letters plus common Cyrillic/Greek look-alikes to ASCII, and lowercases.
+type Folder struct{}
+
+// Apply -
+func (Folder) Apply(doc *Document) {
+	doc.Rewrite(func(r rune) []rune {
+		if r = Fold(r); r < 0 {
+			return nil
+		}
+		return []rune{r}
+	})
+}
+
+// Fold - folded form of one rune, -1 when it should be dropped
+func Fold(r rune) rune {
+	switch {
+	case r < utf8.RuneSelf:
+		return unicode.ToLower(r)
+	case isInvisible(r), unicode.Is(unicode.Mn, r):
+		return -1
+	case r >= 0xFF01 && r <= 0xFF5E: //* fullwidth ASCII
+		return unicode.ToLower(r - 0xFEE0)
+	case r >= 0x24B6 && r <= 0x24CF: //* circled capitals
+		return 'a' + r - 0x24B6
+	case r >= 0x24D0 && r <= 0x24E9: //* circled small letters
+		return 'a' + r - 0x24D0
+	case r >= 0x1D400 && r <= 0x1D6A3: //* mathematical alphanumerics, 13 alphabets of A-Z a-z
+		i := (r - 0x1D400) % 52
+		if i < 26 {
+			return 'a' + i
+		}
+		return 'a' + i - 26
+	case r >= 0x1D7CE && r <= 0x1D7FF: //* mathematical digits
+		return '0' + (r-0x1D7CE)%10
+	}
+
+	if folded, ok := confusables[r]; ok {
+		return folded
+	}
+	return unicode.ToLower(r)
+}
+
+func isInvisible(r rune) bool {
+	switch {
+	case r == 0x00AD, r == 0x034F, r == 0x180E, r == 0xFEFF:
+		return true
+	case r >= 0x200B && r <= 0x200F, r >= 0x202A && r <= 0x202E, r >= 0x2060 && r <= 0x2064:
+		return true
+	case r >= 0xFE00 && r <= 0xFE0F:
+		return true
+	}
+	return false
+}
+
+var confusables = func() map[rune]rune {
+	groups := map[rune]string{
+		'a': "ÀÁÂÃÄÅàáâãäåĀāĂăĄąǍǎАаΑαά",
+		'b': "ВЬьΒβ",
+		'c': "ÇçĆćĈĉĊċČčСсϲ",
+		'd': "ĎďĐđԁ",
+		'e': "ÈÉÊËèéêëĒēĔĕĖėĘęĚěЕеЁёΕεέ",
+		'g': "ĜĝĞğĠġĢģ",
+		'h': "ĤĥĦħНһΗ",
+		'i': "ÌÍÎÏìíîïĨĩĪīĬĭĮįİıІіЇїΙιίϊ",
+		'j': "Ĵĵјϳ",
+		'k': "ĶķКкΚκ",
+		'l': "ĹĺĻļĽľĿŀŁłӏ",
+		'm': "МмΜ",
+		'n': "ÑñŃńŅņŇňΝη",
+		'o': "ÒÓÔÕÖØòóôõöøŌōŎŏŐőОоΟοόσ",
+		'p': "РрΡρ",
+		'r': "ŔŕŖŗŘř",
+		's': "ŚśŜŝŞşŠšЅѕ",
+		't': "ŢţŤťŦŧТтΤτ",
+		'u': "ÙÚÛÜùúûüŨũŪūŬŭŮůŰűŲųυύ",
+		'v': "ν",
+		'w': "Ŵŵω",
+		'x': "ХхΧχ",
+		'y': "ÝýÿŶŷŸУуΥγ",
+		'z': "ŹźŻżŽžΖ",
+	}
+
+	m := map[rune]rune{}
+	for ascii, lookAlikes := range groups {
+		for _, r := range lookAlikes {
+			m[r] = ascii
+		}
+	}
+	return m
+}()
@@ -0,0 +1,187 @@
+package moderation
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// DefaultMaxLength - chirp limit, counted in runes
+const DefaultMaxLength = 140
+
+// mask - what masked text is replaced with
+const mask = "****"
+
+// ErrTooLong -
+var ErrTooLong = errors.New("chirp is too long")
+
+// ErrRejected -
+var ErrRejected = errors.New("chirp rejected by moderation")
+
+// Filter - one step of the pipeline. Filters run in order, so a normalizing filter
+// placed first changes what every later filter sees.
+type Filter interface {
+	Apply(doc *Document)
+}
+
+// Hit - one rule matching one piece of text
+type Hit struct {
+	Rule   string
+	Action Action
+	Match  string
+}
+
+// Result - text after masking and the strongest action any rule asked for
+type Result struct {
+	Text   string
+	Action Action
+	Hits   []Hit
+}
+
+// Flags - hits that should go to the review queue
+func (r Result) Flags() []Hit {
+	var flags []Hit
+	for _, h := range r.Hits {
+		if h.Action == ActionFlag {
+			flags = append(flags, h)
+		}
+	}
+	return flags
+}
+
+// Document - the text being moderated. Filters match against Folded; every folded
+// rune remembers the original rune it came from so masks land on the original text.
+type Document struct {
+	Original []rune
+	Folded   []rune
+	index    []int
+	hits     []Hit
+	masks    [][2]int
+}
+
+func newDocument(text string) *Document {
+	original := []rune(text)
+	index := make([]int, len(original))
+	for i := range index {
+		index[i] = i
+	}
+	return &Document{
+		Original: original,
+		Folded:   append([]rune(nil), original...),
+		index:    index,
+	}
+}
+
+// Rewrite - replaces every folded rune with fn's output, which may be empty to drop it
+func (d *Document) Rewrite(fn func(r rune) []rune) {
+	folded := make([]rune, 0, len(d.Folded))
+	index := make([]int, 0, len(d.index))
+	for i, r := range d.Folded {
+		for _, out := range fn(r) {
+			folded = append(folded, out)
+			index = append(index, d.index[i])
+		}
+	}
+	d.Folded, d.index = folded, index
+}
+
+// Report - records a hit on Folded[start:end]
+func (d *Document) Report(rule string, action Action, start, end int) {
+	if start >= end || end > len(d.Folded) {
+		return
+	}
+	from, to := d.index[start], d.index[end-1]+1
+
+	d.hits = append(d.hits, Hit{Rule: rule, Action: action, Match: string(d.Original[from:to])})
+	if action == ActionMask {
+		d.masks = append(d.masks, [2]int{from, to})
+	}
+}
+
+// masked - original text with every masked range replaced, overlapping ranges merged
+func (d *Document) masked() string {
+	if len(d.masks) == 0 {
+		return string(d.Original)
+	}
+
+	sort.Slice(d.masks, func(i, j int) bool { return d.masks[i][0] < d.masks[j][0] })
+
+	var b strings.Builder
+	pos := 0
+	for i := 0; i < len(d.masks); {
+		from, to := d.masks[i][0], d.masks[i][1]
+		for i++; i < len(d.masks) && d.masks[i][0] <= to; i++ {
+			to = max(to, d.masks[i][1])
+		}
+		b.WriteString(string(d.Original[pos:from]))
+		b.WriteString(mask)
+		pos = to
+	}
+	b.WriteString(string(d.Original[pos:]))
+
+	return b.String()
+}
+
+// Pipeline - runs the filter chain and keeps per-rule hit counters
+type Pipeline struct {
+	maxLength int
+	filters   []Filter
+
+	mu   sync.Mutex
+	hits map[string]int64
+}
+
+// NewPipeline -
+func NewPipeline(maxLength int, filters ...Filter) *Pipeline {
+	return &Pipeline{
+		maxLength: maxLength,
+		filters:   filters,
+		hits:      map[string]int64{},
+	}
+}
+
+// Moderate - ErrTooLong and ErrRejected come back with the Result so callers can still log the hits
+func (p *Pipeline) Moderate(text string) (Result, error) {
+	if utf8.RuneCountInString(text) > p.maxLength {
+		return Result{}, ErrTooLong
+	}
+
+	doc := newDocument(text)
+	for _, f := range p.filters {
+		f.Apply(doc)
+	}
+
+	result := Result{Text: doc.masked(), Action: ActionAllow, Hits: doc.hits}
+
+	p.mu.Lock()
+	for _, h := range doc.hits {
+		p.hits[h.Rule]++
+		result.Action = max(result.Action, h.Action)
+	}
+	p.mu.Unlock()
+
+	if result.Action == ActionReject {
+		for _, h := range doc.hits {
+			if h.Action == ActionReject {
+				return result, fmt.Errorf("%w: %s", ErrRejected, h.Rule)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// HitCounts - snapshot of how often each rule matched since start
+func (p *Pipeline) HitCounts() map[string]int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	counts := make(map[string]int64, len(p.hits))
+	for rule, n := range p.hits {
+		counts[rule] = n
+	}
+	return counts
+}
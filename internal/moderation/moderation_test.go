@@ -0,0 +1,175 @@
+package moderation
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func testPipeline() *Pipeline {
+	pipeline, _, _ := New(Config{
+		Words: map[string]Action{
+			"kerfuffle": ActionMask,
+			"sharbert":  ActionMask,
+			"fornax":    ActionReject,
+			"heck":      ActionFlag,
+		},
+		Regex: []RegexConfig{
+			{Name: "phone", Pattern: `\b\d{3}-\d{3}-\d{4}\b`, Action: ActionMask},
+		},
+		BlockedDomains:      []string{"spam.example"},
+		BlockedDomainAction: ActionReject,
+	})
+	return pipeline
+}
+
+func TestModerate(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		wantText   string
+		wantAction Action
+		wantErr    error
+	}{
+		{
+			name:       "Clean chirp",
+			text:       "I had something interesting for breakfast",
+			wantText:   "I had something interesting for breakfast",
+			wantAction: ActionAllow,
+		},
+		{
+			name:       "Word next to punctuation",
+			text:       "what a kerfuffle!",
+			wantText:   "what a ****!",
+			wantAction: ActionMask,
+		},
+		{
+			name:       "Case and tab",
+			text:       "Kerfuffle\tsharbert",
+			wantText:   "****\t****",
+			wantAction: ActionMask,
+		},
+		{
+			name:       "Cyrillic look-alike and zero width space",
+			text:       "kеrfu​ffle",
+			wantText:   "****",
+			wantAction: ActionMask,
+		},
+		{
+			name:       "Fullwidth and leetspeak",
+			text:       "ｓｈａｒｂｅｒｔ and k3rfuffl3",
+			wantText:   "**** and ****",
+			wantAction: ActionMask,
+		},
+		{
+			name:       "Substring is not a word",
+			text:       "sharberts are fine",
+			wantText:   "sharberts are fine",
+			wantAction: ActionAllow,
+		},
+		{
+			name:       "Flag keeps the text",
+			text:       "oh heck",
+			wantText:   "oh heck",
+			wantAction: ActionFlag,
+		},
+		{
+			name:       "Regex rule",
+			text:       "call 555-123-4567 now",
+			wantText:   "call **** now",
+			wantAction: ActionMask,
+		},
+		{
+			name:       "Reject word",
+			text:       "fornax",
+			wantText:   "fornax",
+			wantAction: ActionReject,
+			wantErr:    ErrRejected,
+		},
+		{
+			name:       "Blocked subdomain",
+			text:       "see https://www.spam.example/deal",
+			wantText:   "see https://www.spam.example/deal",
+			wantAction: ActionReject,
+			wantErr:    ErrRejected,
+		},
+		{
+			name:       "140 multibyte runes fit",
+			text:       strings.Repeat("é", 140),
+			wantText:   strings.Repeat("é", 140),
+			wantAction: ActionAllow,
+		},
+		{
+			name:    "141 runes are too long",
+			text:    strings.Repeat("a", 141),
+			wantErr: ErrTooLong,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testPipeline().Moderate(tt.text)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Moderate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == ErrTooLong {
+				return
+			}
+			if got.Text != tt.wantText {
+				t.Errorf("Moderate() Text = %q, want %q", got.Text, tt.wantText)
+			}
+			if got.Action != tt.wantAction {
+				t.Errorf("Moderate() Action = %v, want %v", got.Action, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestHitCounts(t *testing.T) {
+	pipeline := testPipeline()
+	for _, text := range []string{"kerfuffle", "KERFUFFLE and sharbert", "clean"} {
+		if _, err := pipeline.Moderate(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]int64{"wordlist:kerfuffle": 2, "wordlist:sharbert": 1}
+	if got := pipeline.HitCounts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("HitCounts() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadWordList(t *testing.T) {
+	input := "# comment\nkerfuffle\n\nfornax reject\nheck flag\n"
+
+	got, err := LoadWordList(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Action{"kerfuffle": ActionMask, "fornax": ActionReject, "heck": ActionFlag}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadWordList() = %v, want %v", got, want)
+	}
+
+	if _, err := LoadWordList(strings.NewReader("kerfuffle obliterate")); err == nil {
+		t.Errorf("LoadWordList() expected error for unknown action")
+	}
+}
+
+func TestRegexFilterOffsets(t *testing.T) {
+	//* multibyte text before the match must not shift the mask
+	pipeline := NewPipeline(DefaultMaxLength, Folder{}, RegexFilter{Rules: []RegexRule{
+		{Name: "digits", Pattern: regexp.MustCompile(`\d+`), Action: ActionMask},
+	}})
+
+	got, err := pipeline.Moderate("héllo wörld 1234!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Text != "héllo wörld ****!" {
+		t.Errorf("Moderate() Text = %q", got.Text)
+	}
+}
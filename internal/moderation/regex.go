@@ -0,0 +1,30 @@
+package moderation
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// RegexRule -
+type RegexRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Action  Action
+}
+
+// RegexFilter - rules run against the folded text, so patterns can be written in plain lowercase ASCII
+type RegexFilter struct {
+	Rules []RegexRule
+}
+
+// Apply -
+func (f RegexFilter) Apply(doc *Document) {
+	folded := string(doc.Folded)
+	for _, rule := range f.Rules {
+		for _, loc := range rule.Pattern.FindAllStringIndex(folded, -1) {
+			start := utf8.RuneCountInString(folded[:loc[0]])
+			end := start + utf8.RuneCountInString(folded[loc[0]:loc[1]])
+			doc.Report("regex:"+rule.Name, rule.Action, start, end)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package moderation
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var urlPattern = regexp.MustCompile(`(?:https?://)?((?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+[a-z]{2,})(?:[:/?#][^\s]*)?`)
+
+// URLBlocklist - links whose host is a blocked domain or one of its subdomains
+type URLBlocklist struct {
+	domains map[string]struct{}
+	Action  Action
+}
+
+// NewURLBlocklist -
+func NewURLBlocklist(domains []string, action Action) *URLBlocklist {
+	b := &URLBlocklist{domains: make(map[string]struct{}, len(domains)), Action: action}
+	for _, d := range domains {
+		b.domains[strings.Trim(strings.ToLower(d), ". ")] = struct{}{}
+	}
+	return b
+}
+
+// Apply - matches on the folded text, which also catches look-alike domains
+func (b *URLBlocklist) Apply(doc *Document) {
+	folded := string(doc.Folded)
+	for _, loc := range urlPattern.FindAllStringSubmatchIndex(folded, -1) {
+		domain, ok := b.blocked(folded[loc[2]:loc[3]])
+		if !ok {
+			continue
+		}
+		start := utf8.RuneCountInString(folded[:loc[0]])
+		end := start + utf8.RuneCountInString(folded[loc[0]:loc[1]])
+		doc.Report("url:"+domain, b.Action, start, end)
+	}
+}
+
+func (b *URLBlocklist) blocked(host string) (string, bool) {
+	for {
+		if _, ok := b.domains[host]; ok {
+			return host, true
+		}
+		dot := strings.IndexByte(host, '.')
+		if dot < 0 {
+			return "", false
+		}
+		host = host[dot+1:]
+	}
+}
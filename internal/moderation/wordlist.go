@@ -0,0 +1,118 @@
+package moderation
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// WordList - whole-word matches against a list, each word with its own action.
+// Words are compared after folding and with leetspeak digits read as letters.
+type WordList struct {
+	mu    sync.RWMutex
+	words map[string]Action
+}
+
+// NewWordList -
+func NewWordList(words map[string]Action) *WordList {
+	w := &WordList{}
+	w.SetWords(words)
+	return w
+}
+
+// SetWords - swaps the whole list, safe while chirps are being moderated
+func (w *WordList) SetWords(words map[string]Action) {
+	folded := make(map[string]Action, len(words))
+	for word, action := range words {
+		folded[foldWord(word)] = action
+	}
+
+	w.mu.Lock()
+	w.words = folded
+	w.mu.Unlock()
+}
+
+// Apply -
+func (w *WordList) Apply(doc *Document) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	start := -1
+	for i := 0; i <= len(doc.Folded); i++ {
+		if i < len(doc.Folded) && isWordRune(doc.Folded[i]) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start < 0 {
+			continue
+		}
+
+		word := leet(doc.Folded[start:i])
+		if action, ok := w.words[word]; ok && action != ActionAllow {
+			doc.Report("wordlist:"+word, action, start, i)
+		}
+		start = -1
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+var leetDigits = map[rune]rune{'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b'}
+
+// leet - digits read as letters, only inside tokens that have a letter so numbers stay numbers
+func leet(token []rune) string {
+	hasLetter := false
+	for _, r := range token {
+		hasLetter = hasLetter || unicode.IsLetter(r)
+	}
+	if !hasLetter {
+		return string(token)
+	}
+
+	out := make([]rune, len(token))
+	for i, r := range token {
+		if l, ok := leetDigits[r]; ok {
+			r = l
+		}
+		out[i] = r
+	}
+	return string(out)
+}
+
+func foldWord(word string) string {
+	doc := newDocument(strings.TrimSpace(word))
+	Folder{}.Apply(doc)
+	return leet(doc.Folded)
+}
+
+// LoadWordList - one word per line, optionally followed by an action ("fornax reject").
+// Blank lines and lines starting with # are skipped; the default action is mask.
+func LoadWordList(r io.Reader) (map[string]Action, error) {
+	words := map[string]Action{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		action := ActionMask
+		if len(fields) > 1 {
+			parsed, err := ParseAction(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			action = parsed
+		}
+		words[fields[0]] = action
+	}
+
+	return words, scanner.Err()
+}
@@ -0,0 +1,114 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+// DefaultQueueSize - events buffered before Enqueue starts dropping them
+const DefaultQueueSize = 1024
+
+// Kind - mirrored by the CHECK on notifications.kind
+type Kind string
+
+const (
+	Follow  Kind = "follow"
+	Mention Kind = "mention"
+	Reply   Kind = "reply"
+	Like    Kind = "like"
+	Rechirp Kind = "rechirp"
+)
+
+// Event - something a write handler did; recipients are worked out when it is delivered
+type Event struct {
+	Kind Kind
+	// ActorID - left empty for Reply and Mention, the chirp's author is the actor
+	ActorID uuid.UUID
+	// UserID - the followed user, only for Follow
+	UserID uuid.UUID
+	// ChirpID - the liked or rechirped chirp, or the chirp that replied or mentioned
+	ChirpID uuid.UUID
+}
+
+// DeliverFunc - turns one event into notification rows
+type DeliverFunc func(ctx context.Context, e Event) error
+
+// Queue - hands events from the request path to a single background worker,
+// so notifying never adds latency to the write that caused it
+type Queue struct {
+	events  chan Event
+	deliver DeliverFunc
+}
+
+// NewQueue -
+func NewQueue(deliver DeliverFunc, size int) *Queue {
+	return &Queue{
+		events:  make(chan Event, size),
+		deliver: deliver,
+	}
+}
+
+// Enqueue - never blocks; when the queue is full the event is dropped and false returned,
+// notifications being best effort
+func (q *Queue) Enqueue(e Event) bool {
+	select {
+	case q.events <- e:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run - delivers events one at a time until ctx is done
+func (q *Queue) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-q.events:
+			if err := q.deliver(ctx, e); err != nil {
+				log.Printf("notification: failed to deliver %s event %s\n", e.Kind, err)
+			}
+		}
+	}
+}
+
+func (k Kind) verb() string {
+	switch k {
+	case Follow:
+		return "followed you"
+	case Mention:
+		return "mentioned you"
+	case Reply:
+		return "replied to your chirp"
+	case Like:
+		return "liked your chirp"
+	case Rechirp:
+		return "rechirped your chirp"
+	default:
+		return string(k)
+	}
+}
+
+// Summary - "Alice liked your chirp", "Alice and Bob liked your chirp",
+// "Alice and 3 others liked your chirp"; names are the latest actors, newest first,
+// and total counts every actor in the group
+func Summary(kind Kind, names []string, total int64) string {
+	if len(names) == 0 || total <= 0 {
+		return ""
+	}
+
+	switch {
+	case total == 1:
+		return fmt.Sprintf("%s %s", names[0], kind.verb())
+	case total == 2 && len(names) >= 2:
+		return fmt.Sprintf("%s and %s %s", names[0], names[1], kind.verb())
+	case total == 2:
+		return fmt.Sprintf("%s and 1 other %s", names[0], kind.verb())
+	default:
+		return fmt.Sprintf("%s and %d others %s", names[0], total-1, kind.verb())
+	}
+}
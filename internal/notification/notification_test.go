@@ -0,0 +1,119 @@
+package notification
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		name  string
+		kind  Kind
+		names []string
+		total int64
+		want  string
+	}{
+		{
+			name:  "Single actor",
+			kind:  Like,
+			names: []string{"Alice"},
+			total: 1,
+			want:  "Alice liked your chirp",
+		},
+		{
+			name:  "Two actors named",
+			kind:  Rechirp,
+			names: []string{"Alice", "Bob"},
+			total: 2,
+			want:  "Alice and Bob rechirped your chirp",
+		},
+		{
+			name:  "Two actors, one name",
+			kind:  Follow,
+			names: []string{"Alice"},
+			total: 2,
+			want:  "Alice and 1 other followed you",
+		},
+		{
+			name:  "Grouped",
+			kind:  Like,
+			names: []string{"Alice", "Bob", "Carol"},
+			total: 4,
+			want:  "Alice and 3 others liked your chirp",
+		},
+		{
+			name:  "Reply",
+			kind:  Reply,
+			names: []string{"@bob"},
+			total: 1,
+			want:  "@bob replied to your chirp",
+		},
+		{
+			name:  "Mention",
+			kind:  Mention,
+			names: []string{"Carol"},
+			total: 1,
+			want:  "Carol mentioned you",
+		},
+		{
+			name:  "No actors",
+			kind:  Like,
+			names: nil,
+			total: 0,
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summary(tt.kind, tt.names, tt.total); got != tt.want {
+				t.Errorf("Summary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueue(t *testing.T) {
+	delivered := make(chan Event)
+	q := NewQueue(func(ctx context.Context, e Event) error {
+		delivered <- e
+		return nil
+	}, 2)
+
+	first := Event{Kind: Like, ActorID: uuid.New(), ChirpID: uuid.New()}
+	second := Event{Kind: Follow, ActorID: uuid.New(), UserID: uuid.New()}
+	if !q.Enqueue(first) || !q.Enqueue(second) {
+		t.Fatal("Enqueue() = false before the queue is full")
+	}
+	if q.Enqueue(Event{Kind: Like}) {
+		t.Error("Enqueue() = true on a full queue")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+
+	for _, want := range []Event{first, second} {
+		select {
+		case got := <-delivered:
+			if got != want {
+				t.Errorf("delivered %+v, want %+v", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("event not delivered")
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not stop after ctx was done")
+	}
+}
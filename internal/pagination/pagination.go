@@ -0,0 +1,159 @@
+package pagination
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultLimit - page size used when the client does not send ?limit=
+const DefaultLimit int32 = 20
+
+// MaxLimit - upper bound for ?limit=
+const MaxLimit int32 = 100
+
+// ErrInvalidCursor -
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrInvalidLimit -
+var ErrInvalidLimit = errors.New("invalid limit")
+
+// Cursor - position of the last row of a page, ordered by (created_at, id)
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// IsZero - true for the first page
+func (c Cursor) IsZero() bool {
+	return c.ID == uuid.Nil && c.CreatedAt.IsZero()
+}
+
+// NullCreatedAt -
+func (c Cursor) NullCreatedAt() sql.NullTime {
+	return sql.NullTime{Time: c.CreatedAt, Valid: !c.IsZero()}
+}
+
+// NullID -
+func (c Cursor) NullID() uuid.NullUUID {
+	return uuid.NullUUID{UUID: c.ID, Valid: !c.IsZero()}
+}
+
+// EncodeCursor - opaque token handed to clients as next_cursor
+func EncodeCursor(c Cursor) string {
+	raw := fmt.Sprintf("%d:%s", c.CreatedAt.UnixMicro(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor - an empty token decodes to the zero Cursor (first page)
+func DecodeCursor(token string) (Cursor, error) {
+	if token == "" {
+		return Cursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return parseCursor(string(raw))
+}
+
+func parseCursor(raw string) (Cursor, error) {
+	micro, id, ok := strings.Cut(raw, ":")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	usec, err := strconv.ParseInt(micro, 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{CreatedAt: time.UnixMicro(usec).UTC(), ID: uid}, nil
+}
+
+// ParseLimit - parses ?limit=, falling back to DefaultLimit and capping at MaxLimit
+func ParseLimit(limit string) (int32, error) {
+	if limit == "" {
+		return DefaultLimit, nil
+	}
+
+	n, err := strconv.ParseInt(limit, 10, 32)
+	if err != nil || n < 1 {
+		return 0, ErrInvalidLimit
+	}
+
+	if int32(n) > MaxLimit {
+		return MaxLimit, nil
+	}
+
+	return int32(n), nil
+}
+
+// RankCursor - position of the last row of a page ordered by (rank, created_at, id)
+type RankCursor struct {
+	Rank float32
+	Cursor
+}
+
+// NullRank -
+func (c RankCursor) NullRank() sql.NullFloat64 {
+	return sql.NullFloat64{Float64: float64(c.Rank), Valid: !c.IsZero()}
+}
+
+// EncodeRankCursor -
+func EncodeRankCursor(c RankCursor) string {
+	raw := fmt.Sprintf("%s:%d:%s", strconv.FormatFloat(float64(c.Rank), 'g', -1, 32), c.CreatedAt.UnixMicro(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeRankCursor - an empty token decodes to the zero RankCursor (first page)
+func DecodeRankCursor(token string) (RankCursor, error) {
+	if token == "" {
+		return RankCursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return RankCursor{}, ErrInvalidCursor
+	}
+
+	rank, rest, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return RankCursor{}, ErrInvalidCursor
+	}
+
+	r, err := strconv.ParseFloat(rank, 32)
+	if err != nil {
+		return RankCursor{}, ErrInvalidCursor
+	}
+
+	cursor, err := parseCursor(rest)
+	if err != nil {
+		return RankCursor{}, err
+	}
+
+	return RankCursor{Rank: float32(r), Cursor: cursor}, nil
+}
+
+// Paginate - queries fetch limit+1 rows; drop the look-ahead row and return the cursor of the next page
+func Paginate[T any](items []T, limit int32, encode func(T) string) ([]T, string) {
+	if len(items) <= int(limit) {
+		return items, ""
+	}
+
+	items = items[:limit]
+	return items, encode(items[len(items)-1])
+}
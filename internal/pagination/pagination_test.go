@@ -0,0 +1,132 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	cursor := Cursor{
+		CreatedAt: time.Date(2024, 12, 1, 10, 30, 0, 123456000, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		wantCursor Cursor
+		wantErr    bool
+	}{
+		{
+			name:       "Round trip",
+			token:      EncodeCursor(cursor),
+			wantCursor: cursor,
+			wantErr:    false,
+		},
+		{
+			name:       "Empty token is first page",
+			token:      "",
+			wantCursor: Cursor{},
+			wantErr:    false,
+		},
+		{
+			name:       "Not base64",
+			token:      "!!!",
+			wantCursor: Cursor{},
+			wantErr:    true,
+		},
+		{
+			name:       "Missing separator",
+			token:      "MTIzNDU",
+			wantCursor: Cursor{},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCursor, err := DecodeCursor(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodeCursor() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !gotCursor.CreatedAt.Equal(tt.wantCursor.CreatedAt) || gotCursor.ID != tt.wantCursor.ID {
+				t.Errorf("DecodeCursor() gotCursor = %v, want %v", gotCursor, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestDecodeRankCursor(t *testing.T) {
+	cursor := RankCursor{
+		Rank: 0.0607927,
+		Cursor: Cursor{
+			CreatedAt: time.Date(2024, 12, 1, 10, 30, 0, 123456000, time.UTC),
+			ID:        uuid.New(),
+		},
+	}
+
+	got, err := DecodeRankCursor(EncodeRankCursor(cursor))
+	if err != nil {
+		t.Fatalf("DecodeRankCursor() error = %v", err)
+	}
+	if got.Rank != cursor.Rank || !got.CreatedAt.Equal(cursor.CreatedAt) || got.ID != cursor.ID {
+		t.Errorf("DecodeRankCursor() got = %v, want %v", got, cursor)
+	}
+
+	if _, err := DecodeRankCursor(EncodeCursor(cursor.Cursor)); err == nil {
+		t.Errorf("DecodeRankCursor() accepted a plain cursor")
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     string
+		wantLimit int32
+		wantErr   bool
+	}{
+		{name: "Default", limit: "", wantLimit: DefaultLimit, wantErr: false},
+		{name: "Valid", limit: "5", wantLimit: 5, wantErr: false},
+		{name: "Capped", limit: "1000", wantLimit: MaxLimit, wantErr: false},
+		{name: "Zero", limit: "0", wantLimit: 0, wantErr: true},
+		{name: "Not a number", limit: "ten", wantLimit: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotLimit, err := ParseLimit(tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseLimit() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotLimit != tt.wantLimit {
+				t.Errorf("ParseLimit() gotLimit = %v, want %v", gotLimit, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	base := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	items := make([]Cursor, 3)
+	for i := range items {
+		items[i] = Cursor{CreatedAt: base.Add(time.Duration(i) * time.Second), ID: uuid.New()}
+	}
+	key := func(c Cursor) string { return EncodeCursor(c) }
+
+	page, next := Paginate(items, 2, key)
+	if len(page) != 2 {
+		t.Fatalf("Paginate() len = %d, want 2", len(page))
+	}
+	if next != EncodeCursor(items[1]) {
+		t.Errorf("Paginate() next = %v, want cursor of last item on page", next)
+	}
+
+	page, next = Paginate(items, 3, key)
+	if len(page) != 3 || next != "" {
+		t.Errorf("Paginate() on last page = (%d, %q), want (3, \"\")", len(page), next)
+	}
+}
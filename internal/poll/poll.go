@@ -0,0 +1,62 @@
+package poll
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MinOptions = 2
+	MaxOptions = 4
+	// MaxOptionLength - in runes
+	MaxOptionLength = 50
+	MinDuration     = 5 * time.Minute
+	MaxDuration     = 7 * 24 * time.Hour
+)
+
+// ErrOptionCount -
+var ErrOptionCount = fmt.Errorf("a poll needs %d to %d options", MinOptions, MaxOptions)
+
+// ErrDuplicateOption -
+var ErrDuplicateOption = errors.New("poll options must be unique")
+
+// ErrClosesAt -
+var ErrClosesAt = fmt.Errorf("a poll must run between %s and %s", MinDuration, MaxDuration)
+
+// Validate - trims the options and checks the poll runs a sane amount of time after opensAt
+// (creation, or publish_at for scheduled chirps)
+func Validate(options []string, closesAt, opensAt time.Time) ([]string, error) {
+	if len(options) < MinOptions || len(options) > MaxOptions {
+		return nil, ErrOptionCount
+	}
+
+	cleaned := make([]string, len(options))
+	seen := make(map[string]struct{}, len(options))
+	for i, option := range options {
+		option = strings.TrimSpace(option)
+		if option == "" || utf8.RuneCountInString(option) > MaxOptionLength {
+			return nil, fmt.Errorf("poll options must be 1 to %d characters", MaxOptionLength)
+		}
+
+		key := strings.ToLower(option)
+		if _, ok := seen[key]; ok {
+			return nil, ErrDuplicateOption
+		}
+		seen[key] = struct{}{}
+		cleaned[i] = option
+	}
+
+	if d := closesAt.Sub(opensAt); d < MinDuration || d > MaxDuration {
+		return nil, ErrClosesAt
+	}
+
+	return cleaned, nil
+}
+
+// ResultsVisible - tallies stay hidden until the viewer has voted or the poll closed
+func ResultsVisible(voted bool, closesAt, now time.Time) bool {
+	return voted || !now.Before(closesAt)
+}
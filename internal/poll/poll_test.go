@@ -0,0 +1,120 @@
+package poll
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2024, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		options     []string
+		closesAt    time.Time
+		wantOptions []string
+		wantErr     bool
+	}{
+		{
+			name:        "Trims options",
+			options:     []string{" yes ", "no"},
+			closesAt:    now.Add(24 * time.Hour),
+			wantOptions: []string{"yes", "no"},
+			wantErr:     false,
+		},
+		{
+			name:        "Single option",
+			options:     []string{"yes"},
+			closesAt:    now.Add(24 * time.Hour),
+			wantOptions: nil,
+			wantErr:     true,
+		},
+		{
+			name:        "Five options",
+			options:     []string{"a", "b", "c", "d", "e"},
+			closesAt:    now.Add(24 * time.Hour),
+			wantOptions: nil,
+			wantErr:     true,
+		},
+		{
+			name:        "Duplicate ignoring case",
+			options:     []string{"Yes", "yes"},
+			closesAt:    now.Add(24 * time.Hour),
+			wantOptions: nil,
+			wantErr:     true,
+		},
+		{
+			name:        "Blank option",
+			options:     []string{"yes", "  "},
+			closesAt:    now.Add(24 * time.Hour),
+			wantOptions: nil,
+			wantErr:     true,
+		},
+		{
+			name:        "Closes in the past",
+			options:     []string{"yes", "no"},
+			closesAt:    now.Add(-time.Hour),
+			wantOptions: nil,
+			wantErr:     true,
+		},
+		{
+			name:        "Runs too long",
+			options:     []string{"yes", "no"},
+			closesAt:    now.Add(MaxDuration + time.Second),
+			wantOptions: nil,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOptions, err := Validate(tt.options, tt.closesAt, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotOptions, tt.wantOptions) {
+				t.Errorf("Validate() gotOptions = %v, want %v", gotOptions, tt.wantOptions)
+			}
+		})
+	}
+}
+
+func TestResultsVisible(t *testing.T) {
+	closesAt := time.Date(2024, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		voted bool
+		now   time.Time
+		want  bool
+	}{
+		{
+			name:  "Open and not voted",
+			voted: false,
+			now:   closesAt.Add(-time.Minute),
+			want:  false,
+		},
+		{
+			name:  "Open and voted",
+			voted: true,
+			now:   closesAt.Add(-time.Minute),
+			want:  true,
+		},
+		{
+			name:  "Closed",
+			voted: false,
+			now:   closesAt,
+			want:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResultsVisible(tt.voted, closesAt, tt.now); got != tt.want {
+				t.Errorf("ResultsVisible() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxDisplayNameLength - in runes
+	MaxDisplayNameLength = 50
+	// MaxBioLength - in runes
+	MaxBioLength = 160
+	// MaxAvatarURLLength - in bytes
+	MaxAvatarURLLength = 2048
+)
+
+// ErrDisplayName -
+var ErrDisplayName = fmt.Errorf("display name must be at most %d characters, on one line", MaxDisplayNameLength)
+
+// ErrBio -
+var ErrBio = fmt.Errorf("bio must be at most %d characters", MaxBioLength)
+
+// ErrAvatarURL -
+var ErrAvatarURL = errors.New("avatar url must be an absolute http(s) url")
+
+// Fields - the editable part of a profile; empty values clear a field
+type Fields struct {
+	DisplayName string
+	Bio         string
+	AvatarURL   string
+}
+
+// Validate - trims every field and checks it fits on a profile
+func Validate(f Fields) (Fields, error) {
+	f.DisplayName = strings.TrimSpace(f.DisplayName)
+	f.Bio = strings.TrimSpace(f.Bio)
+	f.AvatarURL = strings.TrimSpace(f.AvatarURL)
+
+	if utf8.RuneCountInString(f.DisplayName) > MaxDisplayNameLength || strings.ContainsFunc(f.DisplayName, unicode.IsControl) {
+		return Fields{}, ErrDisplayName
+	}
+
+	if utf8.RuneCountInString(f.Bio) > MaxBioLength {
+		return Fields{}, ErrBio
+	}
+
+	if f.AvatarURL != "" {
+		if len(f.AvatarURL) > MaxAvatarURLLength {
+			return Fields{}, ErrAvatarURL
+		}
+		u, err := url.Parse(f.AvatarURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Fields{}, ErrAvatarURL
+		}
+	}
+
+	return f, nil
+}
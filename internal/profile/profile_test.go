@@ -0,0 +1,101 @@
+package profile
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		fields     Fields
+		wantFields Fields
+		wantErr    error
+	}{
+		{
+			name:       "Trims every field",
+			fields:     Fields{DisplayName: "  Kim ", Bio: " hi\n", AvatarURL: " https://cdn.example.com/a.png "},
+			wantFields: Fields{DisplayName: "Kim", Bio: "hi", AvatarURL: "https://cdn.example.com/a.png"},
+			wantErr:    nil,
+		},
+		{
+			name:       "Empty clears",
+			fields:     Fields{},
+			wantFields: Fields{},
+			wantErr:    nil,
+		},
+		{
+			name:       "Display name counted in runes",
+			fields:     Fields{DisplayName: strings.Repeat("é", MaxDisplayNameLength)},
+			wantFields: Fields{DisplayName: strings.Repeat("é", MaxDisplayNameLength)},
+			wantErr:    nil,
+		},
+		{
+			name:       "Display name too long",
+			fields:     Fields{DisplayName: strings.Repeat("a", MaxDisplayNameLength+1)},
+			wantFields: Fields{},
+			wantErr:    ErrDisplayName,
+		},
+		{
+			name:       "Display name with a newline",
+			fields:     Fields{DisplayName: "Kim\nAdmin"},
+			wantFields: Fields{},
+			wantErr:    ErrDisplayName,
+		},
+		{
+			name:       "Bio may span lines",
+			fields:     Fields{Bio: "line one\nline two"},
+			wantFields: Fields{Bio: "line one\nline two"},
+			wantErr:    nil,
+		},
+		{
+			name:       "Bio too long",
+			fields:     Fields{Bio: strings.Repeat("b", MaxBioLength+1)},
+			wantFields: Fields{},
+			wantErr:    ErrBio,
+		},
+		{
+			name:       "Plain http avatar",
+			fields:     Fields{AvatarURL: "http://example.com/a.png"},
+			wantFields: Fields{AvatarURL: "http://example.com/a.png"},
+			wantErr:    nil,
+		},
+		{
+			name:       "Relative avatar",
+			fields:     Fields{AvatarURL: "/media/a.png"},
+			wantFields: Fields{},
+			wantErr:    ErrAvatarURL,
+		},
+		{
+			name:       "Javascript avatar",
+			fields:     Fields{AvatarURL: "javascript:alert(1)"},
+			wantFields: Fields{},
+			wantErr:    ErrAvatarURL,
+		},
+		{
+			name:       "Avatar without host",
+			fields:     Fields{AvatarURL: "https:///a.png"},
+			wantFields: Fields{},
+			wantErr:    ErrAvatarURL,
+		},
+		{
+			name:       "Avatar too long",
+			fields:     Fields{AvatarURL: "https://example.com/" + strings.Repeat("a", MaxAvatarURLLength)},
+			wantFields: Fields{},
+			wantErr:    ErrAvatarURL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := Validate(tt.fields)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if fields != tt.wantFields {
+				t.Errorf("Validate() fields = %+v, want %+v", fields, tt.wantFields)
+			}
+		})
+	}
+}
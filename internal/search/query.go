@@ -0,0 +1,79 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ErrEmptyQuery -
+var ErrEmptyQuery = errors.New("search query is empty")
+
+// BuildTSQuery - turns user input into a to_tsquery expression.
+//
+//	word     -> word
+//	word*    -> word:*        (prefix)
+//	"a b c"  -> (a <-> b <-> c) (phrase)
+//	-word    -> !word
+//
+// Every other character is treated as a separator, so the result never
+// contains tsquery operators the user did not ask for.
+func BuildTSQuery(q string) (string, error) {
+	terms := []string{}
+	rest := q
+
+	for rest != "" {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			break
+		}
+
+		if rest[0] == '"' {
+			phrase, after, _ := strings.Cut(rest[1:], `"`)
+			rest = after
+			if words := lexemes(phrase); len(words) > 0 {
+				terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+			}
+			continue
+		}
+
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end == -1 {
+			end = len(rest)
+		}
+		token := rest[:end]
+		rest = rest[end:]
+
+		negate := strings.HasPrefix(token, "-")
+		prefix := strings.HasSuffix(token, "*")
+
+		words := lexemes(token)
+		if len(words) == 0 {
+			continue
+		}
+		if prefix {
+			words[len(words)-1] += ":*"
+		}
+
+		term := strings.Join(words, " & ")
+		if len(words) > 1 {
+			term = "(" + term + ")"
+		}
+		if negate {
+			term = "!" + term
+		}
+		terms = append(terms, term)
+	}
+
+	if len(terms) == 0 {
+		return "", ErrEmptyQuery
+	}
+
+	return strings.Join(terms, " & "), nil
+}
+
+func lexemes(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
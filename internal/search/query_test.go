@@ -0,0 +1,35 @@
+package search
+
+import "testing"
+
+func TestBuildTSQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		q       string
+		want    string
+		wantErr bool
+	}{
+		{name: "Single word", q: "chirpy", want: "chirpy", wantErr: false},
+		{name: "Words are ANDed", q: "Hello  World", want: "hello & world", wantErr: false},
+		{name: "Prefix", q: "chir*", want: "chir:*", wantErr: false},
+		{name: "Phrase", q: `"boot dev" go`, want: "(boot <-> dev) & go", wantErr: false},
+		{name: "Negation", q: "go -java", want: "go & !java", wantErr: false},
+		{name: "Operators are stripped", q: "a&b | !c:*", want: "(a & b) & c:*", wantErr: false},
+		{name: "Unterminated phrase", q: `"open ended`, want: "(open <-> ended)", wantErr: false},
+		{name: "Empty", q: "   ", want: "", wantErr: true},
+		{name: "Only punctuation", q: `"" !! *`, want: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildTSQuery(tt.q)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BuildTSQuery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("BuildTSQuery() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidEventID -
+var ErrInvalidEventID = errors.New("invalid Last-Event-ID")
+
+// Event - a row of chirp_events as it fans out to subscribers
+type Event struct {
+	ID      int64
+	Kind    string
+	ChirpID uuid.UUID
+	UserID  uuid.UUID
+}
+
+// NotificationEvent - a notification was added to, or grew in, UserID's unread notifications
+type NotificationEvent struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+// Hub - fans events out from the one database listener to every open stream
+type Hub[T any] struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription[T]]struct{}
+}
+
+// Subscription - C is closed when the subscriber falls behind or is closed; a client that
+// falls behind resumes from its Last-Event-ID instead of silently missing events
+type Subscription[T any] struct {
+	C   <-chan T
+	c   chan T
+	hub *Hub[T]
+}
+
+// NewHub -
+func NewHub[T any]() *Hub[T] {
+	return &Hub[T]{subscribers: map[*Subscription[T]]struct{}{}}
+}
+
+// Subscribe - buffer is how many events may queue up before the subscription is dropped
+func (h *Hub[T]) Subscribe(buffer int) *Subscription[T] {
+	c := make(chan T, buffer)
+	s := &Subscription[T]{C: c, c: c, hub: h}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers[s] = struct{}{}
+	return s
+}
+
+// Publish - never blocks on a slow subscriber
+func (h *Hub[T]) Publish(e T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for s := range h.subscribers {
+		select {
+		case s.c <- e:
+		default:
+			delete(h.subscribers, s)
+			close(s.c)
+		}
+	}
+}
+
+// Close - safe to call more than once, and after the hub dropped the subscription
+func (s *Subscription[T]) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	if _, ok := s.hub.subscribers[s]; ok {
+		delete(s.hub.subscribers, s)
+		close(s.c)
+	}
+}
+
+// Filter - what a stream asked for; the zero value matches every chirp
+type Filter struct {
+	AuthorID uuid.NullUUID
+	// Hashtag - lower case, without the #
+	Hashtag string
+	// Thread - the conversation_id of a thread, i.e. its root chirp
+	Thread uuid.NullUUID
+	// Following - when not nil, only these authors
+	Following map[uuid.UUID]struct{}
+	// Muted - left out, unless the stream is narrowed to one author
+	Muted map[uuid.UUID]struct{}
+}
+
+// Matches - hashtags are the chirp's, as returned by entities.Hashtags
+func (f Filter) Matches(authorID, conversationID uuid.UUID, hashtags []string) bool {
+	if f.AuthorID.Valid && f.AuthorID.UUID != authorID {
+		return false
+	}
+	if f.Thread.Valid && f.Thread.UUID != conversationID {
+		return false
+	}
+	if f.Following != nil {
+		if _, ok := f.Following[authorID]; !ok {
+			return false
+		}
+	}
+	if _, ok := f.Muted[authorID]; ok && !f.AuthorID.Valid {
+		return false
+	}
+	if f.Hashtag != "" && !slices.Contains(hashtags, f.Hashtag) {
+		return false
+	}
+	return true
+}
+
+// ParseLastEventID - an empty header means start from now, reported as 0
+func ParseLastEventID(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 0 {
+		return 0, ErrInvalidEventID
+	}
+	return id, nil
+}
+
+// WriteEvent - one SSE message; every line of data gets its own data: field
+func WriteEvent(w io.Writer, id int64, event string, data []byte) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "id: %d\nevent: %s\n", id, event)
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteComment - ignored by clients, keeps idle connections from being cut by proxies
+func WriteComment(w io.Writer, comment string) error {
+	_, err := fmt.Fprintf(w, ": %s\n\n", comment)
+	return err
+}
@@ -0,0 +1,220 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestFilterMatches(t *testing.T) {
+	author, other := uuid.New(), uuid.New()
+	thread := uuid.New()
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		authorID uuid.UUID
+		threadID uuid.UUID
+		hashtags []string
+		want     bool
+	}{
+		{
+			name:     "Zero filter",
+			filter:   Filter{},
+			authorID: author,
+			hashtags: nil,
+			want:     true,
+		},
+		{
+			name:     "Author matches",
+			filter:   Filter{AuthorID: uuid.NullUUID{UUID: author, Valid: true}},
+			authorID: author,
+			want:     true,
+		},
+		{
+			name:     "Other author",
+			filter:   Filter{AuthorID: uuid.NullUUID{UUID: author, Valid: true}},
+			authorID: other,
+			want:     false,
+		},
+		{
+			name:     "Hashtag present",
+			filter:   Filter{Hashtag: "golang"},
+			authorID: author,
+			hashtags: []string{"chirpy", "golang"},
+			want:     true,
+		},
+		{
+			name:     "Hashtag missing",
+			filter:   Filter{Hashtag: "golang"},
+			authorID: author,
+			hashtags: []string{"chirpy"},
+			want:     false,
+		},
+		{
+			name:     "Same thread",
+			filter:   Filter{Thread: uuid.NullUUID{UUID: thread, Valid: true}},
+			authorID: author,
+			threadID: thread,
+			want:     true,
+		},
+		{
+			name:     "Other thread",
+			filter:   Filter{Thread: uuid.NullUUID{UUID: thread, Valid: true}},
+			authorID: author,
+			threadID: uuid.New(),
+			want:     false,
+		},
+		{
+			name:     "Followed author",
+			filter:   Filter{Following: map[uuid.UUID]struct{}{author: {}}},
+			authorID: author,
+			want:     true,
+		},
+		{
+			name:     "Not followed",
+			filter:   Filter{Following: map[uuid.UUID]struct{}{author: {}}},
+			authorID: other,
+			want:     false,
+		},
+		{
+			name:     "Following nobody",
+			filter:   Filter{Following: map[uuid.UUID]struct{}{}},
+			authorID: author,
+			want:     false,
+		},
+		{
+			name:     "Muted author",
+			filter:   Filter{Muted: map[uuid.UUID]struct{}{author: {}}},
+			authorID: author,
+			want:     false,
+		},
+		{
+			name: "Mute does not apply to an author stream",
+			filter: Filter{
+				AuthorID: uuid.NullUUID{UUID: author, Valid: true},
+				Muted:    map[uuid.UUID]struct{}{author: {}},
+			},
+			authorID: author,
+			want:     true,
+		},
+		{
+			name: "Every filter at once",
+			filter: Filter{
+				AuthorID:  uuid.NullUUID{UUID: author, Valid: true},
+				Hashtag:   "golang",
+				Following: map[uuid.UUID]struct{}{author: {}},
+			},
+			authorID: author,
+			hashtags: []string{"golang"},
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.authorID, tt.threadID, tt.hashtags); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLastEventID(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int64
+		wantErr error
+	}{
+		{name: "Empty", input: "", want: 0, wantErr: nil},
+		{name: "Valid", input: "42", want: 42, wantErr: nil},
+		{name: "Negative", input: "-1", want: 0, wantErr: ErrInvalidEventID},
+		{name: "Not a number", input: "abc", want: 0, wantErr: ErrInvalidEventID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLastEventID(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseLastEventID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLastEventID() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		id    int64
+		event string
+		data  string
+		want  string
+	}{
+		{
+			name:  "Single line",
+			id:    7,
+			event: "chirp.created",
+			data:  `{"id":"x"}`,
+			want:  "id: 7\nevent: chirp.created\ndata: {\"id\":\"x\"}\n\n",
+		},
+		{
+			name:  "Multi line",
+			id:    8,
+			event: "chirp.deleted",
+			data:  "a\nb",
+			want:  "id: 8\nevent: chirp.deleted\ndata: a\ndata: b\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteEvent(&buf, tt.id, tt.event, []byte(tt.data)); err != nil {
+				t.Fatalf("WriteEvent() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("WriteEvent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHub(t *testing.T) {
+	hub := NewHub[Event]()
+	fast := hub.Subscribe(2)
+	slow := hub.Subscribe(1)
+
+	first := Event{ID: 1, Kind: "created"}
+	second := Event{ID: 2, Kind: "deleted"}
+	hub.Publish(first)
+	hub.Publish(second)
+
+	for _, want := range []Event{first, second} {
+		if got := <-fast.C; got != want {
+			t.Errorf("fast subscriber got %+v, want %+v", got, want)
+		}
+	}
+
+	if got := <-slow.C; got != first {
+		t.Errorf("slow subscriber got %+v, want %+v", got, first)
+	}
+	if _, ok := <-slow.C; ok {
+		t.Error("slow subscriber not dropped after falling behind")
+	}
+
+	fast.Close()
+	fast.Close()
+	slow.Close()
+	if _, ok := <-fast.C; ok {
+		t.Error("closed subscription still open")
+	}
+
+	//* publishing with nobody listening must not block or panic
+	hub.Publish(Event{ID: 3})
+}
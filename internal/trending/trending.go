@@ -0,0 +1,175 @@
+package trending
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BaselineFactor - the baseline covers this many windows right before the current one
+const BaselineFactor = 7
+
+// DefaultLimit - how many tags are kept per window
+const DefaultLimit = 20
+
+// ErrInvalidWindow -
+var ErrInvalidWindow = errors.New("invalid window")
+
+// Window - sliding time window trending tags are ranked over
+type Window string
+
+const (
+	Window1h  Window = "1h"
+	Window24h Window = "24h"
+	Window7d  Window = "7d"
+)
+
+// Windows - every window the aggregator keeps a snapshot for
+var Windows = []Window{Window1h, Window24h, Window7d}
+
+// ParseWindow - an empty value falls back to 24h
+func ParseWindow(s string) (Window, error) {
+	if s == "" {
+		return Window24h, nil
+	}
+	for _, w := range Windows {
+		if Window(s) == w {
+			return w, nil
+		}
+	}
+	return "", ErrInvalidWindow
+}
+
+// Duration -
+func (w Window) Duration() time.Duration {
+	switch w {
+	case Window1h:
+		return time.Hour
+	case Window7d:
+		return 7 * 24 * time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// Count - usage of a tag inside the window and in the baseline before it
+type Count struct {
+	Tag      string
+	Recent   int64
+	Baseline int64
+}
+
+// Tag - ranked entry of a snapshot
+type Tag struct {
+	Tag      string
+	Count    int64
+	Velocity float64
+}
+
+// Velocity - recent usage against the average baseline window, +1 keeps brand new tags finite
+func Velocity(c Count) float64 {
+	return float64(c.Recent) / (float64(c.Baseline)/BaselineFactor + 1)
+}
+
+// Rank - orders by velocity, then raw count, then tag, and keeps the first limit
+func Rank(counts []Count, limit int) []Tag {
+	tags := make([]Tag, len(counts))
+	for i, c := range counts {
+		tags[i] = Tag{Tag: c.Tag, Count: c.Recent, Velocity: Velocity(c)}
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Velocity != tags[j].Velocity {
+			return tags[i].Velocity > tags[j].Velocity
+		}
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+
+	if len(tags) > limit {
+		tags = tags[:limit]
+	}
+	return tags
+}
+
+// Snapshot - cached ranking of one window
+type Snapshot struct {
+	Tags       []Tag
+	ComputedAt time.Time
+}
+
+// CountFunc - loads tag counts for [baselineStart, windowStart) and [windowStart, now]
+type CountFunc func(ctx context.Context, windowStart, baselineStart time.Time) ([]Count, error)
+
+// Aggregator - recomputes every window on an interval and serves the cached result
+type Aggregator struct {
+	count    CountFunc
+	interval time.Duration
+	limit    int
+
+	mu        sync.RWMutex
+	snapshots map[Window]Snapshot
+}
+
+// NewAggregator -
+func NewAggregator(count CountFunc, interval time.Duration, limit int) *Aggregator {
+	return &Aggregator{
+		count:     count,
+		interval:  interval,
+		limit:     limit,
+		snapshots: make(map[Window]Snapshot, len(Windows)),
+	}
+}
+
+// Refresh - recomputes all windows, a failed window keeps its previous snapshot
+func (a *Aggregator) Refresh(ctx context.Context) error {
+	var errs []error
+	for _, w := range Windows {
+		now := time.Now().UTC()
+		windowStart := now.Add(-w.Duration())
+		baselineStart := windowStart.Add(-BaselineFactor * w.Duration())
+
+		counts, err := a.count(ctx, windowStart, baselineStart)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		a.mu.Lock()
+		a.snapshots[w] = Snapshot{Tags: Rank(counts, a.limit), ComputedAt: now}
+		a.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Run - refreshes right away and then every interval until ctx is done
+func (a *Aggregator) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.Refresh(ctx); err != nil {
+			log.Printf("trending: failed to refresh %s\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Snapshot - false until the first refresh of w succeeded
+func (a *Aggregator) Snapshot(w Window) (Snapshot, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	s, ok := a.snapshots[w]
+	return s, ok
+}
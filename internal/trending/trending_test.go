@@ -0,0 +1,126 @@
+package trending
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantWindow Window
+		wantErr    bool
+	}{
+		{
+			name:       "Empty defaults to 24h",
+			input:      "",
+			wantWindow: Window24h,
+			wantErr:    false,
+		},
+		{
+			name:       "One hour",
+			input:      "1h",
+			wantWindow: Window1h,
+			wantErr:    false,
+		},
+		{
+			name:       "Seven days",
+			input:      "7d",
+			wantWindow: Window7d,
+			wantErr:    false,
+		},
+		{
+			name:       "Unknown window",
+			input:      "30d",
+			wantWindow: "",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotWindow, err := ParseWindow(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseWindow() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotWindow != tt.wantWindow {
+				t.Errorf("ParseWindow() gotWindow = %v, want %v", gotWindow, tt.wantWindow)
+			}
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	counts := []Count{
+		{Tag: "steady", Recent: 10, Baseline: 70},
+		{Tag: "rising", Recent: 10, Baseline: 0},
+		{Tag: "small", Recent: 2, Baseline: 0},
+		{Tag: "tie", Recent: 2, Baseline: 0},
+	}
+
+	tests := []struct {
+		name     string
+		limit    int
+		wantTags []string
+	}{
+		{
+			name:     "Velocity beats volume, ties by count then tag",
+			limit:    10,
+			wantTags: []string{"rising", "small", "tie", "steady"},
+		},
+		{
+			name:     "Limit",
+			limit:    2,
+			wantTags: []string{"rising", "small"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Rank(counts, tt.limit)
+			if len(got) != len(tt.wantTags) {
+				t.Fatalf("Rank() len = %d, want %d", len(got), len(tt.wantTags))
+			}
+			for i, tag := range got {
+				if tag.Tag != tt.wantTags[i] {
+					t.Errorf("Rank()[%d] = %v, want %v", i, tag.Tag, tt.wantTags[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAggregatorRefresh(t *testing.T) {
+	fail := true
+	a := NewAggregator(func(ctx context.Context, windowStart, baselineStart time.Time) ([]Count, error) {
+		if fail {
+			return nil, errors.New("db down")
+		}
+		if got := windowStart.Sub(baselineStart); got%BaselineFactor != 0 {
+			t.Errorf("baseline span %v is not a multiple of the window", got)
+		}
+		return []Count{{Tag: "go", Recent: 1}}, nil
+	}, time.Minute, DefaultLimit)
+
+	if err := a.Refresh(context.Background()); err == nil {
+		t.Fatalf("Refresh() expected error")
+	}
+	if _, ok := a.Snapshot(Window1h); ok {
+		t.Fatalf("Snapshot() expected no snapshot after failed refresh")
+	}
+
+	fail = false
+	if err := a.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	for _, w := range Windows {
+		s, ok := a.Snapshot(w)
+		if !ok || len(s.Tags) != 1 || s.Tags[0].Tag != "go" {
+			t.Errorf("Snapshot(%s) = %v, %v", w, s, ok)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package visibility
+
+import "fmt"
+
+// Level - who besides the author may read a chirp, mirrored by the CHECK on chirps.visibility
+// and by the chirp_visible_to SQL function the list queries filter with
+type Level string
+
+const (
+	Public    Level = "public"
+	Followers Level = "followers"
+	Private   Level = "private"
+	// Direct - only the users mentioned in the chirp
+	Direct Level = "direct"
+)
+
+// Audience - how a viewer relates to a chirp; the zero value is an anonymous viewer
+type Audience struct {
+	Author bool
+	// Follower - follows the author
+	Follower bool
+	// Recipient - mentioned in the chirp
+	Recipient bool
+}
+
+// Parse - an empty level is Public
+func Parse(s string) (Level, error) {
+	switch level := Level(s); level {
+	case "":
+		return Public, nil
+	case Public, Followers, Private, Direct:
+		return level, nil
+	default:
+		return "", fmt.Errorf("invalid visibility %q", s)
+	}
+}
+
+// NeedsAudience - whether Allows can look past Audience.Author, i.e. whether
+// following or being mentioned makes a difference
+func (l Level) NeedsAudience() bool {
+	return l == Followers || l == Direct
+}
+
+// Allows - the author always reads their own chirps, unknown levels nobody else
+func (l Level) Allows(a Audience) bool {
+	if a.Author {
+		return true
+	}
+	switch l {
+	case Public:
+		return true
+	case Followers:
+		return a.Follower
+	case Direct:
+		return a.Recipient
+	default:
+		return false
+	}
+}
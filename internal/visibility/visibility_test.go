@@ -0,0 +1,136 @@
+package visibility
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantLevel Level
+		wantErr   bool
+	}{
+		{
+			name:      "Empty defaults to public",
+			input:     "",
+			wantLevel: Public,
+			wantErr:   false,
+		},
+		{
+			name:      "Public",
+			input:     "public",
+			wantLevel: Public,
+			wantErr:   false,
+		},
+		{
+			name:      "Followers",
+			input:     "followers",
+			wantLevel: Followers,
+			wantErr:   false,
+		},
+		{
+			name:      "Private",
+			input:     "private",
+			wantLevel: Private,
+			wantErr:   false,
+		},
+		{
+			name:      "Direct",
+			input:     "direct",
+			wantLevel: Direct,
+			wantErr:   false,
+		},
+		{
+			name:      "Case sensitive",
+			input:     "Public",
+			wantLevel: "",
+			wantErr:   true,
+		},
+		{
+			name:      "Unknown level",
+			input:     "friends",
+			wantLevel: "",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if level != tt.wantLevel {
+				t.Errorf("Parse() level = %q, want %q", level, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestAllows(t *testing.T) {
+	// anonymous - also any signed-in user who neither follows the author nor is mentioned
+	anonymous := Audience{}
+	author := Audience{Author: true}
+	follower := Audience{Follower: true}
+	recipient := Audience{Recipient: true}
+	followingRecipient := Audience{Follower: true, Recipient: true}
+
+	tests := []struct {
+		name     string
+		level    Level
+		audience Audience
+		want     bool
+	}{
+		{name: "Public, anonymous", level: Public, audience: anonymous, want: true},
+		{name: "Public, follower", level: Public, audience: follower, want: true},
+		{name: "Public, recipient", level: Public, audience: recipient, want: true},
+		{name: "Public, author", level: Public, audience: author, want: true},
+
+		{name: "Followers, anonymous", level: Followers, audience: anonymous, want: false},
+		{name: "Followers, follower", level: Followers, audience: follower, want: true},
+		{name: "Followers, mentioned but not following", level: Followers, audience: recipient, want: false},
+		{name: "Followers, author", level: Followers, audience: author, want: true},
+
+		{name: "Private, anonymous", level: Private, audience: anonymous, want: false},
+		{name: "Private, follower", level: Private, audience: follower, want: false},
+		{name: "Private, recipient", level: Private, audience: recipient, want: false},
+		{name: "Private, following recipient", level: Private, audience: followingRecipient, want: false},
+		{name: "Private, author", level: Private, audience: author, want: true},
+
+		{name: "Direct, anonymous", level: Direct, audience: anonymous, want: false},
+		{name: "Direct, follower not mentioned", level: Direct, audience: follower, want: false},
+		{name: "Direct, recipient", level: Direct, audience: recipient, want: true},
+		{name: "Direct, following recipient", level: Direct, audience: followingRecipient, want: true},
+		{name: "Direct, author", level: Direct, audience: author, want: true},
+
+		{name: "Unknown level, follower", level: "friends", audience: followingRecipient, want: false},
+		{name: "Unknown level, author", level: "friends", audience: author, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.level.Allows(tt.audience); got != tt.want {
+				t.Errorf("%q.Allows(%+v) = %v, want %v", tt.level, tt.audience, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsAudience(t *testing.T) {
+	tests := []struct {
+		level Level
+		want  bool
+	}{
+		{level: Public, want: false},
+		{level: Followers, want: true},
+		{level: Private, want: false},
+		{level: Direct, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.level), func(t *testing.T) {
+			if got := tt.level.NeedsAudience(); got != tt.want {
+				t.Errorf("%q.NeedsAudience() = %v, want %v", tt.level, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,24 +1,73 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+	"github.com/trantuvan/chirpy/internal/activitypub"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/blob"
 	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/mail"
+	"github.com/trantuvan/chirpy/internal/moderation"
+	"github.com/trantuvan/chirpy/internal/notification"
+	"github.com/trantuvan/chirpy/internal/stream"
+	"github.com/trantuvan/chirpy/internal/trending"
 )
 
+// appDir - served as it is at /app/, so nothing private may live under it
+const appDir = "."
+
 type apiConfig struct {
 	fileserverHits atomic.Int32
 	db             *database.Queries
+	dbConn         *sql.DB
 	platform       string
 	secretKey      string
 	polkaKey       string
+	// chirpEditWindow - how long after creation a chirp may be edited, 0 = no limit
+	chirpEditWindow time.Duration
+	// trendingInterval - how often the trending hashtags cache is recomputed
+	trendingInterval time.Duration
+	trending         *trending.Aggregator
+	// schedulerInterval - how often scheduled chirps that are due get published
+	schedulerInterval time.Duration
+	// blobs - where chirp attachments are stored; mediaFiles serves them from the local store,
+	// nil with S3
+	blobs      blob.BlobStore
+	mediaFiles http.Handler
+	// moderator - every chirp body goes through it; moderationWords is its word list,
+	// rebuilt from configuredWords plus the moderation_words table
+	moderator       *moderation.Pipeline
+	moderationWords *moderation.WordList
+	configuredWords map[string]moderation.Action
+	// notifications - write handlers queue events here, a background worker turns them into notifications
+	notifications *notification.Queue
+	// chirpStream and notificationStream - events from listenEvents, fanned out to SSE and
+	// WebSocket clients
+	chirpStream        *stream.Hub[stream.Event]
+	notificationStream *stream.Hub[stream.NotificationEvent]
+	// baseURL - scheme and host absolute links (feeds, ActivityPub ids, verification links) are
+	// built from, empty = the request's own, no federation and no mails
+	baseURL string
+	// federation - deliveries to other instances, nil when federation is off
+	federation       *activitypub.Queue
+	federationClient *http.Client
+	// mailer - verification links go out through it, nil when SMTP_HOST is not set
+	mailer mail.Mailer
+	// emailVerification - whether accounts with an unverified email may chirp
+	emailVerification auth.VerificationPolicy
 }
 
 func main() {
@@ -37,25 +86,140 @@ func main() {
 		log.Fatal("DB_URL & PLATFORM & SECRET_KEY & polkaKey must be set")
 	}
 
+	chirpEditWindow := time.Duration(0)
+	if editWindow := os.Getenv("CHIRP_EDIT_WINDOW"); editWindow != "" {
+		chirpEditWindow, err = time.ParseDuration(editWindow)
+		if err != nil {
+			log.Fatalf("cannot parse CHIRP_EDIT_WINDOW: %s\n", err)
+		}
+	}
+
+	trendingInterval := 5 * time.Minute
+	if interval := os.Getenv("TRENDING_INTERVAL"); interval != "" {
+		trendingInterval, err = time.ParseDuration(interval)
+		if err != nil || trendingInterval <= 0 {
+			log.Fatalf("cannot parse TRENDING_INTERVAL: %s\n", interval)
+		}
+	}
+
+	schedulerInterval := 30 * time.Second
+	if interval := os.Getenv("SCHEDULER_INTERVAL"); interval != "" {
+		schedulerInterval, err = time.ParseDuration(interval)
+		if err != nil || schedulerInterval <= 0 {
+			log.Fatalf("cannot parse SCHEDULER_INTERVAL: %s\n", interval)
+		}
+	}
+
+	emailVerification := auth.VerificationPolicy{Mode: auth.VerificationGrace, Grace: 24 * time.Hour}
+	if mode := os.Getenv("EMAIL_VERIFICATION"); mode != "" {
+		emailVerification.Mode, err = auth.ParseVerificationMode(mode)
+		if err != nil {
+			log.Fatalf("cannot parse EMAIL_VERIFICATION: %s\n", err)
+		}
+	}
+	if grace := os.Getenv("EMAIL_VERIFICATION_GRACE"); grace != "" {
+		emailVerification.Grace, err = time.ParseDuration(grace)
+		if err != nil || emailVerification.Grace < 0 {
+			log.Fatalf("cannot parse EMAIL_VERIFICATION_GRACE: %s\n", grace)
+		}
+	}
+
+	baseURL := strings.TrimSuffix(os.Getenv("BASE_URL"), "/")
+
+	//* only for instances federating with each other over localhost or a private network
+	federationAllowPrivate := false
+	if allow := os.Getenv("FEDERATION_ALLOW_PRIVATE"); allow != "" {
+		federationAllowPrivate, err = strconv.ParseBool(allow)
+		if err != nil {
+			log.Fatalf("cannot parse FEDERATION_ALLOW_PRIVATE: %s\n", allow)
+		}
+	}
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	blobs, mediaDir, err := newBlobStore()
+	if err != nil {
+		log.Fatalf("cannot create blob store: %s\n", err)
+	}
+
+	mailer, err := newMailer()
+	if err != nil {
+		log.Fatalf("cannot create mailer: %s\n", err)
+	}
+	//* verification links point at BASE_URL, never at whatever host a request claims to be for
+	if mailer != nil && baseURL == "" {
+		log.Fatal("SMTP_HOST needs BASE_URL for the links in verification mails")
+	}
+	//* nobody could verify without mails going out: asking for verification needs SMTP_HOST,
+	//* the grace default is dropped without it
+	if mailer == nil && emailVerification.Mode != auth.VerificationOff {
+		if os.Getenv("EMAIL_VERIFICATION") != "" {
+			log.Fatalf("EMAIL_VERIFICATION=%s needs SMTP_HOST\n", emailVerification.Mode)
+		}
+		log.Println("SMTP_HOST not set, email verification is off")
+		emailVerification.Mode = auth.VerificationOff
+	}
+
+	moderator, moderationWords, configuredWords, err := newModerator()
+	if err != nil {
+		log.Fatalf("cannot load moderation rules: %s\n", err)
+	}
+
 	db, err := sql.Open("postgres", dbURL)
 
 	if err != nil {
 		log.Fatalf("cannot open database: %s\n", err)
 	}
 
-	const port string = "8080"
 	apiConfig := apiConfig{
-		fileserverHits: atomic.Int32{},
-		db:             database.New(db),
-		platform:       platform,
-		secretKey:      secretKey,
-		polkaKey:       polkaKey,
+		fileserverHits:    atomic.Int32{},
+		db:                database.New(db),
+		dbConn:            db,
+		platform:          platform,
+		secretKey:         secretKey,
+		polkaKey:          polkaKey,
+		chirpEditWindow:   chirpEditWindow,
+		trendingInterval:  trendingInterval,
+		schedulerInterval: schedulerInterval,
+		blobs:             blobs,
+		moderator:         moderator,
+		moderationWords:   moderationWords,
+		configuredWords:   configuredWords,
+		baseURL:           baseURL,
+		mailer:            mailer,
+		emailVerification: emailVerification,
+	}
+	if err := apiConfig.loadModerationWords(context.Background()); err != nil {
+		log.Fatalf("cannot load moderation words: %s\n", err)
+	}
+	apiConfig.trending = trending.NewAggregator(apiConfig.countHashtags, apiConfig.trendingInterval, trending.DefaultLimit)
+	go apiConfig.trending.Run(context.Background())
+	apiConfig.notifications = notification.NewQueue(apiConfig.deliverNotification, notification.DefaultQueueSize)
+	go apiConfig.notifications.Run(context.Background())
+	go apiConfig.runScheduler(context.Background())
+	apiConfig.chirpStream = stream.NewHub[stream.Event]()
+	apiConfig.notificationStream = stream.NewHub[stream.NotificationEvent]()
+	go apiConfig.listenEvents(context.Background(), dbURL)
+	if baseURL != "" {
+		apiConfig.federationClient = activitypub.NewClient(federationTimeout, federationAllowPrivate)
+		apiConfig.federation = activitypub.NewQueue(activitypub.DefaultQueueSize)
+		go apiConfig.federation.Run(context.Background())
 	}
 
 	mux := http.NewServeMux()
-	mux.Handle("/app/", apiConfig.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(".")))))
+	mux.Handle("/app/", apiConfig.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(appDir)))))
+	if mediaDir != "" {
+		apiConfig.mediaFiles = http.StripPrefix("/media", http.FileServer(blob.FileSystem(mediaDir)))
+		mux.HandleFunc("GET /media/{key...}", apiConfig.handlerGetMedia)
+	}
 
 	mux.HandleFunc("GET /api/healthz", handlerReadiness) // only GET
+	mux.HandleFunc("GET /users/{idOrHandle}/feed.atom", apiConfig.handlerGetUserFeedAtom)
+	mux.HandleFunc("GET /users/{idOrHandle}/feed.rss", apiConfig.handlerGetUserFeedRSS)
+	mux.HandleFunc("GET /users/{idOrHandle}/feed.json", apiConfig.handlerGetUserFeedJSON)
 	mux.HandleFunc("POST /api/refresh", apiConfig.handlerGetUserFromRefreshToken)
 	mux.HandleFunc("POST /api/revoke", apiConfig.handlderRevokeRefreshToken)
 	mux.HandleFunc("POST /api/polka/webhooks", apiConfig.handlerUpdateUserToChirpyRed)
@@ -63,17 +227,179 @@ func main() {
 
 	mux.HandleFunc("POST /api/users", apiConfig.handlerCreateUser)
 	mux.HandleFunc("PUT /api/users", apiConfig.handlerUpdateUserEmailPassword)
+	mux.HandleFunc("PUT /api/users/profile", apiConfig.handlerUpdateProfile)
+	mux.HandleFunc("GET /api/users/verify-email", apiConfig.handlerVerifyEmail)
+	mux.HandleFunc("POST /api/users/verify-email", apiConfig.handlerResendVerification)
+	mux.HandleFunc("GET /api/users/{idOrHandle}", apiConfig.handlerGetProfile)
+	mux.HandleFunc("POST /api/users/{userID}/follow", apiConfig.handlerFollowUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", apiConfig.handlerUnfollowUser)
+	mux.HandleFunc("GET /api/users/{userID}/followers", apiConfig.handlerGetFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", apiConfig.handlerGetFollowing)
+	mux.HandleFunc("GET /api/users/{userID}/mentions", apiConfig.handlerGetUserMentions)
+	mux.HandleFunc("POST /api/users/{userID}/reports", apiConfig.handlerReportUser)
+	mux.HandleFunc("POST /api/users/{userID}/block", apiConfig.handlerBlockUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/block", apiConfig.handlerUnblockUser)
+	mux.HandleFunc("POST /api/users/{userID}/mute", apiConfig.handlerMuteUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/mute", apiConfig.handlerUnmuteUser)
+
+	mux.HandleFunc("GET /api/blocks", apiConfig.handlerGetBlocks)
+	mux.HandleFunc("GET /api/mutes", apiConfig.handlerGetMutes)
+	mux.HandleFunc("GET /api/bookmarks", apiConfig.handlerGetBookmarks)
+
+	mux.HandleFunc("POST /api/lists", apiConfig.handlerCreateList)
+	mux.HandleFunc("GET /api/lists", apiConfig.handlerGetLists)
+	mux.HandleFunc("GET /api/lists/{listID}", apiConfig.handlerGetList)
+	mux.HandleFunc("DELETE /api/lists/{listID}", apiConfig.handlerDeleteList)
+	mux.HandleFunc("GET /api/lists/{listID}/members", apiConfig.handlerGetListMembers)
+	mux.HandleFunc("POST /api/lists/{listID}/members", apiConfig.handlerAddListMember)
+	mux.HandleFunc("DELETE /api/lists/{listID}/members/{userID}", apiConfig.handlerRemoveListMember)
+	mux.HandleFunc("GET /api/lists/{listID}/chirps", apiConfig.handlerGetListChirps)
+
+	mux.HandleFunc("POST /api/conversations", apiConfig.handlerCreateConversation)
+	mux.HandleFunc("GET /api/conversations", apiConfig.handlerGetConversations)
+	mux.HandleFunc("GET /api/conversations/{conversationID}/messages", apiConfig.handlerGetMessages)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/messages", apiConfig.handlerCreateMessage)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/read", apiConfig.handlerReadConversation)
+
+	mux.HandleFunc("GET /api/notifications", apiConfig.handlerGetNotifications)
+	mux.HandleFunc("POST /api/notifications/read", apiConfig.handlerReadNotifications)
+
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", apiConfig.handlerGetHashtagChirps)
+	mux.HandleFunc("GET /api/trending", apiConfig.handlerGetTrending)
+
+	mux.HandleFunc("GET /api/timeline", apiConfig.handlerGetTimeline)
+	mux.HandleFunc("GET /api/stream/chirps", apiConfig.handlerStreamChirps)
+	mux.HandleFunc("GET /api/ws", apiConfig.handlerGateway)
 
 	mux.HandleFunc("POST /api/chirps", apiConfig.handlerCreateChirp)
 	mux.HandleFunc("GET /api/chirps", apiConfig.handlerGetChirps)
+	mux.HandleFunc("GET /api/chirps/search", apiConfig.handlerSearchChirps)
+	mux.HandleFunc("GET /api/chirps/drafts", apiConfig.handlerGetUnpublishedChirps)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", apiConfig.handlerGetChirp)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", apiConfig.handlerUpdateChirp)
+	mux.HandleFunc("PATCH /api/chirps/{chirpID}", apiConfig.handlerUpdateChirp)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiConfig.handlerDeleteChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/publish", apiConfig.handlerPublishChirp)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/revisions", apiConfig.handlerGetChirpRevisions)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", apiConfig.handlerGetChirpThread)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/like", apiConfig.handlerLikeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/like", apiConfig.handlerUnlikeChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", apiConfig.handlerRechirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp", apiConfig.handlerUndoRechirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/votes", apiConfig.handlerVotePoll)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/reports", apiConfig.handlerReportChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", apiConfig.handlerBookmarkChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", apiConfig.handlerUnbookmarkChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/pin", apiConfig.handlerPinChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", apiConfig.handlerUnpinChirp)
+
+	mux.HandleFunc("PUT /api/pins", apiConfig.handlerReorderPins)
+
+	//* ActivityPub ids are built from BASE_URL, so without it the instance does not federate
+	if baseURL != "" {
+		mux.HandleFunc("GET /.well-known/webfinger", apiConfig.handlerWebFinger)
+		mux.HandleFunc("GET /ap/users/{userID}", apiConfig.handlerGetActor)
+		mux.HandleFunc("GET /ap/users/{userID}/outbox", apiConfig.handlerGetOutbox)
+		mux.HandleFunc("GET /ap/users/{userID}/followers", apiConfig.handlerGetFollowersCollection)
+		mux.HandleFunc("POST /ap/users/{userID}/inbox", apiConfig.handlerInbox)
+		mux.HandleFunc("POST /ap/inbox", apiConfig.handlerInbox)
+		mux.HandleFunc("GET /ap/chirps/{chirpID}", apiConfig.handlerGetNote)
+		mux.HandleFunc("GET /api/federation/lookup", apiConfig.handlerLookupRemoteUser)
+	}
 
 	mux.HandleFunc("GET /admin/metrics", apiConfig.handlerMetrics) // only GET
 	mux.HandleFunc("POST /admin/reset", apiConfig.handlerReset)    // only POST
+	mux.HandleFunc("GET /admin/moderation/hits", apiConfig.handlerGetModerationHits)
+	mux.HandleFunc("GET /admin/moderation/reports", apiConfig.handlerListReports)
+	mux.HandleFunc("GET /admin/moderation/reports/{reportID}", apiConfig.handlerGetReport)
+	mux.HandleFunc("POST /admin/moderation/reports/{reportID}/claim", apiConfig.handlerClaimReport)
+	mux.HandleFunc("POST /admin/moderation/reports/{reportID}/resolve", apiConfig.handlerResolveReport)
 
 	server := http.Server{Addr: fmt.Sprintf(":%s", port), Handler: mux}
 
 	log.Printf("Servering on port: %s\n", port)
 	log.Fatalf("Server failed: %s", server.ListenAndServe())
 }
+
+// newBlobStore - MEDIA_STORE=s3 talks to any S3-compatible endpoint, a private bucket handed out
+// through presigned URLs; anything else keeps files under MEDIA_DIR (~/.chirpy/media by default,
+// never under appDir), which is then served from /media/ (returned as mediaDir)
+func newBlobStore() (blobs blob.BlobStore, mediaDir string, err error) {
+	if os.Getenv("MEDIA_STORE") == "s3" {
+		blobs, err = blob.NewS3Store(blob.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		})
+		return blobs, "", err
+	}
+
+	mediaDir = os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, "", err
+		}
+		mediaDir = filepath.Join(home, ".chirpy", "media")
+	}
+	blobs, err = blob.NewLocalStore(mediaDir, "/media")
+	if err != nil {
+		return nil, "", err
+	}
+
+	//* under appDir every attachment could be fetched from /app/ without the checks in handlerGetMedia
+	inside, err := insideDir(mediaDir, appDir)
+	if err != nil {
+		return nil, "", err
+	}
+	if inside {
+		return nil, "", fmt.Errorf("MEDIA_DIR %s is inside %s, which is served at /app/", mediaDir, appDir)
+	}
+	return blobs, mediaDir, nil
+}
+
+// insideDir - whether dir is root or below it once both are absolute and symlinks resolved;
+// both must exist
+func insideDir(dir, root string) (bool, error) {
+	resolved := make([]string, 2)
+	for i, p := range []string{dir, root} {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return false, err
+		}
+		if resolved[i], err = filepath.EvalSymlinks(abs); err != nil {
+			return false, err
+		}
+	}
+
+	rel, err := filepath.Rel(resolved[1], resolved[0])
+	if err != nil {
+		return false, err
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
+
+// newMailer - SMTP when SMTP_HOST is set, otherwise no mailer at all
+func newMailer() (mail.Mailer, error) {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil, nil
+	}
+
+	port := 0
+	if p := os.Getenv("SMTP_PORT"); p != "" {
+		var err error
+		if port, err = strconv.Atoi(p); err != nil {
+			return nil, fmt.Errorf("cannot parse SMTP_PORT: %w", err)
+		}
+	}
+	return mail.NewSMTPMailer(mail.SMTPConfig{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInsideDir(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	//* shares root as a prefix of its path without being below it
+	sibling := root + "-media"
+	for _, dir := range []string{filepath.Join(root, "media"), sibling} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { os.RemoveAll(sibling) })
+	link := filepath.Join(outside, "link")
+	if err := os.Symlink(filepath.Join(root, "media"), link); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		want bool
+	}{
+		{name: "Root itself", dir: root, want: true},
+		{name: "Below root", dir: filepath.Join(root, "media"), want: true},
+		{name: "Symlink into root", dir: link, want: true},
+		{name: "Outside root", dir: outside, want: false},
+		{name: "Sibling with root as prefix", dir: sibling, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := insideDir(tt.dir, root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("insideDir(%s, %s) = %t, want %t", tt.dir, root, got, tt.want)
+			}
+		})
+	}
+}
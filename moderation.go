@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/moderation"
+)
+
+// newModerator - rules from MODERATION_CONFIG (JSON) with extra words from MODERATION_WORDS
+// (one "word [action]" per line); neither set means the built-in defaults
+func newModerator() (*moderation.Pipeline, *moderation.WordList, map[string]moderation.Action, error) {
+	cfg := moderation.DefaultConfig()
+	if path := os.Getenv("MODERATION_CONFIG"); path != "" {
+		var err error
+		cfg, err = moderation.LoadConfig(path)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	if path := os.Getenv("MODERATION_WORDS"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		defer f.Close()
+
+		words, err := moderation.LoadWordList(f)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("moderation words %s: %w", path, err)
+		}
+		if cfg.Words == nil {
+			cfg.Words = make(map[string]moderation.Action, len(words))
+		}
+		for word, action := range words {
+			cfg.Words[word] = action
+		}
+	}
+
+	pipeline, words, err := moderation.New(cfg)
+	return pipeline, words, cfg.Words, err
+}
+
+// loadModerationWords - words stored in moderation_words win over the configured ones
+func (cfg *apiConfig) loadModerationWords(ctx context.Context) error {
+	rows, err := cfg.db.ListModerationWords(ctx)
+	if err != nil {
+		return err
+	}
+
+	words := make(map[string]moderation.Action, len(cfg.configuredWords)+len(rows))
+	for word, action := range cfg.configuredWords {
+		words[word] = action
+	}
+	for _, row := range rows {
+		action, err := moderation.ParseAction(row.Action)
+		if err != nil {
+			return err
+		}
+		words[row.Word] = action
+	}
+
+	cfg.moderationWords.SetWords(words)
+	return nil
+}
+
+// recordChirpFlags - keeps the hits that let the chirp through but want a human to look at it
+func recordChirpFlags(ctx context.Context, q *database.Queries, chirpID uuid.UUID, result moderation.Result) error {
+	for _, hit := range result.Flags() {
+		if err := q.CreateChirpFlag(ctx, database.CreateChirpFlagParams{
+			ChirpID: chirpID,
+			Rule:    hit.Rule,
+			Match:   hit.Match,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) handlerGetModerationHits(w http.ResponseWriter, r *http.Request) {
+	if _, err := cfg.adminID(r); err != nil {
+		helpers.ResponseWithError(w, adminStatus(err), fmt.Sprintf("handlerGetModerationHits: %s", err), err)
+		return
+	}
+
+	helpers.ResponseWithJson(w, http.StatusOK, cfg.moderator.HitCounts())
+}
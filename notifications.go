@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/notification"
+)
+
+// notify - queues e for the background worker; a full queue drops it rather than slow the request
+func (cfg *apiConfig) notify(e notification.Event) {
+	if !cfg.notifications.Enqueue(e) {
+		log.Printf("notification: queue full, dropped %s event\n", e.Kind)
+	}
+}
+
+// notifyPublished - a chirp that just went public tells the author it replied to and the users
+// it mentions; the worker fills in the actor, so the scheduler can call this with ids alone
+func (cfg *apiConfig) notifyPublished(chirpID uuid.UUID) {
+	cfg.notify(notification.Event{Kind: notification.Reply, ChirpID: chirpID})
+	cfg.notify(notification.Event{Kind: notification.Mention, ChirpID: chirpID})
+}
+
+// deliverNotification - the notification.DeliverFunc run by the worker
+func (cfg *apiConfig) deliverNotification(ctx context.Context, e notification.Event) error {
+	if e.Kind == notification.Follow {
+		return cfg.addNotification(ctx, e.UserID, e, uuid.NullUUID{})
+	}
+
+	chirp, err := cfg.db.GetChirp(ctx, e.ChirpID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	//* gone or unpublished again before the worker got to it
+	if !chirpVisibleTo(chirp, uuid.NullUUID{}) {
+		return nil
+	}
+
+	switch e.Kind {
+	case notification.Like, notification.Rechirp:
+		return cfg.addNotification(ctx, chirp.UserID, e, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+	case notification.Reply:
+		if !chirp.InReplyTo.Valid {
+			return nil
+		}
+		e.ActorID = chirp.UserID
+		parent, err := cfg.db.GetChirp(ctx, chirp.InReplyTo.UUID)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return cfg.notifyReader(ctx, chirp, parent.UserID, e)
+	case notification.Mention:
+		e.ActorID = chirp.UserID
+		mentioned, err := cfg.db.ListMentionedUserIDs(ctx, chirp.ID)
+		if err != nil {
+			return err
+		}
+
+		//* the replied-to author already hears about it as a reply
+		var parentAuthor uuid.UUID
+		if chirp.InReplyTo.Valid && len(mentioned) > 0 {
+			parent, err := cfg.db.GetChirp(ctx, chirp.InReplyTo.UUID)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+			parentAuthor = parent.UserID
+		}
+
+		for _, userID := range mentioned {
+			if userID == parentAuthor {
+				continue
+			}
+			if err := cfg.notifyReader(ctx, chirp, userID, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// notifyReader - replies and mentions only reach users the chirp's visibility lets read it
+func (cfg *apiConfig) notifyReader(ctx context.Context, chirp database.Chirp, userID uuid.UUID, e notification.Event) error {
+	allowed, err := cfg.chirpAllows(ctx, chirp, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil || !allowed {
+		return err
+	}
+	return cfg.addNotification(ctx, userID, e, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+}
+
+// addNotification - puts the actor into the recipient's unread group for this kind and chirp;
+// self, blocked, muted and repeated actors are skipped by UpsertNotification itself
+func (cfg *apiConfig) addNotification(ctx context.Context, userID uuid.UUID, e notification.Event, chirpID uuid.NullUUID) error {
+	return cfg.withTx(ctx, func(q *database.Queries) error {
+		notificationID, err := q.UpsertNotification(ctx, database.UpsertNotificationParams{
+			UserID:  userID,
+			Kind:    string(e.Kind),
+			ChirpID: chirpID,
+			ActorID: e.ActorID,
+		})
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return q.AddNotificationActor(ctx, database.AddNotificationActorParams{
+			NotificationID: notificationID,
+			ActorID:        e.ActorID,
+		})
+	})
+}
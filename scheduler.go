@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/trantuvan/chirpy/internal/database"
+)
+
+// schedulerBatchSize - due chirps claimed per statement
+const schedulerBatchSize int32 = 100
+
+// runScheduler - publishes due scheduled chirps every schedulerInterval until ctx is done.
+// Safe to run on every replica, PublishDueChirps claims rows with FOR UPDATE SKIP LOCKED.
+func (cfg *apiConfig) runScheduler(ctx context.Context) {
+	ticker := time.NewTicker(cfg.schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.publishDueChirps(ctx); err != nil {
+			log.Printf("scheduler: failed to publish due chirps %s\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) publishDueChirps(ctx context.Context) error {
+	for {
+		cutoff, held := cfg.emailVerification.Cutoff(time.Now())
+		published, err := cfg.db.PublishDueChirps(ctx, database.PublishDueChirpsParams{
+			UnverifiedCutoff: sql.NullTime{Time: cutoff, Valid: held},
+			BatchSize:        schedulerBatchSize,
+		})
+		if err != nil {
+			return err
+		}
+		if len(published) > 0 {
+			log.Printf("scheduler: published %d chirps\n", len(published))
+		}
+		for _, chirpID := range published {
+			cfg.notifyPublished(chirpID)
+			cfg.federateChirp(chirpID)
+		}
+		if int32(len(published)) < schedulerBatchSize {
+			return nil
+		}
+	}
+}
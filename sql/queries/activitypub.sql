@@ -0,0 +1,54 @@
+-- name: GetActorKey :one
+SELECT * FROM actor_keys WHERE user_id = $1;
+
+-- name: CreateActorKey :one
+-- when two requests race to make a user's key, both get the one that was stored first
+INSERT INTO actor_keys(user_id, public_key_pem, private_key_pem, created_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (user_id) DO UPDATE SET user_id = actor_keys.user_id
+RETURNING *;
+
+-- name: GetRemoteActor :one
+SELECT * FROM remote_actors WHERE user_id = $1;
+
+-- name: GetRemoteActorByURL :one
+SELECT * FROM remote_actors WHERE actor_url = $1;
+
+-- name: CreateRemoteUser :one
+-- no email and no password, the actor URI is what a remote user is known by
+INSERT INTO users(id, created_at, updated_at, actor_uri, display_name, bio, avatar_url)
+VALUES (gen_random_uuid(), NOW(), NOW(), sqlc.arg('actor_uri')::text, sqlc.arg('display_name'), sqlc.arg('bio'), sqlc.arg('avatar_url'))
+RETURNING *;
+
+-- name: UpsertRemoteActor :exec
+INSERT INTO remote_actors(user_id, actor_url, inbox_url, shared_inbox_url, key_id, public_key_pem, fetched_at)
+VALUES ($1, $2, $3, $4, $5, $6, NOW())
+ON CONFLICT (user_id) DO UPDATE
+SET inbox_url = EXCLUDED.inbox_url,
+    shared_inbox_url = EXCLUDED.shared_inbox_url,
+    key_id = EXCLUDED.key_id,
+    public_key_pem = EXCLUDED.public_key_pem,
+    fetched_at = EXCLUDED.fetched_at;
+
+-- name: ListFollowerInboxes :many
+-- one inbox per remote follower, or per instance when it has a shared inbox
+SELECT DISTINCT COALESCE(r.shared_inbox_url, r.inbox_url)::text AS inbox_url
+FROM follows AS f
+JOIN remote_actors AS r ON r.user_id = f.follower_id
+WHERE f.followee_id = $1;
+
+-- name: HasLocalFollowers :one
+SELECT EXISTS (
+    SELECT 1 FROM follows AS f
+    WHERE f.followee_id = $1
+    AND NOT EXISTS (SELECT 1 FROM remote_actors AS r WHERE r.user_id = f.follower_id)
+)::bool AS has_local_followers;
+
+-- name: CreateRemoteChirp :exec
+INSERT INTO remote_chirps(chirp_id, object_url) VALUES ($1, $2);
+
+-- name: GetRemoteChirpID :one
+SELECT chirp_id FROM remote_chirps WHERE object_url = $1;
+
+-- name: GetRemoteChirpURL :one
+SELECT object_url FROM remote_chirps WHERE chirp_id = $1;
@@ -0,0 +1,44 @@
+-- name: BlockUser :exec
+-- blocking also drops any follow between the two users, in both directions
+WITH unfollow AS (
+    DELETE FROM follows
+    WHERE (follower_id = sqlc.arg('blocker_id') AND followee_id = sqlc.arg('blocked_id'))
+    OR (follower_id = sqlc.arg('blocked_id') AND followee_id = sqlc.arg('blocker_id'))
+)
+INSERT INTO blocks(blocker_id, blocked_id, created_at)
+VALUES (sqlc.arg('blocker_id'), sqlc.arg('blocked_id'), NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :exec
+DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: MuteUser :exec
+INSERT INTO mutes(muter_id, muted_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :exec
+DELETE FROM mutes WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: IsBlockedBetween :one
+-- true when either user blocked the other
+SELECT blocked_between(sqlc.arg('user_a'), sqlc.arg('user_b'))::bool AS blocked;
+
+-- name: ListMutedUserIDs :many
+SELECT muted_id FROM mutes WHERE muter_id = $1;
+
+-- name: ListBlocks :many
+SELECT blocked_id AS user_id, created_at FROM blocks
+WHERE blocker_id = sqlc.arg('user_id')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, blocked_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, blocked_id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: ListMutes :many
+SELECT muted_id AS user_id, created_at FROM mutes
+WHERE muter_id = sqlc.arg('user_id')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, muted_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, muted_id DESC
+LIMIT sqlc.arg('page_limit');
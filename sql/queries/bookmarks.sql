@@ -0,0 +1,22 @@
+-- name: BookmarkChirp :exec
+INSERT INTO bookmarks(user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBookmark :exec
+DELETE FROM bookmarks WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: ListBookmarks :many
+-- tombstones stay listed; available is false for any chirp the user can no longer read
+SELECT sqlc.embed(c), b.created_at AS bookmarked_at,
+    (c.deleted_at IS NULL
+        AND (c.hidden_at IS NULL OR c.user_id = b.user_id)
+        AND NOT blocked_between(c.user_id, b.user_id)
+        AND chirp_visible_to(c.id, c.user_id, c.visibility, b.user_id))::bool AS available
+FROM bookmarks AS b
+JOIN chirps AS c ON c.id = b.chirp_id
+WHERE b.user_id = sqlc.arg('user_id')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (b.created_at, b.chirp_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY b.created_at DESC, b.chirp_id DESC
+LIMIT sqlc.arg('page_limit');
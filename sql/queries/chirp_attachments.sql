@@ -0,0 +1,19 @@
+-- name: CreateChirpAttachment :one
+INSERT INTO chirp_attachments(id, chirp_id, position, blob_key, content_type, size_bytes, created_at)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, NOW())
+RETURNING *;
+
+-- name: ListChirpAttachments :many
+SELECT * FROM chirp_attachments
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: DeleteChirpAttachments :many
+-- returns the blob keys so the caller can remove them from the store
+DELETE FROM chirp_attachments WHERE chirp_id = $1
+RETURNING blob_key;
+
+-- name: GetChirpByBlobKey :one
+SELECT c.* FROM chirps AS c
+JOIN chirp_attachments AS a ON a.chirp_id = c.id
+WHERE a.blob_key = $1;
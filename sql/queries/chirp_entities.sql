@@ -0,0 +1,67 @@
+-- name: InsertChirpHashtags :exec
+INSERT INTO chirp_hashtags(chirp_id, tag, created_at)
+SELECT sqlc.arg('chirp_id')::uuid, unnest(sqlc.arg('tags')::text[]), sqlc.arg('created_at')::timestamp
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags WHERE chirp_id = $1;
+
+-- name: InsertChirpMentions :exec
+-- handles are resolved to user ids here, so later renames keep the mention intact;
+-- users blocked either way by the author are not mentioned at all
+INSERT INTO chirp_mentions(chirp_id, user_id, created_at)
+SELECT sqlc.arg('chirp_id')::uuid, u.id, sqlc.arg('created_at')::timestamp
+FROM users AS u
+WHERE LOWER(u.handle) = ANY(sqlc.arg('handles')::text[])
+AND NOT blocked_between(u.id, sqlc.arg('author_id'))
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions WHERE chirp_id = $1;
+
+-- name: ListMentionedUserIDs :many
+SELECT user_id FROM chirp_mentions WHERE chirp_id = $1;
+
+-- name: ListChirpsByHashtag :many
+SELECT sqlc.embed(c) FROM chirp_hashtags AS h
+JOIN chirps AS c ON c.id = h.chirp_id
+WHERE h.tag = sqlc.arg('tag')
+AND c.deleted_at IS NULL
+AND c.status = 'published'
+AND c.hidden_at IS NULL
+AND NOT blocked_between(c.user_id, sqlc.narg('viewer_id'))
+AND chirp_visible_to(c.id, c.user_id, c.visibility, sqlc.narg('viewer_id'))
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = sqlc.narg('viewer_id') AND muted_id = c.user_id)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (h.created_at, h.chirp_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY h.created_at DESC, h.chirp_id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: ListChirpsMentioningUser :many
+SELECT sqlc.embed(c) FROM chirp_mentions AS m
+JOIN chirps AS c ON c.id = m.chirp_id
+WHERE m.user_id = sqlc.arg('user_id')
+AND c.deleted_at IS NULL
+AND c.status = 'published'
+AND c.hidden_at IS NULL
+AND NOT blocked_between(c.user_id, sqlc.narg('viewer_id'))
+AND chirp_visible_to(c.id, c.user_id, c.visibility, sqlc.narg('viewer_id'))
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (m.created_at, m.chirp_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY m.created_at DESC, m.chirp_id DESC
+LIMIT sqlc.arg('page_limit');
+-- name: CountHashtagsSince :many
+-- recent counts usage inside the window, baseline the span before it;
+-- trending is the same for everyone, so only public chirps count
+SELECT h.tag,
+    COUNT(*) FILTER (WHERE h.created_at >= sqlc.arg('window_start')::timestamp)::bigint AS recent_count,
+    COUNT(*) FILTER (WHERE h.created_at < sqlc.arg('window_start')::timestamp)::bigint AS baseline_count
+FROM chirp_hashtags AS h
+JOIN chirps AS c ON c.id = h.chirp_id
+WHERE h.created_at >= sqlc.arg('baseline_start')::timestamp
+AND c.deleted_at IS NULL
+AND c.status = 'published'
+AND c.hidden_at IS NULL
+AND c.visibility = 'public'
+GROUP BY h.tag
+HAVING COUNT(*) FILTER (WHERE h.created_at >= sqlc.arg('window_start')::timestamp) > 0;
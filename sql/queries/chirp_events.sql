@@ -0,0 +1,15 @@
+-- name: GetChirpEvent :one
+SELECT * FROM chirp_events WHERE id = $1;
+
+-- name: GetLatestChirpEventID :one
+SELECT COALESCE(MAX(id), 0)::bigint AS id FROM chirp_events;
+
+-- name: ListChirpEventsAfter :many
+-- oldest first, for replaying what a client missed since Last-Event-ID
+SELECT * FROM chirp_events
+WHERE id > sqlc.arg('after_id')
+ORDER BY id
+LIMIT sqlc.arg('page_limit');
+
+-- name: DeleteChirpEventsBefore :exec
+DELETE FROM chirp_events WHERE created_at < $1;
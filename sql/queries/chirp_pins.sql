@@ -0,0 +1,32 @@
+-- name: PinChirp :execrows
+-- goes after the user's last pin; nothing is inserted once they have max_pins
+INSERT INTO chirp_pins(user_id, chirp_id, position, created_at)
+SELECT sqlc.arg('user_id'), sqlc.arg('chirp_id'), COALESCE(MAX(position) + 1, 0), NOW()
+FROM chirp_pins
+WHERE user_id = sqlc.arg('user_id')
+HAVING COUNT(*) < sqlc.arg('max_pins')::int
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: UnpinChirp :exec
+DELETE FROM chirp_pins WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: ListPinnedChirpIDs :many
+SELECT chirp_id FROM chirp_pins WHERE user_id = $1 ORDER BY position;
+
+-- name: ReorderPins :exec
+-- chirp_ids holds every pin of the user, in the new order
+UPDATE chirp_pins SET position = new.ordinality - 1
+FROM unnest(sqlc.arg('chirp_ids')::uuid[]) WITH ORDINALITY AS new(chirp_id, ordinality)
+WHERE chirp_pins.user_id = sqlc.arg('user_id') AND chirp_pins.chirp_id = new.chirp_id;
+
+-- name: GetPinnedChirps :many
+-- an author's pins in their order, filtered like any other read of their chirps
+SELECT sqlc.embed(c) FROM chirp_pins AS p
+JOIN chirps AS c ON c.id = p.chirp_id
+WHERE p.user_id = sqlc.arg('user_id')
+AND c.deleted_at IS NULL
+AND c.status = 'published'
+AND (c.hidden_at IS NULL OR c.user_id = sqlc.narg('viewer_id'))
+AND NOT blocked_between(c.user_id, sqlc.narg('viewer_id'))
+AND chirp_visible_to(c.id, c.user_id, c.visibility, sqlc.narg('viewer_id'))
+ORDER BY p.position;
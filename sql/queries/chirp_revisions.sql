@@ -0,0 +1,4 @@
+-- name: GetChirpRevisions :many
+SELECT * FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY created_at DESC, id DESC;
\ No newline at end of file
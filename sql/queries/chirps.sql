@@ -1,16 +1,264 @@
 -- name: CreateChirps :one
-INSERT INTO chirps(id, created_at, updated_at, body, user_id)
-VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2)
+-- a reply joins its parent's conversation, anything else starts a new one
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, status, publish_at, visibility)
+SELECT new.id, NOW(), NOW(), sqlc.arg('body')::text, sqlc.arg('user_id')::uuid,
+    sqlc.narg('in_reply_to')::uuid, COALESCE(parent.conversation_id, new.id),
+    sqlc.arg('status')::text, sqlc.narg('publish_at')::timestamp, sqlc.arg('visibility')::text
+FROM (SELECT gen_random_uuid() AS id) AS new
+LEFT JOIN chirps AS parent ON parent.id = sqlc.narg('in_reply_to')::uuid
 RETURNING *;
 
+-- name: CreateRechirp :one
+-- a plain rechirp (empty body) is idempotent per user, a quote always creates a chirp
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, conversation_id, rechirp_of)
+SELECT new.id, NOW(), NOW(), sqlc.arg('body')::text, sqlc.arg('user_id')::uuid,
+    new.id, sqlc.arg('rechirp_of')::uuid
+FROM (SELECT gen_random_uuid() AS id) AS new
+ON CONFLICT (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL AND body = '' DO NOTHING
+RETURNING *;
+
+-- name: DeleteRechirp :execrows
+DELETE FROM chirps WHERE user_id = $1 AND rechirp_of = $2 AND body = '';
+
+-- name: DeleteRechirpsOf :exec
+-- the plain rechirps of a chirp its author is deleting; tombstones of deleted quotes keep their place
+DELETE FROM chirps AS rechirp
+USING chirps AS original
+WHERE rechirp.rechirp_of = original.id AND original.id = $1 AND original.user_id = $2
+AND rechirp.body = '' AND rechirp.deleted_at IS NULL;
+
 -- name: GetChirps :many
-SELECT * FROM chirps ORDER BY created_at;
+SELECT * FROM chirps
+WHERE deleted_at IS NULL AND status = 'published' AND hidden_at IS NULL
+AND NOT blocked_between(chirps.user_id, sqlc.narg('viewer_id'))
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = sqlc.narg('viewer_id') AND muted_id = chirps.user_id)
+ORDER BY created_at;
 
 -- name: GetChirpsByUserID :many
-SELECT * FROM chirps WHERE user_id = $1 ORDER BY created_at;
+-- asking for one author's chirps by name goes past a mute, not past a block
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id') AND deleted_at IS NULL AND status = 'published' AND hidden_at IS NULL
+AND NOT blocked_between(chirps.user_id, sqlc.narg('viewer_id'))
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+ORDER BY created_at;
+
+-- name: GetLatestChirpsByUserID :many
+-- an author's newest chirps for feeds, plain rechirps left out as they have no text of their own
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id') AND deleted_at IS NULL AND status = 'published' AND hidden_at IS NULL
+AND (rechirp_of IS NULL OR body <> '')
+AND NOT blocked_between(chirps.user_id, sqlc.narg('viewer_id'))
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: ListChirpsAsc :many
+-- hidden chirps are only listed for their author and for admins (include_hidden),
+-- mutes apply unless the list is already narrowed to one author
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+AND status = 'published'
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+AND (hidden_at IS NULL OR user_id = sqlc.narg('viewer_id') OR sqlc.arg('include_hidden')::bool)
+AND NOT blocked_between(chirps.user_id, sqlc.narg('viewer_id'))
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+AND (sqlc.narg('author_id')::uuid IS NOT NULL
+    OR NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = sqlc.narg('viewer_id') AND muted_id = chirps.user_id))
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg('page_limit');
+
+-- name: ListChirpsDesc :many
+-- hidden chirps are only listed for their author and for admins (include_hidden),
+-- mutes apply unless the list is already narrowed to one author
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+AND status = 'published'
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+AND (hidden_at IS NULL OR user_id = sqlc.narg('viewer_id') OR sqlc.arg('include_hidden')::bool)
+AND NOT blocked_between(chirps.user_id, sqlc.narg('viewer_id'))
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+AND (sqlc.narg('author_id')::uuid IS NOT NULL
+    OR NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = sqlc.narg('viewer_id') AND muted_id = chirps.user_id))
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
 
 -- name: GetChirp :one
 SELECT * FROM chirps WHERE id = $1;
 
+-- name: ListChirpsByIDs :many
+SELECT * FROM chirps WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: UpdateChirpBody :one
+-- keeps the previous body in chirp_revisions, stamped with the time it was written
+WITH prev AS (
+    SELECT id, body, updated_at FROM chirps
+    WHERE chirps.id = sqlc.arg('id') AND chirps.user_id = sqlc.arg('user_id')
+    FOR UPDATE
+), revision AS (
+    INSERT INTO chirp_revisions(id, chirp_id, body, created_at)
+    SELECT gen_random_uuid(), prev.id, prev.body, prev.updated_at FROM prev
+)
+UPDATE chirps
+SET body = sqlc.arg('body'),
+    updated_at = NOW(),
+    revision_count = chirps.revision_count + 1
+FROM prev
+WHERE chirps.id = prev.id
+RETURNING chirps.*;
+
 -- name: DeleteChirpByID :exec
-DELETE FROM chirps WHERE id = $1 AND user_id = $2;
\ No newline at end of file
+-- chirps with replies, quotes or bookmarks become tombstones so the thread below them,
+-- the quotes and the bookmarks survive
+WITH tombstone AS (
+    UPDATE chirps
+    SET body = '', deleted_at = NOW(), updated_at = NOW()
+    WHERE chirps.id = $1 AND chirps.user_id = $2
+    AND (EXISTS (SELECT 1 FROM chirps AS reply WHERE reply.in_reply_to = $1)
+        OR EXISTS (SELECT 1 FROM chirps AS quote WHERE quote.rechirp_of = $1 AND quote.body <> '')
+        OR EXISTS (SELECT 1 FROM bookmarks WHERE bookmarks.chirp_id = $1))
+    RETURNING chirps.id
+)
+DELETE FROM chirps
+WHERE chirps.id = $1 AND chirps.user_id = $2
+AND NOT EXISTS (SELECT 1 FROM tombstone);
+
+-- name: GetChirpAncestors :many
+-- walks in_reply_to up to the conversation root, root first
+WITH RECURSIVE ancestors(id, in_reply_to, depth) AS (
+    SELECT c.id, c.in_reply_to, 0 FROM chirps AS c WHERE c.id = $1
+    UNION ALL
+    SELECT p.id, p.in_reply_to, a.depth + 1
+    FROM chirps AS p
+    JOIN ancestors AS a ON p.id = a.in_reply_to
+)
+SELECT sqlc.embed(chirps) FROM chirps
+JOIN ancestors ON chirps.id = ancestors.id
+WHERE ancestors.depth > 0
+ORDER BY ancestors.depth DESC;
+
+-- name: ListChirpReplies :many
+SELECT * FROM chirps
+WHERE in_reply_to = sqlc.arg('parent_id')
+AND status = 'published'
+AND hidden_at IS NULL
+AND NOT blocked_between(chirps.user_id, sqlc.narg('viewer_id'))
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id'))
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetChirpDescendants :many
+-- replies below the given parents, at most max_depth levels and max_nodes rows;
+-- a reply the viewer may not see takes its whole subtree with it
+WITH RECURSIVE tree(id, depth) AS (
+    SELECT c.id, 1 FROM chirps AS c WHERE c.in_reply_to = ANY(sqlc.arg('parent_ids')::uuid[]) AND c.status = 'published' AND c.hidden_at IS NULL
+    AND chirp_visible_to(c.id, c.user_id, c.visibility, sqlc.narg('viewer_id'))
+    AND NOT blocked_between(c.user_id, sqlc.narg('viewer_id'))
+    UNION ALL
+    SELECT c.id, t.depth + 1
+    FROM chirps AS c
+    JOIN tree AS t ON c.in_reply_to = t.id
+    WHERE t.depth < sqlc.arg('max_depth')::int AND c.status = 'published' AND c.hidden_at IS NULL
+    AND chirp_visible_to(c.id, c.user_id, c.visibility, sqlc.narg('viewer_id'))
+    AND NOT blocked_between(c.user_id, sqlc.narg('viewer_id'))
+)
+SELECT sqlc.embed(chirps), tree.depth::int AS depth FROM chirps
+JOIN tree ON chirps.id = tree.id
+ORDER BY tree.depth, chirps.created_at, chirps.id
+LIMIT sqlc.arg('max_nodes');
+
+-- name: SearchChirps :many
+-- snippet is HTML: the body is escaped first, so <mark> is the only markup in it
+SELECT sqlc.embed(c),
+    ts_rank(c.search_vector, to_tsquery('english', sqlc.arg('query')))::real AS rank,
+    ts_headline('english', html_escape(c.body), to_tsquery('english', sqlc.arg('query')),
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2')::text AS snippet
+FROM chirps AS c
+WHERE c.search_vector @@ to_tsquery('english', sqlc.arg('query'))
+AND c.deleted_at IS NULL
+AND c.status = 'published'
+AND c.hidden_at IS NULL
+AND (sqlc.narg('author_id')::uuid IS NULL OR c.user_id = sqlc.narg('author_id'))
+AND NOT blocked_between(c.user_id, sqlc.narg('viewer_id'))
+AND chirp_visible_to(c.id, c.user_id, c.visibility, sqlc.narg('viewer_id'))
+AND (sqlc.narg('author_id')::uuid IS NOT NULL
+    OR NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = sqlc.narg('viewer_id') AND muted_id = c.user_id))
+AND (sqlc.narg('cursor_rank')::real IS NULL
+    OR (ts_rank(c.search_vector, to_tsquery('english', sqlc.arg('query'))), c.created_at, c.id)
+        < (sqlc.narg('cursor_rank')::real, sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY rank DESC, c.created_at DESC, c.id DESC
+LIMIT sqlc.arg('page_limit');
+-- name: ListUnpublishedChirps :many
+-- drafts and scheduled chirps, only ever listed for their author
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+AND status <> 'published'
+AND deleted_at IS NULL
+AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: PublishChirp :one
+-- publishing stamps created_at, so the chirp shows up at the top of timelines
+WITH published AS (
+    UPDATE chirps
+    SET status = 'published', created_at = NOW(), updated_at = NOW()
+    WHERE chirps.id = sqlc.arg('id') AND chirps.user_id = sqlc.arg('user_id')
+    AND chirps.status <> 'published' AND chirps.deleted_at IS NULL
+    RETURNING chirps.id, chirps.created_at
+), hashtags AS (
+    UPDATE chirp_hashtags SET created_at = published.created_at
+    FROM published WHERE chirp_hashtags.chirp_id = published.id
+), mentions AS (
+    UPDATE chirp_mentions SET created_at = published.created_at
+    FROM published WHERE chirp_mentions.chirp_id = published.id
+)
+SELECT id FROM published;
+
+-- name: PublishDueChirps :many
+-- SKIP LOCKED lets every replica run the scheduler, each due chirp is claimed by exactly one of them
+WITH due AS (
+    SELECT id FROM chirps
+    WHERE status = 'scheduled' AND publish_at <= NOW() AND deleted_at IS NULL
+    -- chirps of suspended users wait, they go out if the suspension is lifted
+    AND user_id NOT IN (SELECT users.id FROM users WHERE suspended_at IS NOT NULL)
+    -- and so do those of users email verification keeps from chirping, until they verify
+    AND (sqlc.narg('unverified_cutoff')::timestamp IS NULL
+        OR user_id NOT IN (SELECT users.id FROM users
+            WHERE email_verified_at IS NULL AND created_at <= sqlc.narg('unverified_cutoff')::timestamp))
+    ORDER BY publish_at
+    LIMIT sqlc.arg('batch_size')
+    FOR UPDATE SKIP LOCKED
+), published AS (
+    -- stamped when it actually goes out, like PublishChirp: a chirp held back past publish_at
+    -- would otherwise be backdated below chirps that readers have already paged past
+    UPDATE chirps
+    SET status = 'published', created_at = NOW(), updated_at = NOW()
+    FROM due
+    WHERE chirps.id = due.id AND chirps.status = 'scheduled'
+    RETURNING chirps.id, chirps.created_at
+), hashtags AS (
+    UPDATE chirp_hashtags SET created_at = published.created_at
+    FROM published WHERE chirp_hashtags.chirp_id = published.id
+), mentions AS (
+    UPDATE chirp_mentions SET created_at = published.created_at
+    FROM published WHERE chirp_mentions.chirp_id = published.id
+)
+SELECT id FROM published;
+
+-- name: GetChirpAudience :one
+-- how the viewer relates to a chirp, for the visibility levels that depend on it
+SELECT
+    EXISTS (SELECT 1 FROM follows WHERE follower_id = sqlc.arg('viewer_id') AND followee_id = sqlc.arg('author_id'))::bool AS is_follower,
+    EXISTS (SELECT 1 FROM chirp_mentions WHERE chirp_id = sqlc.arg('chirp_id') AND user_id = sqlc.arg('viewer_id'))::bool AS is_recipient;
+
+-- name: HideChirp :execrows
+UPDATE chirps SET hidden_at = NOW() WHERE id = $1 AND hidden_at IS NULL;
@@ -0,0 +1,73 @@
+-- name: CreateConversation :one
+INSERT INTO conversations(id, created_at, updated_at)
+VALUES (gen_random_uuid(), NOW(), NOW())
+RETURNING *;
+
+-- name: AddConversationMembers :exec
+INSERT INTO conversation_members(conversation_id, user_id, joined_at)
+SELECT sqlc.arg('conversation_id')::uuid, unnest(sqlc.arg('user_ids')::uuid[]), NOW();
+
+-- name: FindDirectConversation :one
+-- the existing one-to-one conversation between the two users, if any
+SELECT c.* FROM conversations AS c
+JOIN conversation_members AS a ON a.conversation_id = c.id AND a.user_id = sqlc.arg('user_a')
+JOIN conversation_members AS b ON b.conversation_id = c.id AND b.user_id = sqlc.arg('user_b')
+WHERE (SELECT COUNT(*) FROM conversation_members AS m WHERE m.conversation_id = c.id) = 2
+LIMIT 1;
+
+-- name: GetConversationMember :one
+SELECT * FROM conversation_members WHERE conversation_id = $1 AND user_id = $2;
+
+-- name: ListConversationMembers :many
+SELECT * FROM conversation_members
+WHERE conversation_id = ANY(sqlc.arg('conversation_ids')::uuid[])
+ORDER BY conversation_id, joined_at, user_id;
+
+-- name: ListConversations :many
+-- the user's conversations, most recently active first; unread_count leaves out
+-- their own messages and those of users blocked either way
+SELECT sqlc.embed(c), m.last_read_at,
+    (SELECT COUNT(*) FROM messages AS msg
+        WHERE msg.conversation_id = c.id
+        AND msg.sender_id <> m.user_id
+        AND (m.last_read_at IS NULL OR msg.created_at > m.last_read_at)
+        AND NOT blocked_between(msg.sender_id, m.user_id))::bigint AS unread_count
+FROM conversation_members AS m
+JOIN conversations AS c ON c.id = m.conversation_id
+WHERE m.user_id = sqlc.arg('user_id')
+AND (sqlc.narg('cursor_updated_at')::timestamp IS NULL
+    OR (c.updated_at, c.id) < (sqlc.narg('cursor_updated_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY c.updated_at DESC, c.id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: ConversationHasBlock :one
+-- true when the user blocked, or is blocked by, any other member
+SELECT EXISTS (
+    SELECT 1 FROM conversation_members
+    WHERE conversation_id = sqlc.arg('conversation_id')
+    AND user_id <> sqlc.arg('user_id')
+    AND blocked_between(user_id, sqlc.arg('user_id'))
+)::bool AS blocked;
+
+-- name: CreateMessage :one
+WITH touched AS (
+    UPDATE conversations SET updated_at = NOW() WHERE conversations.id = sqlc.arg('conversation_id')
+)
+INSERT INTO messages(id, conversation_id, sender_id, body, created_at)
+VALUES (gen_random_uuid(), sqlc.arg('conversation_id'), sqlc.arg('sender_id'), sqlc.arg('body'), NOW())
+RETURNING *;
+
+-- name: ListMessages :many
+-- newest first; messages from users blocked either way are left out
+SELECT * FROM messages
+WHERE conversation_id = sqlc.arg('conversation_id')
+AND NOT blocked_between(sender_id, sqlc.arg('viewer_id'))
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_members
+SET last_read_at = NOW()
+WHERE conversation_id = $1 AND user_id = $2;
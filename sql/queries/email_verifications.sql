@@ -0,0 +1,19 @@
+-- name: CreateEmailVerification :one
+INSERT INTO email_verifications(id, user_id, email, created_at, expires_at)
+VALUES (gen_random_uuid(), $1, $2, NOW(), $3)
+RETURNING *;
+
+-- name: GetLatestEmailVerification :one
+SELECT * FROM email_verifications
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: UseEmailVerification :one
+-- a link works once and only until it expires; of two concurrent uses one gets no row
+UPDATE email_verifications
+SET used_at = NOW()
+WHERE id = $1
+AND used_at IS NULL
+AND expires_at > NOW()
+RETURNING *;
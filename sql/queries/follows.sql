@@ -0,0 +1,41 @@
+-- name: FollowUser :execrows
+INSERT INTO follows(follower_id, followee_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :execrows
+DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: ListFollowers :many
+SELECT follower_id AS user_id, created_at FROM follows
+WHERE followee_id = sqlc.arg('user_id')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, follower_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, follower_id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: ListFollowing :many
+SELECT followee_id AS user_id, created_at FROM follows
+WHERE follower_id = sqlc.arg('user_id')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, followee_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, followee_id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: ListFolloweeIDs :many
+SELECT followee_id FROM follows WHERE follower_id = $1;
+
+-- name: GetTimeline :many
+-- chirps of everyone the user follows plus their own, newest first
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+AND status = 'published'
+AND hidden_at IS NULL
+AND (user_id = sqlc.arg('user_id')
+    OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg('user_id')))
+AND chirp_visible_to(id, user_id, visibility, sqlc.arg('user_id'))
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = sqlc.arg('user_id') AND muted_id = chirps.user_id)
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
\ No newline at end of file
@@ -0,0 +1,49 @@
+-- name: CreateList :one
+INSERT INTO lists(id, owner_id, name, created_at, updated_at)
+VALUES (gen_random_uuid(), $1, $2, NOW(), NOW())
+RETURNING *;
+
+-- name: GetList :one
+SELECT * FROM lists WHERE id = $1;
+
+-- name: ListLists :many
+SELECT * FROM lists
+WHERE owner_id = sqlc.arg('owner_id')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: DeleteList :execrows
+DELETE FROM lists WHERE id = $1 AND owner_id = $2;
+
+-- name: AddListMember :exec
+INSERT INTO list_members(list_id, user_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveListMember :exec
+DELETE FROM list_members WHERE list_id = $1 AND user_id = $2;
+
+-- name: ListListMembers :many
+SELECT user_id, created_at FROM list_members
+WHERE list_id = sqlc.arg('list_id')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, user_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, user_id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetListChirps :many
+-- the members' chirps, newest first; blocks and visibility apply as anywhere else,
+-- mutes do not since the owner put every member on the list by name
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+AND status = 'published'
+AND hidden_at IS NULL
+AND user_id IN (SELECT user_id FROM list_members WHERE list_id = sqlc.arg('list_id'))
+AND NOT blocked_between(chirps.user_id, sqlc.arg('viewer_id'))
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg('viewer_id'))
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
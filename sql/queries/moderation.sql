@@ -0,0 +1,8 @@
+-- name: ListModerationWords :many
+SELECT * FROM moderation_words ORDER BY word;
+
+-- name: CreateChirpFlag :exec
+-- an edit that trips the same rule again keeps the first flag
+INSERT INTO chirp_flags(chirp_id, rule, match, created_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,80 @@
+-- name: UpsertNotification :one
+-- adds the event to the recipient's unread group, creating it if needed. No row comes back
+-- when there is nothing to do: the actor is the recipient, blocked either way or muted by
+-- them, or already in the group.
+INSERT INTO notifications(id, user_id, kind, chirp_id, created_at, updated_at)
+SELECT gen_random_uuid(), sqlc.arg('user_id')::uuid, sqlc.arg('kind')::text, sqlc.narg('chirp_id')::uuid, NOW(), NOW()
+WHERE sqlc.arg('user_id')::uuid <> sqlc.arg('actor_id')::uuid
+AND NOT blocked_between(sqlc.arg('user_id')::uuid, sqlc.arg('actor_id')::uuid)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes WHERE muter_id = sqlc.arg('user_id')::uuid AND muted_id = sqlc.arg('actor_id')::uuid
+)
+ON CONFLICT (user_id, kind, COALESCE(chirp_id, '00000000-0000-0000-0000-000000000000'::uuid)) WHERE read_at IS NULL
+DO UPDATE SET updated_at = NOW()
+WHERE NOT EXISTS (
+    SELECT 1 FROM notification_actors AS a
+    WHERE a.notification_id = notifications.id AND a.actor_id = sqlc.arg('actor_id')::uuid
+)
+RETURNING id;
+
+-- name: AddNotificationActor :exec
+INSERT INTO notification_actors(notification_id, actor_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: ListNotifications :many
+-- newest activity first; actors blocked since the event are left out of actor_count,
+-- and a group with no actor left is left out altogether
+SELECT sqlc.embed(n),
+    (SELECT COUNT(*) FROM notification_actors AS a
+        WHERE a.notification_id = n.id AND NOT blocked_between(a.actor_id, n.user_id))::bigint AS actor_count
+FROM notifications AS n
+WHERE n.user_id = sqlc.arg('user_id')
+AND EXISTS (
+    SELECT 1 FROM notification_actors AS a
+    WHERE a.notification_id = n.id AND NOT blocked_between(a.actor_id, n.user_id)
+)
+AND (sqlc.narg('cursor_updated_at')::timestamp IS NULL
+    OR (n.updated_at, n.id) < (sqlc.narg('cursor_updated_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY n.updated_at DESC, n.id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetNotification :one
+SELECT sqlc.embed(n),
+    (SELECT COUNT(*) FROM notification_actors AS a
+        WHERE a.notification_id = n.id AND NOT blocked_between(a.actor_id, n.user_id))::bigint AS actor_count
+FROM notifications AS n
+WHERE n.id = $1;
+
+-- name: ListNotificationActors :many
+-- the latest actors of each notification, at most actor_limit per notification
+SELECT ranked.notification_id, u.id, u.handle, u.display_name
+FROM (
+    SELECT a.notification_id, a.actor_id, a.created_at,
+        ROW_NUMBER() OVER (PARTITION BY a.notification_id ORDER BY a.created_at DESC, a.actor_id) AS rank
+    FROM notification_actors AS a
+    JOIN notifications AS n ON n.id = a.notification_id
+    WHERE a.notification_id = ANY(sqlc.arg('notification_ids')::uuid[])
+    AND NOT blocked_between(a.actor_id, n.user_id)
+) AS ranked
+JOIN users AS u ON u.id = ranked.actor_id
+WHERE ranked.rank <= sqlc.arg('actor_limit')
+ORDER BY ranked.notification_id, ranked.created_at DESC, ranked.actor_id;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications AS n
+WHERE n.user_id = $1 AND n.read_at IS NULL
+AND EXISTS (
+    SELECT 1 FROM notification_actors AS a
+    WHERE a.notification_id = n.id AND NOT blocked_between(a.actor_id, n.user_id)
+);
+
+-- name: MarkNotificationsRead :exec
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = sqlc.arg('user_id') AND read_at IS NULL AND id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: MarkAllNotificationsRead :exec
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL;
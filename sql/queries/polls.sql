@@ -0,0 +1,31 @@
+-- name: CreatePoll :exec
+INSERT INTO polls(chirp_id, closes_at, created_at)
+VALUES ($1, $2, NOW());
+
+-- name: CreatePollOptions :exec
+-- options keep the order they were given in
+INSERT INTO poll_options(id, chirp_id, position, label, vote_count)
+SELECT gen_random_uuid(), sqlc.arg('chirp_id')::uuid, o.position - 1, o.label, 0
+FROM unnest(sqlc.arg('labels')::text[]) WITH ORDINALITY AS o(label, position);
+
+-- name: ListPolls :many
+SELECT * FROM polls WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: ListPollOptions :many
+SELECT * FROM poll_options
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: ListPollVotesByUser :many
+SELECT chirp_id, option_id FROM poll_votes
+WHERE user_id = sqlc.arg('user_id')
+AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: CastPollVote :execrows
+-- the option must belong to the chirp's poll and the poll must still be open
+INSERT INTO poll_votes(chirp_id, user_id, option_id, created_at)
+SELECT o.chirp_id, sqlc.arg('user_id')::uuid, o.id, NOW()
+FROM poll_options AS o
+JOIN polls AS p ON p.chirp_id = o.chirp_id
+WHERE o.id = sqlc.arg('option_id') AND o.chirp_id = sqlc.arg('chirp_id') AND p.closes_at > NOW()
+ON CONFLICT DO NOTHING;
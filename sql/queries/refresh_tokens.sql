@@ -4,11 +4,13 @@ VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
 -- name: GetUserFromRefreshToken :one
+-- suspended users keep their tokens but cannot refresh them
 SELECT u.* FROM users AS u
 JOIN refresh_tokens AS rt ON u.id = rt.user_id
 WHERE rt.token = $1
 AND rt.revoked_at IS NULL
-AND rt.expires_at > NOW();
+AND rt.expires_at > NOW()
+AND u.suspended_at IS NULL;
 
 -- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens
@@ -0,0 +1,49 @@
+-- name: CreateReport :one
+INSERT INTO reports(id, reporter_id, user_id, chirp_id, reason, details, created_at, updated_at)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, NOW(), NOW())
+RETURNING *;
+
+-- name: GetReport :one
+SELECT * FROM reports WHERE id = $1;
+
+-- name: ListReports :many
+-- the queue is worked oldest first
+SELECT * FROM reports
+WHERE status = sqlc.arg('status')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg('page_limit');
+
+-- name: ClaimReport :one
+-- claiming is idempotent for the admin holding the claim and fails for anyone else
+UPDATE reports
+SET status = 'claimed',
+    claimed_by = sqlc.arg('admin_id')::uuid,
+    claimed_at = COALESCE(claimed_at, NOW()),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+AND status <> 'resolved'
+AND (claimed_by IS NULL OR claimed_by = sqlc.arg('admin_id'))
+RETURNING *;
+
+-- name: ResolveReport :one
+-- an unclaimed report is claimed by whoever resolves it
+UPDATE reports
+SET status = 'resolved',
+    resolution = sqlc.arg('resolution')::text,
+    claimed_by = sqlc.arg('admin_id')::uuid,
+    claimed_at = COALESCE(claimed_at, NOW()),
+    resolved_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+AND status <> 'resolved'
+AND (claimed_by IS NULL OR claimed_by = sqlc.arg('admin_id'))
+RETURNING *;
+
+-- name: CreateModerationAction :exec
+INSERT INTO moderation_actions(id, report_id, admin_id, action, note, created_at)
+VALUES (gen_random_uuid(), $1, $2::uuid, $3, $4, NOW());
+
+-- name: ListModerationActions :many
+SELECT * FROM moderation_actions WHERE report_id = $1 ORDER BY created_at, id;
@@ -1,14 +1,40 @@
 -- name: CreateUser :one
 INSERT INTO users(id, created_at, updated_at, email, hashed_password)
-VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2)
+VALUES (gen_random_uuid(), NOW(), NOW(), sqlc.arg('email')::text, sqlc.arg('hashed_password'))
 RETURNING *;
 
 -- name: UpdateUserEmailPassword :one
+-- a new address has to be verified again
 UPDATE users
-SET email = $1,
-    hashed_password = $2,
+SET email = sqlc.arg('email')::text,
+    hashed_password = sqlc.arg('hashed_password'),
+    email_verified_at = CASE WHEN email = sqlc.arg('email')::text THEN email_verified_at END,
     updated_at = NOW()
-WHERE id = $3
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: MarkEmailVerified :one
+-- only while the address is still the one the link was sent to
+UPDATE users
+SET email_verified_at = COALESCE(email_verified_at, NOW()),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id') AND email = sqlc.arg('email')::text
+RETURNING *;
+
+-- name: UpdateUserHandle :one
+UPDATE users
+SET handle = $1,
+    updated_at = NOW()
+WHERE id = $2
+RETURNING *;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET display_name = $1,
+    bio = $2,
+    avatar_url = $3,
+    updated_at = NOW()
+WHERE id = $4
 RETURNING *;
 
 -- name: UpdateUserToChirpyRed :exec
@@ -17,8 +43,30 @@ SET is_chirpy_red = TRUE,
     updated_at = NOW()
 WHERE id = $1;
 
+-- name: SuspendUser :execrows
+UPDATE users
+SET suspended_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND suspended_at IS NULL;
+
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = $1;
+
+-- name: GetUserByHandle :one
+-- handles are unique ignoring case, see users_handle_lower_idx
+SELECT * FROM users WHERE LOWER(handle) = LOWER($1);
+
+-- name: GetUserStats :one
+-- the chirp count leaves out drafts, deleted and hidden chirps
+SELECT
+    (SELECT COUNT(*) FROM chirps
+        WHERE chirps.user_id = $1 AND deleted_at IS NULL AND status = 'published' AND hidden_at IS NULL)::bigint AS chirp_count,
+    (SELECT COUNT(*) FROM follows WHERE followee_id = $1)::bigint AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follower_id = $1)::bigint AS following_count;
+
 -- name: GetUserByEmail :one
-SELECT * FROM users WHERE email = $1;
+-- remote users have no email, only an actor_uri
+SELECT * FROM users WHERE email = sqlc.arg('email')::text;
 
 -- name: ResetUsers :exec
 TRUNCATE TABLE users CASCADE;
\ No newline at end of file
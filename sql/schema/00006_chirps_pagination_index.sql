@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX chirps_created_at_id_idx ON chirps(created_at, id);
+CREATE INDEX chirps_user_id_created_at_id_idx ON chirps(user_id, created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX chirps_user_id_created_at_id_idx;
+DROP INDEX chirps_created_at_id_idx;
+-- +goose StatementEnd
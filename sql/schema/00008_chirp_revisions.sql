@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chirps ADD COLUMN revision_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE chirp_revisions(
+    id UUID DEFAULT gen_random_uuid(),
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(id)
+);
+
+CREATE INDEX chirp_revisions_chirp_id_created_at_idx ON chirp_revisions(chirp_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE chirp_revisions;
+ALTER TABLE chirps DROP COLUMN revision_count;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chirps ADD COLUMN in_reply_to UUID REFERENCES chirps(id) ON DELETE SET NULL;
+ALTER TABLE chirps ADD COLUMN conversation_id UUID;
+ALTER TABLE chirps ADD COLUMN deleted_at TIMESTAMP;
+
+UPDATE chirps SET conversation_id = id WHERE conversation_id IS NULL;
+ALTER TABLE chirps ALTER COLUMN conversation_id SET NOT NULL;
+
+CREATE INDEX chirps_in_reply_to_created_at_id_idx ON chirps(in_reply_to, created_at, id);
+CREATE INDEX chirps_conversation_id_idx ON chirps(conversation_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX chirps_conversation_id_idx;
+DROP INDEX chirps_in_reply_to_created_at_id_idx;
+ALTER TABLE chirps DROP COLUMN deleted_at;
+ALTER TABLE chirps DROP COLUMN conversation_id;
+ALTER TABLE chirps DROP COLUMN in_reply_to;
+-- +goose StatementEnd
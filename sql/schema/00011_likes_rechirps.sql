@@ -0,0 +1,69 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chirps ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE chirps ADD COLUMN rechirp_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE chirps ADD COLUMN rechirp_of UUID REFERENCES chirps(id) ON DELETE CASCADE;
+
+-- a plain rechirp (empty body) can only happen once per user, quotes are unlimited
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of_idx ON chirps(user_id, rechirp_of)
+    WHERE rechirp_of IS NOT NULL AND body = '';
+
+CREATE TABLE chirp_likes(
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(user_id, chirp_id)
+);
+
+CREATE INDEX chirp_likes_chirp_id_idx ON chirp_likes(chirp_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- counters are kept by triggers so every write path (including cascades) stays consistent
+CREATE FUNCTION chirp_likes_count() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE chirps SET like_count = like_count + 1 WHERE id = NEW.chirp_id;
+    ELSE
+        UPDATE chirps SET like_count = like_count - 1 WHERE id = OLD.chirp_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER chirp_likes_count AFTER INSERT OR DELETE ON chirp_likes
+    FOR EACH ROW EXECUTE FUNCTION chirp_likes_count();
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION chirps_rechirp_count() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' AND NEW.rechirp_of IS NOT NULL THEN
+        UPDATE chirps SET rechirp_count = rechirp_count + 1 WHERE id = NEW.rechirp_of;
+    ELSIF TG_OP = 'DELETE' AND OLD.rechirp_of IS NOT NULL THEN
+        UPDATE chirps SET rechirp_count = rechirp_count - 1 WHERE id = OLD.rechirp_of;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER chirps_rechirp_count AFTER INSERT OR DELETE ON chirps
+    FOR EACH ROW EXECUTE FUNCTION chirps_rechirp_count();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER chirps_rechirp_count ON chirps;
+DROP FUNCTION chirps_rechirp_count;
+DROP TRIGGER chirp_likes_count ON chirp_likes;
+DROP FUNCTION chirp_likes_count;
+DROP TABLE chirp_likes;
+DROP INDEX chirps_user_id_rechirp_of_idx;
+ALTER TABLE chirps DROP COLUMN rechirp_of;
+ALTER TABLE chirps DROP COLUMN rechirp_count;
+ALTER TABLE chirps DROP COLUMN like_count;
+-- +goose StatementEnd
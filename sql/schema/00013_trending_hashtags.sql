@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX chirp_hashtags_created_at_idx ON chirp_hashtags(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX chirp_hashtags_created_at_idx;
+-- +goose StatementEnd
@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE polls(
+    chirp_id UUID PRIMARY KEY REFERENCES chirps(id) ON DELETE CASCADE,
+    closes_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE poll_options(
+    id UUID PRIMARY KEY,
+    chirp_id UUID NOT NULL REFERENCES polls(chirp_id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    label TEXT NOT NULL,
+    vote_count INTEGER NOT NULL DEFAULT 0,
+    UNIQUE(chirp_id, position)
+);
+
+-- one vote per user per poll, whichever option it went to
+CREATE TABLE poll_votes(
+    chirp_id UUID NOT NULL REFERENCES polls(chirp_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    option_id UUID NOT NULL REFERENCES poll_options(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(chirp_id, user_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION poll_votes_count() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE poll_options SET vote_count = vote_count + 1 WHERE id = NEW.option_id;
+    ELSE
+        UPDATE poll_options SET vote_count = vote_count - 1 WHERE id = OLD.option_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER poll_votes_count AFTER INSERT OR DELETE ON poll_votes
+    FOR EACH ROW EXECUTE FUNCTION poll_votes_count();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER poll_votes_count ON poll_votes;
+DROP FUNCTION poll_votes_count;
+DROP TABLE poll_votes;
+DROP TABLE poll_options;
+DROP TABLE polls;
+-- +goose StatementEnd
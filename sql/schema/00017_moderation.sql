@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- words added at runtime, merged over the ones from MODERATION_CONFIG / MODERATION_WORDS
+CREATE TABLE moderation_words(
+    word TEXT PRIMARY KEY,
+    action TEXT NOT NULL CHECK (action IN ('flag', 'mask', 'reject')),
+    created_at TIMESTAMP NOT NULL
+);
+
+-- chirps that went out but tripped a rule set to flag
+CREATE TABLE chirp_flags(
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    rule TEXT NOT NULL,
+    match TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(chirp_id, rule)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE chirp_flags;
+DROP TABLE moderation_words;
+-- +goose StatementEnd
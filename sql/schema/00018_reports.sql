@@ -0,0 +1,58 @@
+-- +goose Up
+-- +goose StatementBegin
+-- there is no endpoint that makes an admin, it is granted straight in the database
+ALTER TABLE users
+ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN suspended_at TIMESTAMP;
+
+ALTER TABLE chirps ADD COLUMN hidden_at TIMESTAMP;
+
+-- user_id is the reported user, for a chirp report that is the chirp's author
+CREATE TABLE reports(
+    id UUID PRIMARY KEY,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL
+        CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'sexual', 'self_harm', 'misinformation', 'impersonation', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'claimed', 'resolved')),
+    claimed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    claimed_at TIMESTAMP,
+    resolution TEXT
+        CHECK (resolution IN ('hide_chirp', 'suspend_user', 'dismiss')),
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    CONSTRAINT reports_resolution_check CHECK ((status = 'resolved') = (resolution IS NOT NULL))
+);
+
+-- one unresolved report per reporter and target
+CREATE UNIQUE INDEX reports_unresolved_idx ON reports(reporter_id, user_id, COALESCE(chirp_id, user_id))
+    WHERE status <> 'resolved';
+CREATE INDEX reports_queue_idx ON reports(status, created_at, id);
+
+-- audit trail, kept even when the admin account goes away
+CREATE TABLE moderation_actions(
+    id UUID PRIMARY KEY,
+    report_id UUID NOT NULL REFERENCES reports(id) ON DELETE CASCADE,
+    admin_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL
+        CHECK (action IN ('claim', 'hide_chirp', 'suspend_user', 'dismiss')),
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX moderation_actions_report_id_idx ON moderation_actions(report_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE moderation_actions;
+DROP TABLE reports;
+ALTER TABLE chirps DROP COLUMN hidden_at;
+ALTER TABLE users
+DROP COLUMN suspended_at,
+DROP COLUMN is_admin;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- a block hides both users from each other, a mute only hides muted_id from muter_id
+CREATE TABLE blocks(
+    blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX blocks_blocked_id_idx ON blocks(blocked_id);
+
+CREATE TABLE mutes(
+    muter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(muter_id, muted_id),
+    CHECK (muter_id <> muted_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- not STRICT on purpose: an anonymous (NULL) viewer is blocked by nobody
+CREATE FUNCTION blocked_between(a UUID, b UUID) RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocker_id = a AND blocked_id = b) OR (blocker_id = b AND blocked_id = a)
+    );
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION blocked_between;
+DROP TABLE mutes;
+DROP TABLE blocks;
+-- +goose StatementEnd
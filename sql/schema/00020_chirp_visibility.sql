@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- direct chirps are visible to the users they mention
+ALTER TABLE chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+    CHECK (visibility IN ('public', 'followers', 'private', 'direct'));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- mirrors internal/visibility; a NULL viewer is anonymous and only sees public chirps
+CREATE FUNCTION chirp_visible_to(chirp_id UUID, author_id UUID, visibility TEXT, viewer_id UUID) RETURNS BOOLEAN AS $$
+    SELECT COALESCE(
+        $3 = 'public'
+        OR $2 = $4
+        OR ($3 = 'followers' AND EXISTS (
+            SELECT 1 FROM follows WHERE follower_id = $4 AND followee_id = $2))
+        OR ($3 = 'direct' AND EXISTS (
+            SELECT 1 FROM chirp_mentions WHERE chirp_mentions.chirp_id = $1 AND user_id = $4)),
+        FALSE);
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION chirp_visible_to;
+ALTER TABLE chirps DROP COLUMN visibility;
+-- +goose StatementEnd
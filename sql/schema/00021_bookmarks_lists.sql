@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- a bookmarked chirp is tombstoned rather than deleted, so its bookmarks survive it
+CREATE TABLE bookmarks(
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(user_id, chirp_id)
+);
+
+CREATE INDEX bookmarks_chirp_id_idx ON bookmarks(chirp_id);
+
+-- lists are private to their owner, their members' chirps make up the list's feed
+CREATE TABLE lists(
+    id UUID PRIMARY KEY,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE(owner_id, name)
+);
+
+CREATE TABLE list_members(
+    list_id UUID NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(list_id, user_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE list_members;
+DROP TABLE lists;
+DROP TABLE bookmarks;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- position is deferred so a reorder can swap pins inside one UPDATE
+CREATE TABLE chirp_pins(
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(user_id, chirp_id),
+    UNIQUE(user_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE chirp_pins;
+-- +goose StatementEnd
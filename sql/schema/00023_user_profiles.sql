@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+ADD COLUMN handle TEXT,
+ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+ADD COLUMN bio TEXT NOT NULL DEFAULT '',
+ADD COLUMN avatar_url TEXT NOT NULL DEFAULT '';
+
+-- unique ignoring case: @Alice and @alice are the same user
+CREATE UNIQUE INDEX users_handle_lower_idx ON users(LOWER(handle));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX users_handle_lower_idx;
+ALTER TABLE users
+DROP COLUMN avatar_url,
+DROP COLUMN bio,
+DROP COLUMN display_name,
+DROP COLUMN handle;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- private conversations, kept apart from chirps so no chirp query can ever return a message;
+-- updated_at moves with every new message
+CREATE TABLE conversations(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- last_read_at is the member's read receipt, NULL until they first read the conversation
+CREATE TABLE conversation_members(
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP NOT NULL,
+    last_read_at TIMESTAMP,
+    PRIMARY KEY(conversation_id, user_id)
+);
+
+CREATE INDEX conversation_members_user_id_idx ON conversation_members(user_id);
+
+CREATE TABLE messages(
+    id UUID PRIMARY KEY,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX messages_conversation_id_created_at_id_idx ON messages(conversation_id, created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE messages;
+DROP TABLE conversation_members;
+DROP TABLE conversations;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- one row per group: likes and rechirps group by chirp, follows by recipient, and replies and
+-- mentions by the chirp that replied or mentioned. Only unread groups grow; once read, the next
+-- event starts a new group.
+CREATE TABLE notifications(
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('follow', 'mention', 'reply', 'like', 'rechirp')),
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    read_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX notifications_unread_group_idx
+ON notifications(user_id, kind, COALESCE(chirp_id, '00000000-0000-0000-0000-000000000000'::uuid))
+WHERE read_at IS NULL;
+
+CREATE INDEX notifications_user_id_updated_at_id_idx ON notifications(user_id, updated_at, id);
+
+CREATE TABLE notification_actors(
+    notification_id UUID NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(notification_id, actor_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE notification_actors;
+DROP TABLE notifications;
+-- +goose StatementEnd
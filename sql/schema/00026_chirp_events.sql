@@ -0,0 +1,63 @@
+-- +goose Up
+-- +goose StatementBegin
+-- append-only log of chirps appearing and disappearing, read by the SSE stream; the ids are
+-- what clients resume from with Last-Event-ID. No foreign key, so hard deletes are logged too.
+CREATE TABLE chirp_events(
+    id BIGSERIAL PRIMARY KEY,
+    chirp_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('created', 'deleted')),
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX chirp_events_created_at_idx ON chirp_events(created_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- fires from every write path (CreateChirps, DeleteChirpByID, publishing, moderation), so a
+-- chirp is "created" when it becomes visible to readers and "deleted" when it stops being.
+-- pg_notify is sent at commit, so listeners never see an event before its row.
+CREATE FUNCTION chirps_record_event() RETURNS TRIGGER AS $$
+DECLARE
+    was_visible BOOLEAN := FALSE;
+    is_visible BOOLEAN := FALSE;
+    subject_id UUID;
+    subject_user_id UUID;
+    event_id BIGINT;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        was_visible := OLD.status = 'published' AND OLD.deleted_at IS NULL AND OLD.hidden_at IS NULL;
+        subject_id := OLD.id;
+        subject_user_id := OLD.user_id;
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        is_visible := NEW.status = 'published' AND NEW.deleted_at IS NULL AND NEW.hidden_at IS NULL;
+        subject_id := NEW.id;
+        subject_user_id := NEW.user_id;
+    END IF;
+
+    IF was_visible = is_visible THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO chirp_events(chirp_id, user_id, kind, created_at)
+    VALUES (subject_id, subject_user_id, CASE WHEN is_visible THEN 'created' ELSE 'deleted' END, NOW())
+    RETURNING id INTO event_id;
+
+    PERFORM pg_notify('chirp_events', event_id::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER chirps_record_event AFTER INSERT OR UPDATE OR DELETE ON chirps
+    FOR EACH ROW EXECUTE FUNCTION chirps_record_event();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER chirps_record_event ON chirps;
+DROP FUNCTION chirps_record_event;
+DROP TABLE chirp_events;
+-- +goose StatementEnd
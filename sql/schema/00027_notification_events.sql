@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- tells every replica's listener a notification was added or grew, so the WebSocket
+-- gateway can push it wherever the recipient is connected
+CREATE FUNCTION notifications_notify() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.read_at IS NULL THEN
+        PERFORM pg_notify('notifications', NEW.id::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER notifications_notify AFTER INSERT OR UPDATE OF updated_at ON notifications
+    FOR EACH ROW EXECUTE FUNCTION notifications_notify();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER notifications_notify ON notifications;
+DROP FUNCTION notifications_notify;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the key pair a local user signs deliveries with, made the first time it is needed
+CREATE TABLE actor_keys(
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    public_key_pem TEXT NOT NULL,
+    private_key_pem TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- users on other instances. Each has a users row, with the actor URL as its email and no
+-- password, so follows, likes, blocks and chirps work for them unchanged.
+CREATE TABLE remote_actors(
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    actor_url TEXT UNIQUE NOT NULL,
+    inbox_url TEXT NOT NULL,
+    shared_inbox_url TEXT,
+    key_id TEXT NOT NULL,
+    public_key_pem TEXT NOT NULL,
+    fetched_at TIMESTAMP NOT NULL
+);
+
+-- chirps that arrived through an inbox, by the id of the Note they came from
+CREATE TABLE remote_chirps(
+    chirp_id UUID PRIMARY KEY REFERENCES chirps(id) ON DELETE CASCADE,
+    object_url TEXT UNIQUE NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE remote_chirps;
+DROP TABLE remote_actors;
+DROP TABLE actor_keys;
+-- +goose StatementEnd
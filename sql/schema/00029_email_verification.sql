@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+
+-- accounts made before verification existed keep working, with or without a stored password
+-- hash; remote users have no address to verify
+UPDATE users SET email_verified_at = created_at WHERE id NOT IN (SELECT user_id FROM remote_actors);
+
+-- one row per verification mail. The link carries the id, signed with SECRET_KEY; a link is
+-- good for a single use, and only for the address it was sent to.
+CREATE TABLE email_verifications(
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+
+CREATE INDEX email_verifications_user_id_idx ON email_verifications(user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE email_verifications;
+ALTER TABLE users DROP COLUMN email_verified_at;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- search snippets are HTML, so chirp text has to be escaped before ts_headline adds <mark> to it
+CREATE FUNCTION html_escape(s TEXT) RETURNS TEXT AS $$
+    SELECT replace(replace(replace(replace(replace(s,
+        '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;');
+$$ LANGUAGE sql IMMUTABLE STRICT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION html_escape;
+-- +goose StatementEnd
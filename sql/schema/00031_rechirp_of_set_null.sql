@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- deleting a chirp no longer deletes the quotes of other users with it; DeleteChirpByID keeps a
+-- quoted chirp as a tombstone, so rechirp_of only goes NULL when a row goes some other way
+ALTER TABLE chirps DROP CONSTRAINT chirps_rechirp_of_fkey;
+ALTER TABLE chirps ADD CONSTRAINT chirps_rechirp_of_fkey
+    FOREIGN KEY (rechirp_of) REFERENCES chirps(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE chirps DROP CONSTRAINT chirps_rechirp_of_fkey;
+ALTER TABLE chirps ADD CONSTRAINT chirps_rechirp_of_fkey
+    FOREIGN KEY (rechirp_of) REFERENCES chirps(id) ON DELETE CASCADE;
+-- +goose StatementEnd
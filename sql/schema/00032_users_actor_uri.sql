@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- remote users were stored with their actor URL as the email; give it its own column so that
+-- an email always is one, and a user has exactly one of the two
+ALTER TABLE users ADD COLUMN actor_uri TEXT UNIQUE;
+ALTER TABLE users ALTER COLUMN email DROP NOT NULL;
+UPDATE users SET actor_uri = email, email = NULL
+WHERE id IN (SELECT user_id FROM remote_actors);
+ALTER TABLE users ADD CONSTRAINT users_email_or_actor_uri
+    CHECK ((email IS NULL) <> (actor_uri IS NULL));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP CONSTRAINT users_email_or_actor_uri;
+UPDATE users SET email = actor_uri WHERE actor_uri IS NOT NULL;
+ALTER TABLE users ALTER COLUMN email SET NOT NULL;
+ALTER TABLE users DROP COLUMN actor_uri;
+-- +goose StatementEnd
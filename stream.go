@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/stream"
+)
+
+const (
+	// chirpEventsChannel - the channel chirps_record_event notifies on
+	chirpEventsChannel = "chirp_events"
+	// notificationsChannel - the channel notifications_notify notifies on
+	notificationsChannel = "notifications"
+	// chirpEventRetention - how far back a client can resume with Last-Event-ID
+	chirpEventRetention = 24 * time.Hour
+	// chirpEventBatchSize - events read per query when catching up
+	chirpEventBatchSize int32 = 500
+)
+
+func streamEventFromDB(e database.ChirpEvent) stream.Event {
+	return stream.Event{
+		ID:      e.ID,
+		Kind:    e.Kind,
+		ChirpID: e.ChirpID,
+		UserID:  e.UserID,
+	}
+}
+
+// listenEvents - LISTENs for chirp events and notifications and publishes them to cfg.chirpStream
+// and cfg.notificationStream until ctx is done. Every replica runs one, so a stream sees writes
+// made through any replica.
+func (cfg *apiConfig) listenEvents(ctx context.Context, dbURL string) {
+	listener := pq.NewListener(dbURL, 10*time.Second, time.Minute, func(_ pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("stream: listener %s\n", err)
+		}
+	})
+	defer listener.Close()
+
+	for _, channel := range []string{chirpEventsChannel, notificationsChannel} {
+		if err := listener.Listen(channel); err != nil {
+			log.Printf("stream: cannot listen on %s %s\n", channel, err)
+			return
+		}
+	}
+
+	lastID, err := cfg.db.GetLatestChirpEventID(ctx)
+	if err != nil {
+		log.Printf("stream: failed to get latest event %s\n", err)
+	}
+
+	prune := time.NewTicker(time.Hour)
+	defer prune.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-prune.C:
+			if err := cfg.db.DeleteChirpEventsBefore(ctx, time.Now().Add(-chirpEventRetention)); err != nil {
+				log.Printf("stream: failed to prune events %s\n", err)
+			}
+		case n := <-listener.Notify:
+			//* nil after a reconnect: whatever was notified meanwhile is lost, so catch up from the table;
+			//* missed notifications are still there on the next GET /api/notifications
+			if n == nil {
+				lastID = cfg.publishChirpEventsAfter(ctx, lastID)
+				continue
+			}
+
+			if n.Channel == notificationsChannel {
+				cfg.publishNotification(ctx, n.Extra)
+				continue
+			}
+
+			id, err := strconv.ParseInt(n.Extra, 10, 64)
+			if err != nil {
+				log.Printf("stream: bad notification %q\n", n.Extra)
+				continue
+			}
+			e, err := cfg.db.GetChirpEvent(ctx, id)
+			if err != nil {
+				log.Printf("stream: failed to get event %d %s\n", id, err)
+				continue
+			}
+			cfg.chirpStream.Publish(streamEventFromDB(e))
+			lastID = max(lastID, id)
+		}
+	}
+}
+
+func (cfg *apiConfig) publishNotification(ctx context.Context, payload string) {
+	id, err := uuid.Parse(payload)
+	if err != nil {
+		log.Printf("stream: bad notification %q\n", payload)
+		return
+	}
+	row, err := cfg.db.GetNotification(ctx, id)
+	if err != nil {
+		log.Printf("stream: failed to get notification %s %s\n", id, err)
+		return
+	}
+	cfg.notificationStream.Publish(stream.NotificationEvent{ID: id, UserID: row.Notification.UserID})
+}
+
+// publishChirpEventsAfter - returns the id of the last event published
+func (cfg *apiConfig) publishChirpEventsAfter(ctx context.Context, afterID int64) int64 {
+	for {
+		events, err := cfg.db.ListChirpEventsAfter(ctx, database.ListChirpEventsAfterParams{
+			AfterID:   afterID,
+			PageLimit: chirpEventBatchSize,
+		})
+		if err != nil {
+			log.Printf("stream: failed to catch up after event %d %s\n", afterID, err)
+			return afterID
+		}
+		for _, e := range events {
+			cfg.chirpStream.Publish(streamEventFromDB(e))
+			afterID = e.ID
+		}
+		if int32(len(events)) < chirpEventBatchSize {
+			return afterID
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/trantuvan/chirpy/internal/database"
+)
+
+// withTx - runs fn inside a transaction, rolling back when it returns an error
+func (cfg *apiConfig) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(cfg.db.WithTx(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// isUniqueViolation - reports whether err is a postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
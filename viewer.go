@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trantuvan/chirpy/helpers"
+	"github.com/trantuvan/chirpy/internal/auth"
+	"github.com/trantuvan/chirpy/internal/database"
+	"github.com/trantuvan/chirpy/internal/visibility"
+)
+
+// viewerID - user behind the bearer token on endpoints where auth is optional.
+// No Authorization header means an anonymous viewer; a bad token is still an error.
+func (cfg *apiConfig) viewerID(r *http.Request) (uuid.NullUUID, error) {
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if errors.Is(err, auth.ErrNoAuthHeaderIncluded) {
+		return uuid.NullUUID{}, nil
+	}
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	return uuid.NullUUID{UUID: userID, Valid: true}, nil
+}
+
+var errNotAdmin = errors.New("admin only")
+
+// adminID - like viewerID, but the token is required and has to belong to an admin
+func (cfg *apiConfig) adminID(r *http.Request) (uuid.UUID, error) {
+	tokenJWT, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	userID, err := auth.ValidateJWT(tokenJWT, cfg.secretKey)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	isAdmin, err := cfg.isAdmin(r.Context(), uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !isAdmin {
+		return uuid.Nil, errNotAdmin
+	}
+
+	return userID, nil
+}
+
+// requireActiveUser - the user a write acts for. A suspended user's access token stays valid until
+// it expires, so every write handler checks here after ValidateJWT; on false the response is written.
+func (cfg *apiConfig) requireActiveUser(w http.ResponseWriter, r *http.Request, handler string, userID uuid.UUID) (database.User, bool) {
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err == sql.ErrNoRows {
+		helpers.ResponseWithError(w, http.StatusUnauthorized, fmt.Sprintf("%s: user not exist", handler), err)
+		return database.User{}, false
+	}
+	if err != nil {
+		helpers.ResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s: failed to get user %s", handler, err), err)
+		return database.User{}, false
+	}
+	if user.SuspendedAt.Valid {
+		helpers.ResponseWithError(w, http.StatusForbidden, fmt.Sprintf("%s: user is suspended", handler), nil)
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// requireCanChirp - every path that creates or publishes a chirp of the user checks here, after
+// requireActiveUser; on false the response is written. The scheduler holds chirps back by the
+// same policy, through its Cutoff.
+func (cfg *apiConfig) requireCanChirp(w http.ResponseWriter, handler string, user database.User) bool {
+	if !cfg.emailVerification.CanChirp(user.CreatedAt, user.EmailVerifiedAt.Valid, time.Now()) {
+		helpers.ResponseWithError(w, http.StatusForbidden, fmt.Sprintf("%s: email is not verified", handler), nil)
+		return false
+	}
+	return true
+}
+
+// isAdmin - a suspended admin is not one
+func (cfg *apiConfig) isAdmin(ctx context.Context, viewer uuid.NullUUID) (bool, error) {
+	if !viewer.Valid {
+		return false, nil
+	}
+
+	user, err := cfg.db.GetUserByID(ctx, viewer.UUID)
+	if err != nil {
+		return false, err
+	}
+
+	return user.IsAdmin && !user.SuspendedAt.Valid, nil
+}
+
+// blockedWith - whether the viewer and userID blocked each other, either way round.
+// Anonymous viewers are never blocked.
+func (cfg *apiConfig) blockedWith(ctx context.Context, viewer uuid.NullUUID, userID uuid.UUID) (bool, error) {
+	if !viewer.Valid {
+		return false, nil
+	}
+	return cfg.db.IsBlockedBetween(ctx, database.IsBlockedBetweenParams{
+		UserA: viewer.UUID,
+		UserB: userID,
+	})
+}
+
+// chirpAllows - whether the chirp's visibility level lets the viewer read it;
+// only followers and direct chirps need to look the viewer up
+func (cfg *apiConfig) chirpAllows(ctx context.Context, c database.Chirp, viewer uuid.NullUUID) (bool, error) {
+	level := visibility.Level(c.Visibility)
+	audience := visibility.Audience{Author: viewer.Valid && viewer.UUID == c.UserID}
+
+	if viewer.Valid && !audience.Author && level.NeedsAudience() {
+		row, err := cfg.db.GetChirpAudience(ctx, database.GetChirpAudienceParams{
+			ViewerID: viewer.UUID,
+			AuthorID: c.UserID,
+			ChirpID:  c.ID,
+		})
+		if err != nil {
+			return false, err
+		}
+		audience.Follower = row.IsFollower
+		audience.Recipient = row.IsRecipient
+	}
+
+	return level.Allows(audience), nil
+}
+
+// chirpWithheldFrom - a block either way or a visibility level that leaves the viewer out;
+// both are answered with a 404 so the chirp's existence does not leak
+func (cfg *apiConfig) chirpWithheldFrom(ctx context.Context, c database.Chirp, viewer uuid.NullUUID) (bool, error) {
+	blocked, err := cfg.blockedWith(ctx, viewer, c.UserID)
+	if err != nil || blocked {
+		return blocked, err
+	}
+
+	allowed, err := cfg.chirpAllows(ctx, c, viewer)
+	return !allowed, err
+}
+
+// chirpVisibleTo - drafts, scheduled and hidden chirps exist only for their author
+// (admins see hidden ones through handlerGetChirp), deleted ones for nobody
+func chirpVisibleTo(c database.Chirp, viewer uuid.NullUUID) bool {
+	if c.DeletedAt.Valid {
+		return false
+	}
+	if viewer.Valid && viewer.UUID == c.UserID {
+		return true
+	}
+	return c.Status == chirpStatusPublished && !c.HiddenAt.Valid
+}
+
+// hydrateChirps - everything on a Chirp that does not come from the chirps row itself
+func (cfg *apiConfig) hydrateChirps(ctx context.Context, viewer uuid.NullUUID, chirps ...*Chirp) error {
+	originals, err := cfg.attachOriginals(ctx, viewer, chirps...)
+	if err != nil {
+		return err
+	}
+	chirps = slices.Concat(chirps, originals)
+
+	if err := cfg.markLikedByMe(ctx, viewer, chirps...); err != nil {
+		return err
+	}
+	if err := cfg.attachPolls(ctx, viewer, chirps...); err != nil {
+		return err
+	}
+	return cfg.attachMedia(ctx, chirps...)
+}
+
+// markLikedByMe - fills Chirp.LikedByMe with one query for the whole page
+func (cfg *apiConfig) markLikedByMe(ctx context.Context, viewer uuid.NullUUID, chirps ...*Chirp) error {
+	if !viewer.Valid || len(chirps) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, len(chirps))
+	for i, c := range chirps {
+		chirpIDs[i] = c.ID
+	}
+
+	liked, err := cfg.db.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+		UserID:   viewer.UUID,
+		ChirpIds: chirpIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	likedSet := make(map[uuid.UUID]struct{}, len(liked))
+	for _, id := range liked {
+		likedSet[id] = struct{}{}
+	}
+
+	for _, c := range chirps {
+		_, c.LikedByMe = likedSet[c.ID]
+	}
+
+	return nil
+}
+
+func chirpRefs(chirps []Chirp) []*Chirp {
+	refs := make([]*Chirp, len(chirps))
+	for i := range chirps {
+		refs[i] = &chirps[i]
+	}
+	return refs
+}